@@ -3,10 +3,128 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"zabbix-dna/internal/api"
 
 	"github.com/spf13/cobra"
 )
 
+// actionSources maps spec names of event sources to their Zabbix codes.
+var actionSources = map[string]string{
+	"trigger":          "0",
+	"discovery":        "1",
+	"autoregistration": "2",
+	"internal":         "3",
+}
+
+// actionConditionTypes maps spec names of action condition types to their Zabbix codes.
+var actionConditionTypes = map[string]string{
+	"hostgroup":        "0",
+	"host":             "1",
+	"trigger":          "2",
+	"event_name":       "3",
+	"severity":         "4",
+	"time_period":      "6",
+	"host_ip":          "7",
+	"service_type":     "8",
+	"service_port":     "9",
+	"discovery_status": "10",
+	"uptime":           "11",
+	"received_value":   "12",
+	"template":         "13",
+	"suppressed":       "16",
+	"drule":            "18",
+	"dcheck":           "19",
+	"proxy":            "20",
+	"discovery_object": "21",
+	"host_name":        "22",
+	"event_type":       "23",
+	"host_metadata":    "24",
+	"tag":              "25",
+	"tag_value":        "26",
+	"service":          "27",
+	"service_name":     "28",
+}
+
+// actionConditionLabels are the frontend labels of condition types.
+var actionConditionLabels = map[string]string{
+	"0": "Host group", "1": "Host", "2": "Trigger", "3": "Event name", "4": "Trigger severity",
+	"6": "Time period", "7": "Host IP", "8": "Service type", "9": "Service port",
+	"10": "Discovery status", "11": "Uptime/Downtime", "12": "Received value", "13": "Template",
+	"16": "Problem is suppressed", "18": "Discovery rule", "19": "Discovery check", "20": "Proxy",
+	"21": "Discovery object", "22": "Host name", "23": "Event type", "24": "Host metadata",
+	"25": "Tag name", "26": "Tag value", "27": "Service", "28": "Service name",
+}
+
+// actionConditionObjects are the condition types whose value is the ID of an object.
+var actionConditionObjects = map[string]string{
+	"0": "hostgroup", "1": "host", "2": "trigger", "13": "template",
+	"18": "drule", "20": "proxy", "27": "service",
+}
+
+// actionOperators maps spec names of condition operators to their Zabbix codes.
+var actionOperators = map[string]string{
+	"equals":       "0",
+	"=":            "0",
+	"not equals":   "1",
+	"<>":           "1",
+	"contains":     "2",
+	"like":         "2",
+	"not contains": "3",
+	"not like":     "3",
+	"in":           "4",
+	">=":           "5",
+	"<=":           "6",
+	"not in":       "7",
+	"matches":      "8",
+	"not matches":  "9",
+	"yes":          "10",
+	"no":           "11",
+}
+
+var actionOperatorLabels = map[string]string{
+	"0": "equals", "1": "does not equal", "2": "contains", "3": "does not contain", "4": "in",
+	"5": ">=", "6": "<=", "7": "not in", "8": "matches", "9": "does not match", "10": "yes", "11": "no",
+}
+
+// actionConditionValues maps names of enumerated condition values, by condition type.
+var actionConditionValues = map[string]map[string]string{
+	"8": {
+		"ssh": "0", "ldap": "1", "smtp": "2", "ftp": "3", "http": "4", "pop": "5", "nntp": "6",
+		"imap": "7", "tcp": "8", "zabbix agent": "9", "snmpv1": "10", "snmpv2": "11", "icmp": "12",
+		"snmpv3": "13", "https": "14", "telnet": "15",
+	},
+	"10": {"up": "0", "down": "1", "discovered": "2", "lost": "3"},
+	"21": {"host": "1", "service": "2"},
+	"23": {"item not supported": "0", "lld rule not supported": "2", "trigger unknown": "4"},
+}
+
+// actionOperationTypes maps spec names of operation types to their Zabbix codes.
+var actionOperationTypes = map[string]string{
+	"message":               "0",
+	"script":                "1",
+	"add_host":              "2",
+	"remove_host":           "3",
+	"add_to_hostgroup":      "4",
+	"remove_from_hostgroup": "5",
+	"link_template":         "6",
+	"unlink_template":       "7",
+	"enable_host":           "8",
+	"disable_host":          "9",
+	"inventory_mode":        "10",
+	"notify_all":            "11",
+}
+
+// inventoryModes maps inventory mode names to their Zabbix codes.
+var inventoryModes = map[string]string{
+	"disabled":  "-1",
+	"manual":    "0",
+	"automatic": "1",
+}
+
 func newActionCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "action",
@@ -14,6 +132,12 @@ func newActionCmd() *cobra.Command {
 	}
 
 	cmd.AddCommand(newActionListCmd())
+	cmd.AddCommand(newActionShowCmd())
+	cmd.AddCommand(newActionCreateCmd())
+	cmd.AddCommand(newActionUpdateCmd())
+	cmd.AddCommand(newActionStatusCmd("enable", "0"))
+	cmd.AddCommand(newActionStatusCmd("disable", "1"))
+	cmd.AddCommand(newActionSimulateCmd())
 
 	return cmd
 }
@@ -64,6 +188,93 @@ func newActionListCmd() *cobra.Command {
 	return cmd
 }
 
+func newActionShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [action]",
+		Short: "Show the conditions and operations of an action",
+		Long: `Show an action with its conditions and its problem, recovery and update operations.
+Object IDs are shown as names: host groups, hosts, templates, triggers, user groups, users,
+media types and scripts.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			a, err := getAction(client, args[0])
+			handleError(err)
+			names := newActionResolver(client).names(actionReferences([]map[string]interface{}{a}))
+
+			headers := []string{"Property", "Value"}
+			var rows [][]string
+			rows = append(rows, []string{"ActionID", fmt.Sprintf("%v", a["actionid"])})
+			rows = append(rows, []string{"Name", fmt.Sprintf("%v", a["name"])})
+			rows = append(rows, []string{"Source", getEventSourceName(exportString(a, "eventsource"))})
+			rows = append(rows, []string{"Status", actionStatusName(exportString(a, "status"))})
+			if exportString(a, "eventsource") == "0" || exportString(a, "eventsource") == "3" || exportString(a, "eventsource") == "4" {
+				rows = append(rows, []string{"Default step duration", exportString(a, "esc_period")})
+			}
+			if exportString(a, "eventsource") == "0" {
+				rows = append(rows, []string{"Pause for suppressed", yesNo(exportString(a, "pause_suppressed") == "1")})
+				rows = append(rows, []string{"Notify if canceled", yesNo(exportString(a, "notify_if_canceled") == "1")})
+			}
+
+			filter, _ := a["filter"].(map[string]interface{})
+			conditions := sortedActionConditions(exportList(filter["conditions"]))
+			if len(conditions) > 0 {
+				rows = append(rows, []string{"Evaluation", describeActionEvaluation(filter)})
+			}
+			for _, c := range conditions {
+				rows = append(rows, []string{"Condition " + exportString(c, "formulaid"), describeActionCondition(c, names)})
+			}
+
+			for _, op := range exportList(a["operations"]) {
+				rows = append(rows, []string{"Operation " + describeActionSteps(op, exportString(a, "esc_period")), describeActionOperation(op, names)})
+			}
+			for _, op := range exportList(a["recovery_operations"]) {
+				rows = append(rows, []string{"Recovery operation", describeActionOperation(op, names)})
+			}
+			for _, op := range exportList(a["update_operations"]) {
+				rows = append(rows, []string{"Update operation", describeActionOperation(op, names)})
+			}
+
+			outputResult(cmd, a, headers, rows)
+		},
+	}
+}
+
+func newActionStatusCmd(verb, status string) *cobra.Command {
+	return &cobra.Command{
+		Use:   verb + " [action...]",
+		Short: strings.ToUpper(verb[:1]) + verb[1:] + " Zabbix actions",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			headers := []string{"Action", "Status", "Result"}
+			var rows [][]string
+			var responses []interface{}
+			for _, ref := range args {
+				a, err := getAction(client, ref)
+				handleError(err)
+
+				resp, err := client.Call("action.update", map[string]interface{}{
+					"actionid": a["actionid"],
+					"status":   status,
+				})
+				handleError(err)
+
+				var updateResp map[string]interface{}
+				json.Unmarshal(resp, &updateResp)
+				responses = append(responses, updateResp)
+				rows = append(rows, []string{fmt.Sprintf("%v", a["name"]), actionStatusName(status), "Success"})
+			}
+
+			outputResult(cmd, responses, headers, rows)
+		},
+	}
+}
+
 func getEventSourceName(s string) string {
 	switch s {
 	case "0":
@@ -81,4 +292,404 @@ func getEventSourceName(s string) string {
 	}
 }
 
+func actionStatusName(s string) string {
+	if s == "1" {
+		return "Disabled"
+	}
+	return "Enabled"
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
+
+// getAction resolves an action name or ID and returns it with its conditions and operations.
+func getAction(client *api.ZabbixClient, ref string) (map[string]interface{}, error) {
+	filter := map[string]interface{}{"name": ref}
+	if _, err := strconv.Atoi(ref); err == nil {
+		filter = map[string]interface{}{"actionid": ref}
+	}
+	actions, err := getActionsWithOperations(client, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("action not found: %s", ref)
+	}
+	return actions[0], nil
+}
+
+// getActionsWithOperations returns actions with their conditions and all operation kinds.
+func getActionsWithOperations(client *api.ZabbixClient, filter map[string]interface{}) ([]map[string]interface{}, error) {
+	params := map[string]interface{}{
+		"output":                   "extend",
+		"selectFilter":             "extend",
+		"selectOperations":         "extend",
+		"selectRecoveryOperations": "extend",
+		"selectUpdateOperations":   "extend",
+	}
+	if filter != nil {
+		params["filter"] = filter
+	}
+	return callGetList(client, "action.get", params)
+}
+
+// actionOperations returns the problem, recovery and update operations of an action.
+func actionOperations(a map[string]interface{}) []map[string]interface{} {
+	var ops []map[string]interface{}
+	for _, key := range []string{"operations", "recovery_operations", "update_operations"} {
+		ops = append(ops, exportList(a[key])...)
+	}
+	return ops
+}
+
+// actionReferences collects the object IDs actions refer to, by object kind.
+func actionReferences(actions []map[string]interface{}) map[string][]string {
+	seen := map[string]map[string]bool{}
+	refs := map[string][]string{}
+	add := func(kind string, v interface{}) {
+		id := fmt.Sprintf("%v", v)
+		if id == "" || id == "0" || id == "<nil>" {
+			return
+		}
+		if seen[kind] == nil {
+			seen[kind] = map[string]bool{}
+		}
+		if !seen[kind][id] {
+			seen[kind][id] = true
+			refs[kind] = append(refs[kind], id)
+		}
+	}
+	for _, a := range actions {
+		if f, ok := a["filter"].(map[string]interface{}); ok {
+			for _, c := range exportList(f["conditions"]) {
+				if kind, ok := actionConditionObjects[exportString(c, "conditiontype")]; ok {
+					add(kind, c["value"])
+				}
+			}
+		}
+		for _, op := range actionOperations(a) {
+			if msg, ok := op["opmessage"].(map[string]interface{}); ok {
+				add("mediatype", msg["mediatypeid"])
+			}
+			if command, ok := op["opcommand"].(map[string]interface{}); ok {
+				add("script", command["scriptid"])
+			}
+			for _, g := range exportList(op["opmessage_grp"]) {
+				add("usergroup", g["usrgrpid"])
+			}
+			for _, u := range exportList(op["opmessage_usr"]) {
+				add("user", u["userid"])
+			}
+			for _, g := range exportList(op["opcommand_grp"]) {
+				add("hostgroup", g["groupid"])
+			}
+			for _, h := range exportList(op["opcommand_hst"]) {
+				add("host", h["hostid"])
+			}
+			for _, g := range exportList(op["opgroup"]) {
+				add("hostgroup", g["groupid"])
+			}
+			for _, t := range exportList(op["optemplate"]) {
+				add("template", t["templateid"])
+			}
+		}
+	}
+	return refs
+}
+
+// actionObjectKind describes how to look up the objects an action refers to.
+type actionObjectKind struct {
+	method    string
+	idField   string
+	nameField string
+}
+
+var actionObjectKinds = map[string]actionObjectKind{
+	"hostgroup": {"hostgroup.get", "groupid", "name"},
+	"host":      {"host.get", "hostid", "host"},
+	"template":  {"template.get", "templateid", "host"},
+	"trigger":   {"trigger.get", "triggerid", "description"},
+	"usergroup": {"usergroup.get", "usrgrpid", "name"},
+	"user":      {"user.get", "userid", "username"},
+	"mediatype": {"mediatype.get", "mediatypeid", "name"},
+	"script":    {"script.get", "scriptid", "name"},
+	"drule":     {"drule.get", "druleid", "name"},
+	"proxy":     {"proxy.get", "proxyid", "name"},
+	"service":   {"service.get", "serviceid", "name"},
+}
+
+// actionResolver translates between names and IDs of the objects used in actions.
+type actionResolver struct {
+	client *api.ZabbixClient
+	kinds  map[string]actionObjectKind
+}
+
+func newActionResolver(client *api.ZabbixClient) *actionResolver {
+	kinds := map[string]actionObjectKind{}
+	for k, v := range actionObjectKinds {
+		kinds[k] = v
+	}
+	// Proxies were named by their "host" field before Zabbix 7.0.
+	if version := getAPIVersion(client); version != "" && !apiVersionAtLeast(version, 7, 0) {
+		kinds["proxy"] = actionObjectKind{"proxy.get", "proxyid", "host"}
+	}
+	return &actionResolver{client: client, kinds: kinds}
+}
+
+// ids resolves object names, or numeric IDs, of one kind. Triggers are named "host:description".
+func (r *actionResolver) ids(kind string, refs []string) ([]string, error) {
+	k := r.kinds[kind]
+	var ids []string
+	for _, ref := range refs {
+		if _, err := strconv.Atoi(ref); err == nil {
+			ids = append(ids, ref)
+			continue
+		}
+		params := map[string]interface{}{
+			"output": []string{k.idField},
+			"filter": map[string]interface{}{k.nameField: ref},
+		}
+		if kind == "trigger" {
+			host, description, ok := strings.Cut(ref, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid trigger: %s (expected an ID or host:description)", ref)
+			}
+			params["host"] = strings.TrimSpace(host)
+			params["filter"] = map[string]interface{}{"description": strings.TrimSpace(description)}
+		}
+		found, err := callGetList(r.client, k.method, params)
+		if err != nil {
+			return nil, err
+		}
+		if len(found) == 0 {
+			return nil, fmt.Errorf("%s not found: %s", kind, ref)
+		}
+		if len(found) > 1 {
+			return nil, fmt.Errorf("%s %q is ambiguous, use its ID", kind, ref)
+		}
+		ids = append(ids, fmt.Sprintf("%v", found[0][k.idField]))
+	}
+	return ids, nil
+}
+
+func (r *actionResolver) id(kind, ref string) (string, error) {
+	ids, err := r.ids(kind, []string{ref})
+	if err != nil {
+		return "", err
+	}
+	return ids[0], nil
+}
 
+// names looks up the names of referenced objects, by kind and ID. Lookup errors leave IDs unnamed.
+func (r *actionResolver) names(refs map[string][]string) map[string]map[string]string {
+	names := map[string]map[string]string{}
+	for kind, ids := range refs {
+		k := r.kinds[kind]
+		params := map[string]interface{}{
+			"output":        []string{k.idField, k.nameField},
+			k.idField + "s": ids,
+		}
+		if kind == "trigger" {
+			params["selectHosts"] = []string{"host"}
+		}
+		found, _ := callGetList(r.client, k.method, params)
+		names[kind] = map[string]string{}
+		for _, o := range found {
+			name := fmt.Sprintf("%v", o[k.nameField])
+			if hosts := exportList(o["hosts"]); kind == "trigger" && len(hosts) > 0 {
+				name = fmt.Sprintf("%v:%s", hosts[0]["host"], name)
+			}
+			names[kind][fmt.Sprintf("%v", o[k.idField])] = name
+		}
+	}
+	return names
+}
+
+// objectName renders a referenced object by name, or as "<kind> <id> (missing)".
+func objectName(names map[string]map[string]string, kind, id string) string {
+	if name, ok := names[kind][id]; ok {
+		return name
+	}
+	return fmt.Sprintf("%s %s (missing)", kind, id)
+}
+
+func describeActionEvaluation(filter map[string]interface{}) string {
+	switch exportString(filter, "evaltype") {
+	case "1":
+		return "and"
+	case "2":
+		return "or"
+	case "3":
+		return "custom: " + exportString(filter, "formula")
+	}
+	if formula := exportString(filter, "eval_formula"); formula != "" {
+		return "and/or: " + formula
+	}
+	return "and/or"
+}
+
+// describeActionCondition renders a condition as e.g. `Host group equals "Linux servers"`.
+func describeActionCondition(c map[string]interface{}, names map[string]map[string]string) string {
+	ctype := exportString(c, "conditiontype")
+	label := actionConditionLabels[ctype]
+	if label == "" {
+		label = "condition " + ctype
+	}
+	operator := actionOperatorLabels[exportString(c, "operator")]
+	if ctype == "16" {
+		return label + ": " + operator
+	}
+
+	value := exportString(c, "value")
+	switch {
+	case actionConditionObjects[ctype] != "":
+		value = objectName(names, actionConditionObjects[ctype], value)
+	case ctype == "4":
+		value = getPriorityName(value)
+	case actionConditionValues[ctype] != nil:
+		for name, code := range actionConditionValues[ctype] {
+			if code == value {
+				value = name
+			}
+		}
+	}
+	if ctype == "26" {
+		return fmt.Sprintf("Tag %q value %s %q", exportString(c, "value2"), operator, value)
+	}
+	return fmt.Sprintf("%s %s %q", label, operator, value)
+}
+
+// describeActionSteps renders the escalation steps of a problem operation, e.g. "2-4 (every 30m)".
+func describeActionSteps(op map[string]interface{}, defaultPeriod string) string {
+	from := exportString(op, "esc_step_from")
+	to := exportString(op, "esc_step_to")
+	steps := "step " + from
+	switch {
+	case to == "0":
+		steps = "steps " + from + "-∞"
+	case to != from && to != "":
+		steps = "steps " + from + "-" + to
+	}
+	period := exportString(op, "esc_period")
+	if period == "" || period == "0" {
+		period = defaultPeriod + " (default)"
+	}
+	return steps + ", every " + period
+}
+
+// describeActionOperation renders what an operation does, with recipients, media and targets by name.
+func describeActionOperation(op map[string]interface{}, names map[string]map[string]string) string {
+	list := func(kind, key, idField string) []string {
+		var out []string
+		for _, o := range exportList(op[key]) {
+			id := fmt.Sprintf("%v", o[idField])
+			if kind == "host" && id == "0" {
+				out = append(out, "current host")
+				continue
+			}
+			out = append(out, objectName(names, kind, id))
+		}
+		return out
+	}
+
+	var desc string
+	switch exportString(op, "operationtype") {
+	case "0":
+		var to []string
+		if groups := list("usergroup", "opmessage_grp", "usrgrpid"); len(groups) > 0 {
+			to = append(to, "user groups "+strings.Join(groups, ", "))
+		}
+		if users := list("user", "opmessage_usr", "userid"); len(users) > 0 {
+			to = append(to, "users "+strings.Join(users, ", "))
+		}
+		if len(to) == 0 {
+			to = []string{"nobody"}
+		}
+		desc = "Send message to " + strings.Join(to, "; ")
+		desc += describeActionMessage(op, names)
+	case "1":
+		command, _ := op["opcommand"].(map[string]interface{})
+		var on []string
+		on = append(on, list("host", "opcommand_hst", "hostid")...)
+		if groups := list("hostgroup", "opcommand_grp", "groupid"); len(groups) > 0 {
+			on = append(on, "host groups "+strings.Join(groups, ", "))
+		}
+		desc = fmt.Sprintf("Run script %q on %s", objectName(names, "script", exportString(command, "scriptid")), strings.Join(on, ", "))
+	case "2":
+		desc = "Add host"
+	case "3":
+		desc = "Remove host"
+	case "4":
+		desc = "Add to host groups " + strings.Join(list("hostgroup", "opgroup", "groupid"), ", ")
+	case "5":
+		desc = "Remove from host groups " + strings.Join(list("hostgroup", "opgroup", "groupid"), ", ")
+	case "6":
+		desc = "Link templates " + strings.Join(list("template", "optemplate", "templateid"), ", ")
+	case "7":
+		desc = "Unlink templates " + strings.Join(list("template", "optemplate", "templateid"), ", ")
+	case "8":
+		desc = "Enable host"
+	case "9":
+		desc = "Disable host"
+	case "10":
+		inventory, _ := op["opinventory"].(map[string]interface{})
+		mode := exportString(inventory, "inventory_mode")
+		for name, code := range inventoryModes {
+			if code == mode {
+				mode = name
+			}
+		}
+		desc = "Set host inventory mode " + mode
+	case "11", "12":
+		desc = "Notify all involved" + describeActionMessage(op, names)
+	default:
+		desc = "Operation type " + exportString(op, "operationtype")
+	}
+
+	for _, c := range exportList(op["opconditions"]) {
+		// Operation condition 14 is "event acknowledged".
+		if exportString(c, "conditiontype") == "14" {
+			if exportString(c, "value") == "1" {
+				desc += ", only if acknowledged"
+			} else {
+				desc += ", only if not acknowledged"
+			}
+		}
+	}
+	return desc
+}
+
+func describeActionMessage(op map[string]interface{}, names map[string]map[string]string) string {
+	msg, ok := op["opmessage"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	// "Notify all involved" has no media type; it uses the media the problem was sent with.
+	desc := ""
+	if id, ok := msg["mediatypeid"]; ok {
+		desc = " via all media"
+		if id := fmt.Sprintf("%v", id); id != "0" {
+			desc = " via " + objectName(names, "mediatype", id)
+		}
+	}
+	if exportString(msg, "default_msg") == "0" {
+		desc += fmt.Sprintf(" (custom message %q)", exportString(msg, "subject"))
+	}
+	return desc
+}
+
+// sortedActionConditions orders conditions by their formula ID, as the frontend lists them.
+func sortedActionConditions(conditions []map[string]interface{}) []map[string]interface{} {
+	sort.SliceStable(conditions, func(i, j int) bool {
+		a, b := exportString(conditions[i], "formulaid"), exportString(conditions[j], "formulaid")
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+	return conditions
+}
@@ -0,0 +1,545 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"zabbix-dna/internal/api"
+
+	"github.com/spf13/cobra"
+)
+
+// tri is a three-valued truth value: conditions that cannot be evaluated offline are unknown.
+type tri int
+
+const (
+	triFalse tri = iota
+	triUnknown
+	triTrue
+)
+
+func triOf(b bool) tri {
+	if b {
+		return triTrue
+	}
+	return triFalse
+}
+
+func triAnd(a, b tri) tri {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func triOr(a, b tri) tri {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// simulatedEvent is the problem event actions are evaluated against.
+type simulatedEvent struct {
+	hostID     string
+	hostGroups map[string]string // ID -> name
+	triggerID  string
+	templates  map[string]bool // templates the trigger is inherited from
+	eventName  string
+	severity   int // -1 when unknown
+	tags       []map[string]string
+	suppressed bool
+	at         time.Time
+}
+
+type actionSimulation struct {
+	ActionID string `json:"actionid"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Result   string `json:"result"`
+	Details  string `json:"details"`
+}
+
+func newActionSimulateCmd() *cobra.Command {
+	var host string
+	var trigger string
+	var severity string
+	var eventName string
+	var tags []string
+	var suppressed bool
+	var at string
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Show which trigger actions would fire for a problem",
+		Long: `Evaluate the conditions of trigger actions against a problem on a host, described by a
+trigger (which supplies severity, event name, tags and template) and/or by flags, which
+override the trigger. Host tags are added to the event tags, as Zabbix does.
+
+Actions that fire are shown with the operations of their first escalation step. Conditions
+that cannot be evaluated offline (e.g. a time period with macros, or the template condition
+without --trigger) make the result "Maybe".`,
+		Example: `  zabbix-dna action simulate --host db01 --trigger "db01:MySQL is down"
+  zabbix-dna action simulate --host web01 --severity high --tag service=nginx --time "2026-10-18 03:00"`,
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			extraTags, err := parseTagFlags(tags)
+			handleError(err)
+			serverLoc, err := maintenanceServerLocation(cmd, client)
+			handleError(err)
+			now := time.Now().In(serverLoc)
+			if at != "" {
+				t, err := parseTimeSpec(at, now)
+				handleError(err)
+				now = t.In(serverLoc)
+			}
+
+			event, err := buildSimulatedEvent(client, host, trigger)
+			handleError(err)
+			event.at = now
+			event.suppressed = suppressed
+			if severity != "" {
+				n, err := parseSeverity(severity)
+				handleError(err)
+				event.severity = n
+			}
+			if eventName != "" {
+				event.eventName = eventName
+			}
+			event.tags = append(event.tags, extraTags...)
+
+			filter := map[string]interface{}{"eventsource": "0", "status": "0"}
+			if all {
+				delete(filter, "status")
+			}
+			actions, err := getActionsWithOperations(client, filter)
+			handleError(err)
+			names := newActionResolver(client).names(actionReferences(actions))
+
+			var results []actionSimulation
+			headers := []string{"Action", "Status", "Result", "Details"}
+			var rows [][]string
+			for _, a := range actions {
+				s := simulateAction(a, event, names)
+				results = append(results, s)
+				rows = append(rows, []string{s.Name, s.Status, s.Result, s.Details})
+			}
+
+			outputResult(cmd, results, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringVarP(&host, "host", "H", "", "Host of the problem (defaults to the host of --trigger)")
+	cmd.Flags().StringVar(&trigger, "trigger", "", "Trigger ID or host:description")
+	cmd.Flags().StringVar(&severity, "severity", "", "Severity of the problem (overrides the trigger)")
+	cmd.Flags().StringVar(&eventName, "event-name", "", "Event name (overrides the trigger)")
+	cmd.Flags().StringArrayVar(&tags, "tag", []string{}, "Event tag as name=value (repeatable)")
+	cmd.Flags().BoolVar(&suppressed, "suppressed", false, "Simulate a problem suppressed by maintenance")
+	cmd.Flags().StringVar(&at, "time", "", "When the problem starts (default now), e.g. \"2026-10-18 03:00\"")
+	cmd.Flags().BoolVar(&all, "all", false, "Also evaluate disabled actions")
+	addServerTZFlag(cmd)
+
+	return cmd
+}
+
+// buildSimulatedEvent collects the host groups, tags and trigger data of a simulated problem.
+func buildSimulatedEvent(client *api.ZabbixClient, host, trigger string) (*simulatedEvent, error) {
+	if host == "" && trigger == "" {
+		return nil, fmt.Errorf("--host or --trigger is required")
+	}
+	event := &simulatedEvent{severity: -1, hostGroups: map[string]string{}}
+
+	if trigger != "" {
+		triggerID, err := newActionResolver(client).id("trigger", trigger)
+		if err != nil {
+			return nil, err
+		}
+		triggers, err := callGetList(client, "trigger.get", map[string]interface{}{
+			"output":            []string{"triggerid", "description", "event_name", "priority", "templateid"},
+			"triggerids":        []string{triggerID},
+			"expandDescription": true,
+			"selectHosts":       []string{"hostid", "host"},
+			"selectTags":        "extend",
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(triggers) == 0 {
+			return nil, fmt.Errorf("trigger not found: %s", trigger)
+		}
+		t := triggers[0]
+		event.triggerID = triggerID
+		event.eventName = exportString(t, "description")
+		if name := exportString(t, "event_name"); name != "" {
+			event.eventName = name
+		}
+		event.severity, _ = strconv.Atoi(exportString(t, "priority"))
+		for _, tag := range exportList(t["tags"]) {
+			event.tags = append(event.tags, map[string]string{"tag": exportString(tag, "tag"), "value": exportString(tag, "value")})
+		}
+		if hosts := exportList(t["hosts"]); len(hosts) > 0 && host == "" {
+			host = exportString(hosts[0], "host")
+		}
+		event.templates, err = triggerTemplates(client, exportString(t, "templateid"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	groupsParam, groupsKey := "selectGroups", "groups"
+	if apiVersionAtLeast(getAPIVersion(client), 6, 2) {
+		groupsParam, groupsKey = "selectHostGroups", "hostgroups"
+	}
+	hosts, err := callGetList(client, "host.get", map[string]interface{}{
+		"output":     []string{"hostid", "host"},
+		"filter":     map[string]interface{}{"host": host},
+		groupsParam:  []string{"groupid", "name"},
+		"selectTags": "extend",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("host not found: %s", host)
+	}
+	event.hostID = exportString(hosts[0], "hostid")
+	for _, g := range exportList(hosts[0][groupsKey]) {
+		event.hostGroups[exportString(g, "groupid")] = exportString(g, "name")
+	}
+	for _, tag := range exportList(hosts[0]["tags"]) {
+		event.tags = append(event.tags, map[string]string{"tag": exportString(tag, "tag"), "value": exportString(tag, "value")})
+	}
+	return event, nil
+}
+
+// triggerTemplates follows the templateid chain of an inherited trigger up to its origin and
+// returns the templates along the way.
+func triggerTemplates(client *api.ZabbixClient, templateTriggerID string) (map[string]bool, error) {
+	templates := map[string]bool{}
+	for templateTriggerID != "" && templateTriggerID != "0" {
+		parents, err := callGetList(client, "trigger.get", map[string]interface{}{
+			"output":      []string{"triggerid", "templateid"},
+			"triggerids":  []string{templateTriggerID},
+			"selectHosts": []string{"hostid"},
+		})
+		if err != nil || len(parents) == 0 {
+			return templates, err
+		}
+		for _, h := range exportList(parents[0]["hosts"]) {
+			templates[exportString(h, "hostid")] = true
+		}
+		next := exportString(parents[0], "templateid")
+		if next == templateTriggerID {
+			break
+		}
+		templateTriggerID = next
+	}
+	return templates, nil
+}
+
+// simulateAction evaluates an action's conditions against the event.
+func simulateAction(a map[string]interface{}, event *simulatedEvent, names map[string]map[string]string) actionSimulation {
+	s := actionSimulation{
+		ActionID: fmt.Sprintf("%v", a["actionid"]),
+		Name:     fmt.Sprintf("%v", a["name"]),
+		Status:   actionStatusName(exportString(a, "status")),
+	}
+
+	filter, _ := a["filter"].(map[string]interface{})
+	conditions := sortedActionConditions(exportList(filter["conditions"]))
+	values := map[string]tri{}
+	var failed, unknown []string
+	for _, c := range conditions {
+		v := evaluateActionCondition(c, event, names)
+		values[exportString(c, "formulaid")] = v
+		switch v {
+		case triFalse:
+			failed = append(failed, describeActionCondition(c, names))
+		case triUnknown:
+			unknown = append(unknown, describeActionCondition(c, names))
+		}
+	}
+
+	result, err := evaluateActionFilter(filter, conditions, values)
+	switch {
+	case err != nil:
+		s.Result = "Maybe"
+		s.Details = err.Error()
+	case result == triTrue:
+		s.Result = "Fires"
+		var ops []string
+		for _, op := range exportList(a["operations"]) {
+			if exportString(op, "esc_step_from") == "1" {
+				ops = append(ops, describeActionOperation(op, names))
+			}
+		}
+		s.Details = strings.Join(ops, "; ")
+		if event.suppressed && exportString(a, "pause_suppressed") == "1" {
+			s.Details = "paused while suppressed: " + s.Details
+		}
+	case result == triUnknown:
+		s.Result = "Maybe"
+		s.Details = "cannot evaluate: " + strings.Join(unknown, "; ")
+	default:
+		s.Result = "No"
+		s.Details = "not met: " + strings.Join(failed, "; ")
+	}
+	return s
+}
+
+// evaluateActionFilter combines condition results by the action's evaluation type.
+func evaluateActionFilter(filter map[string]interface{}, conditions []map[string]interface{}, values map[string]tri) (tri, error) {
+	evalType := exportString(filter, "evaltype")
+	if evalType == "3" {
+		return evaluateActionFormula(exportString(filter, "formula"), values)
+	}
+
+	result := triTrue
+	if evalType == "2" && len(conditions) > 0 {
+		result = triFalse
+	}
+	// And/or: conditions of the same type are or-ed, different types and-ed.
+	byType := map[string]tri{}
+	var types []string
+	for _, c := range conditions {
+		v := values[exportString(c, "formulaid")]
+		switch evalType {
+		case "1":
+			result = triAnd(result, v)
+		case "2":
+			result = triOr(result, v)
+		default:
+			ctype := exportString(c, "conditiontype")
+			if prev, ok := byType[ctype]; ok {
+				byType[ctype] = triOr(prev, v)
+			} else {
+				byType[ctype] = v
+				types = append(types, ctype)
+			}
+		}
+	}
+	for _, t := range types {
+		result = triAnd(result, byType[t])
+	}
+	return result, nil
+}
+
+// evaluateActionFormula evaluates a custom expression such as "A and (B or not C)".
+func evaluateActionFormula(formula string, values map[string]tri) (tri, error) {
+	var tokens []string
+	for i := 0; i < len(formula); {
+		r := rune(formula[i])
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, string(r))
+			i++
+		case unicode.IsLetter(r):
+			j := i
+			for j < len(formula) && unicode.IsLetter(rune(formula[j])) {
+				j++
+			}
+			tokens = append(tokens, formula[i:j])
+			i = j
+		default:
+			return triUnknown, fmt.Errorf("invalid formula: %s", formula)
+		}
+	}
+
+	pos := 0
+	var expr, term, factor func() (tri, error)
+	expr = func() (tri, error) {
+		v, err := term()
+		for err == nil && pos < len(tokens) && tokens[pos] == "or" {
+			pos++
+			var w tri
+			if w, err = term(); err == nil {
+				v = triOr(v, w)
+			}
+		}
+		return v, err
+	}
+	term = func() (tri, error) {
+		v, err := factor()
+		for err == nil && pos < len(tokens) && tokens[pos] == "and" {
+			pos++
+			var w tri
+			if w, err = factor(); err == nil {
+				v = triAnd(v, w)
+			}
+		}
+		return v, err
+	}
+	factor = func() (tri, error) {
+		if pos >= len(tokens) {
+			return triUnknown, fmt.Errorf("invalid formula: %s", formula)
+		}
+		tok := tokens[pos]
+		pos++
+		switch tok {
+		case "not":
+			v, err := factor()
+			return triTrue - v, err
+		case "(":
+			v, err := expr()
+			if err == nil && (pos >= len(tokens) || tokens[pos] != ")") {
+				err = fmt.Errorf("invalid formula: %s", formula)
+			}
+			pos++
+			return v, err
+		}
+		v, ok := values[tok]
+		if !ok {
+			return triUnknown, fmt.Errorf("formula refers to unknown condition %s", tok)
+		}
+		return v, nil
+	}
+
+	v, err := expr()
+	if err == nil && pos != len(tokens) {
+		err = fmt.Errorf("invalid formula: %s", formula)
+	}
+	return v, err
+}
+
+// evaluateActionCondition evaluates one trigger action condition against the event.
+func evaluateActionCondition(c map[string]interface{}, event *simulatedEvent, names map[string]map[string]string) tri {
+	operator := exportString(c, "operator")
+	value := exportString(c, "value")
+
+	// matches applies equals/contains style operators to a set of candidates: positive
+	// operators need one match, negated operators need none.
+	matches := func(candidates []string) tri {
+		var found bool
+		for _, cand := range candidates {
+			switch operator {
+			case "0", "1":
+				found = found || cand == value
+			case "2", "3":
+				found = found || strings.Contains(cand, value)
+			default:
+				return triUnknown
+			}
+		}
+		if operator == "1" || operator == "3" {
+			return triOf(!found)
+		}
+		return triOf(found)
+	}
+
+	switch exportString(c, "conditiontype") {
+	case "0":
+		if operator != "0" && operator != "1" {
+			return triUnknown
+		}
+		// A host group includes its nested groups.
+		name, ok := names["hostgroup"][value]
+		var in bool
+		for id, group := range event.hostGroups {
+			in = in || id == value || (ok && strings.HasPrefix(group, name+"/"))
+		}
+		return triOf(in == (operator == "0"))
+	case "1":
+		return matches([]string{event.hostID})
+	case "2":
+		if event.triggerID == "" {
+			return triUnknown
+		}
+		return matches([]string{event.triggerID})
+	case "3":
+		if event.eventName == "" {
+			return triUnknown
+		}
+		return matches([]string{event.eventName})
+	case "4":
+		if event.severity < 0 {
+			return triUnknown
+		}
+		want, _ := strconv.Atoi(value)
+		switch operator {
+		case "0":
+			return triOf(event.severity == want)
+		case "1":
+			return triOf(event.severity != want)
+		case "5":
+			return triOf(event.severity >= want)
+		case "6":
+			return triOf(event.severity <= want)
+		}
+		return triUnknown
+	case "6":
+		in, ok := inTimePeriod(value, event.at)
+		if !ok {
+			return triUnknown
+		}
+		if operator == "7" {
+			return triOf(!in)
+		}
+		return triOf(in)
+	case "13":
+		if event.templates == nil {
+			return triUnknown
+		}
+		return matches(sortedKeys(event.templates))
+	case "16":
+		if operator == "11" {
+			return triOf(!event.suppressed)
+		}
+		return triOf(event.suppressed)
+	case "25":
+		var tagNames []string
+		for _, t := range event.tags {
+			tagNames = append(tagNames, t["tag"])
+		}
+		return matches(tagNames)
+	case "26":
+		var tagValues []string
+		for _, t := range event.tags {
+			if t["tag"] == exportString(c, "value2") {
+				tagValues = append(tagValues, t["value"])
+			}
+		}
+		return matches(tagValues)
+	}
+	return triUnknown
+}
+
+// inTimePeriod reports whether t lies in a Zabbix time period such as "1-5,09:00-18:00;6-7,10:00-16:00".
+// ok is false when the period cannot be evaluated, e.g. because it uses macros.
+func inTimePeriod(period string, t time.Time) (in bool, ok bool) {
+	weekday := int(t.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	minute := t.Hour()*60 + t.Minute()
+	for _, part := range strings.Split(period, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		days, hours, found := strings.Cut(part, ",")
+		if !found {
+			return false, false
+		}
+		var dayFrom, dayTo, h1, m1, h2, m2 int
+		if n, _ := fmt.Sscanf(days, "%d-%d", &dayFrom, &dayTo); n == 1 {
+			dayTo = dayFrom
+		} else if n != 2 {
+			return false, false
+		}
+		if n, _ := fmt.Sscanf(hours, "%d:%d-%d:%d", &h1, &m1, &h2, &m2); n != 4 {
+			return false, false
+		}
+		if weekday >= dayFrom && weekday <= dayTo && minute >= h1*60+m1 && minute < h2*60+m2 {
+			in = true
+		}
+	}
+	return in, true
+}
@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluateActionFormula(t *testing.T) {
+	values := map[string]tri{"A": triTrue, "B": triFalse, "C": triFalse, "U": triUnknown}
+	tests := []struct {
+		formula string
+		want    tri
+		err     bool
+	}{
+		{formula: "A", want: triTrue},
+		{formula: "A and B", want: triFalse},
+		{formula: "A or B", want: triTrue},
+		{formula: "not B", want: triTrue},
+		{formula: "not not A", want: triTrue},
+		{formula: "A and (B or not C)", want: triTrue},
+		{formula: "(A and B) or C", want: triFalse},
+		{formula: "B or A and C", want: triFalse},
+		{formula: "A and U", want: triUnknown},
+		{formula: "A or U", want: triTrue},
+		{formula: "B and U", want: triFalse},
+		{formula: "not U", want: triUnknown},
+		{formula: "", err: true},
+		{formula: "A and", err: true},
+		{formula: "(A or B", err: true},
+		{formula: "A B", err: true},
+		{formula: "A and X", err: true},
+		{formula: "A & B", err: true},
+	}
+	for _, tt := range tests {
+		got, err := evaluateActionFormula(tt.formula, values)
+		if tt.err {
+			if err == nil {
+				t.Errorf("evaluateActionFormula(%q) = %v, want error", tt.formula, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("evaluateActionFormula(%q) = %v, %v, want %v", tt.formula, got, err, tt.want)
+		}
+	}
+}
+
+func TestEvaluateActionFilter(t *testing.T) {
+	conditions := []map[string]interface{}{
+		{"formulaid": "A", "conditiontype": "0"},
+		{"formulaid": "B", "conditiontype": "0"},
+		{"formulaid": "C", "conditiontype": "4"},
+	}
+	tests := []struct {
+		name       string
+		filter     map[string]interface{}
+		conditions []map[string]interface{}
+		values     map[string]tri
+		want       tri
+	}{
+		{"and/or same type or-ed", map[string]interface{}{"evaltype": "0"}, conditions, map[string]tri{"A": triFalse, "B": triTrue, "C": triTrue}, triTrue},
+		{"and/or types and-ed", map[string]interface{}{"evaltype": "0"}, conditions, map[string]tri{"A": triFalse, "B": triTrue, "C": triFalse}, triFalse},
+		{"and/or unknown", map[string]interface{}{"evaltype": "0"}, conditions, map[string]tri{"A": triFalse, "B": triFalse, "C": triUnknown}, triFalse},
+		{"and", map[string]interface{}{"evaltype": "1"}, conditions, map[string]tri{"A": triTrue, "B": triFalse, "C": triTrue}, triFalse},
+		{"or", map[string]interface{}{"evaltype": "2"}, conditions, map[string]tri{"A": triFalse, "B": triFalse, "C": triTrue}, triTrue},
+		{"or unknown", map[string]interface{}{"evaltype": "2"}, conditions, map[string]tri{"A": triFalse, "B": triUnknown, "C": triFalse}, triUnknown},
+		{"no conditions", map[string]interface{}{"evaltype": "2"}, nil, nil, triTrue},
+		{"custom formula", map[string]interface{}{"evaltype": "3", "formula": "A and not C"}, conditions, map[string]tri{"A": triTrue, "B": triFalse, "C": triFalse}, triTrue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evaluateActionFilter(tt.filter, tt.conditions, tt.values)
+			if err != nil || got != tt.want {
+				t.Errorf("evaluateActionFilter = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInTimePeriod(t *testing.T) {
+	monday := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	sunday := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		period string
+		at     time.Time
+		in, ok bool
+	}{
+		{"1-5,09:00-18:00", monday, true, true},
+		{"6-7,00:00-24:00", monday, false, true},
+		{"6-7,00:00-24:00", sunday, true, true},
+		{"7,00:00-24:00", sunday, true, true},
+		{"1,09:00-10:00", monday, false, true},
+		{"6-7,10:00-16:00;1-5,09:00-18:00", monday, true, true},
+		{"1-5, 09:00-18:00;", monday, true, true},
+		{"{$WORKING_HOURS}", monday, false, false},
+		{"1-5", monday, false, false},
+		{"1-5,9-18", monday, false, false},
+	}
+	for _, tt := range tests {
+		in, ok := inTimePeriod(tt.period, tt.at)
+		if in != tt.in || ok != tt.ok {
+			t.Errorf("inTimePeriod(%q, %s) = %v, %v, want %v, %v", tt.period, tt.at.Format("Mon 15:04"), in, ok, tt.in, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,556 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// actionSpec is the YAML description of an action used by action create and action update.
+// Objects are referenced by name; omitted fields are left unchanged on update.
+type actionSpec struct {
+	Name               string                `yaml:"name"`
+	Source             string                `yaml:"source"`
+	Enabled            *bool                 `yaml:"enabled"`
+	EscPeriod          string                `yaml:"esc_period"`
+	PauseSuppressed    *bool                 `yaml:"pause_suppressed"`
+	NotifyIfCanceled   *bool                 `yaml:"notify_if_canceled"`
+	EvalType           string                `yaml:"evaltype"`
+	Formula            string                `yaml:"formula"`
+	Conditions         []actionConditionSpec `yaml:"conditions"`
+	Operations         []actionOperationSpec `yaml:"operations"`
+	RecoveryOperations []actionOperationSpec `yaml:"recovery_operations"`
+	UpdateOperations   []actionOperationSpec `yaml:"update_operations"`
+}
+
+type actionConditionSpec struct {
+	ID       string `yaml:"id"` // formula ID, required with evaltype custom
+	Type     string `yaml:"type"`
+	Operator string `yaml:"operator"`
+	Value    string `yaml:"value"`
+	Tag      string `yaml:"tag"` // tag name of a tag_value condition
+}
+
+type actionOperationSpec struct {
+	Type          string   `yaml:"type"`
+	Steps         string   `yaml:"steps"` // "1", "2-4" or "3-" for all further steps
+	StepDuration  string   `yaml:"step_duration"`
+	Groups        []string `yaml:"groups"` // user groups to notify
+	Users         []string `yaml:"users"`
+	MediaType     string   `yaml:"media_type"`
+	Subject       string   `yaml:"subject"`
+	Message       string   `yaml:"message"`
+	Script        string   `yaml:"script"`
+	Hosts         []string `yaml:"hosts"` // "current" is the host of the event
+	HostGroups    []string `yaml:"hostgroups"`
+	Templates     []string `yaml:"templates"`
+	InventoryMode string   `yaml:"inventory_mode"`
+	Acknowledged  *bool    `yaml:"acknowledged"`
+}
+
+// actionSourceConditions lists the condition types each event source supports.
+var actionSourceConditions = map[string][]string{
+	"0": {"hostgroup", "host", "trigger", "event_name", "severity", "time_period", "template", "suppressed", "tag", "tag_value"},
+	"1": {"host_ip", "service_type", "service_port", "discovery_status", "uptime", "received_value", "drule", "dcheck", "proxy", "discovery_object"},
+	"2": {"proxy", "host_name", "host_metadata"},
+	"3": {"hostgroup", "host", "template", "event_type", "tag", "tag_value"},
+}
+
+// actionSourceOperations lists the operation types allowed for problem, recovery and update
+// operations of each event source.
+var actionSourceOperations = map[string]map[string][]string{
+	"0": {
+		"operations":          {"message", "script"},
+		"recovery_operations": {"message", "script", "notify_all"},
+		"update_operations":   {"message", "script", "notify_all"},
+	},
+	"1": {"operations": {"message", "script", "add_host", "remove_host", "add_to_hostgroup", "remove_from_hostgroup", "link_template", "unlink_template", "enable_host", "disable_host", "inventory_mode"}},
+	"2": {"operations": {"message", "script", "add_host", "add_to_hostgroup", "link_template", "disable_host", "inventory_mode"}},
+	"3": {
+		"operations":          {"message"},
+		"recovery_operations": {"message", "notify_all"},
+	},
+}
+
+var actionEvalTypes = map[string]string{
+	"and/or": "0",
+	"and":    "1",
+	"or":     "2",
+	"custom": "3",
+}
+
+func newActionCreateCmd() *cobra.Command {
+	var file string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create an action from a YAML spec",
+		Long: `Create a trigger, discovery, autoregistration or internal action from a YAML spec.
+Host groups, hosts, templates, user groups, users, media types, scripts, discovery rules,
+proxies and services are referenced by name; triggers as "host:description".
+
+  name: Notify DBAs
+  source: trigger            # trigger, discovery, autoregistration or internal
+  esc_period: 30m
+  pause_suppressed: true
+  evaltype: custom           # and/or (default), and, or, custom
+  formula: A and (B or C)
+  conditions:
+    - {id: A, type: hostgroup, value: Databases}
+    - {id: B, type: severity, operator: ">=", value: high}
+    - {id: C, type: tag_value, tag: service, value: mysql}
+  operations:
+    - type: message
+      steps: 1-3             # "1", "2-4", or "5-" for all further steps
+      groups: [DBA]
+      media_type: Email      # all media when omitted
+    - type: script
+      steps: "4"
+      script: Restart MySQL
+      hosts: [current]
+      acknowledged: false    # only while the problem is not acknowledged
+  recovery_operations:
+    - type: notify_all
+
+Operation types: message, script, add_host, remove_host, add_to_hostgroup (hostgroups),
+remove_from_hostgroup, link_template (templates), unlink_template, enable_host, disable_host,
+inventory_mode (manual, automatic or disabled) and notify_all.`,
+		Example: `  zabbix-dna action create -f actions/notify-dba.yaml
+  zabbix-dna action create -f actions/autoreg-linux.yaml --dry-run`,
+		Run: func(cmd *cobra.Command, args []string) {
+			spec, err := loadActionSpec(file)
+			handleError(err)
+			if spec.Name == "" || spec.Source == "" {
+				handleError(fmt.Errorf("%s: name and source are required", file))
+			}
+			source, ok := actionSources[spec.Source]
+			if !ok {
+				handleError(fmt.Errorf("invalid source: %s (use trigger, discovery, autoregistration or internal)", spec.Source))
+			}
+
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			params, err := spec.params(newActionResolver(client), source)
+			handleError(err)
+			params["name"] = spec.Name
+			params["eventsource"] = source
+			if _, ok := params["status"]; !ok {
+				params["status"] = "0"
+			}
+
+			if dryRun {
+				printActionParams(params)
+				return
+			}
+
+			result, err := client.Call("action.create", params)
+			handleError(err)
+
+			var resp map[string]interface{}
+			json.Unmarshal(result, &resp)
+			id := ""
+			if ids, ok := resp["actionids"].([]interface{}); ok && len(ids) > 0 {
+				id = fmt.Sprintf("%v", ids[0])
+			}
+
+			headers := []string{"Name", "Action", "Status", "ID"}
+			rows := [][]string{{spec.Name, "Create", "Success", id}}
+			outputResult(cmd, resp, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "", "YAML spec of the action")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the API request instead of creating the action")
+	cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
+func newActionUpdateCmd() *cobra.Command {
+	var file string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "update [action]",
+		Short: "Update an action from a YAML spec",
+		Long: `Update an action from a YAML spec in the format of action create. Only the fields present
+in the spec change; conditions and each list of operations are replaced as a whole. The
+action is found by the argument, or by the name in the spec; with an argument, a different
+name in the spec renames the action. The event source of an action cannot change.`,
+		Example: `  zabbix-dna action update -f actions/notify-dba.yaml
+  zabbix-dna action update "Notify DBA" -f actions/notify-dba.yaml --dry-run`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			spec, err := loadActionSpec(file)
+			handleError(err)
+			ref := spec.Name
+			if len(args) > 0 {
+				ref = args[0]
+			}
+			if ref == "" {
+				handleError(fmt.Errorf("no action given and %s has no name", file))
+			}
+
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			existing, err := getAction(client, ref)
+			handleError(err)
+			source := exportString(existing, "eventsource")
+			if spec.Source != "" && actionSources[spec.Source] != source {
+				handleError(fmt.Errorf("action %s has source %s; the event source cannot change", ref, strings.ToLower(getEventSourceName(source))))
+			}
+
+			params, err := spec.params(newActionResolver(client), source)
+			handleError(err)
+			params["actionid"] = existing["actionid"]
+			if spec.Name != "" && spec.Name != fmt.Sprintf("%v", existing["name"]) {
+				params["name"] = spec.Name
+			}
+
+			if dryRun {
+				printActionParams(params)
+				return
+			}
+
+			result, err := client.Call("action.update", params)
+			handleError(err)
+
+			var resp map[string]interface{}
+			json.Unmarshal(result, &resp)
+
+			headers := []string{"Name", "Action", "Status", "ID"}
+			rows := [][]string{{fmt.Sprintf("%v", existing["name"]), "Update", "Success", fmt.Sprintf("%v", existing["actionid"])}}
+			outputResult(cmd, resp, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "", "YAML spec of the action")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the API request instead of updating the action")
+	cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
+// loadActionSpec reads an action spec, rejecting unknown fields so typos do not go unnoticed.
+func loadActionSpec(path string) (*actionSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var spec actionSpec
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &spec, nil
+}
+
+func printActionParams(params map[string]interface{}) {
+	data, _ := json.MarshalIndent(params, "", "  ")
+	fmt.Println(string(data))
+}
+
+// params converts the spec to action.create/action.update parameters for an event source.
+func (s *actionSpec) params(r *actionResolver, source string) (map[string]interface{}, error) {
+	params := map[string]interface{}{}
+	if s.Enabled != nil {
+		params["status"] = boolCode(!*s.Enabled)
+	}
+	if s.EscPeriod != "" {
+		if source == "1" || source == "2" {
+			return nil, fmt.Errorf("esc_period is not supported for %s actions", strings.ToLower(getEventSourceName(source)))
+		}
+		params["esc_period"] = s.EscPeriod
+	}
+	for key, v := range map[string]*bool{"pause_suppressed": s.PauseSuppressed, "notify_if_canceled": s.NotifyIfCanceled} {
+		if v == nil {
+			continue
+		}
+		if source != "0" {
+			return nil, fmt.Errorf("%s is only supported for trigger actions", key)
+		}
+		params[key] = boolCode(*v)
+	}
+
+	if s.Conditions != nil || s.EvalType != "" || s.Formula != "" {
+		filter, err := s.filter(r, source)
+		if err != nil {
+			return nil, err
+		}
+		params["filter"] = filter
+	}
+
+	for key, ops := range map[string][]actionOperationSpec{
+		"operations":          s.Operations,
+		"recovery_operations": s.RecoveryOperations,
+		"update_operations":   s.UpdateOperations,
+	} {
+		if ops == nil {
+			continue
+		}
+		allowed, ok := actionSourceOperations[source][key]
+		if !ok && len(ops) > 0 {
+			return nil, fmt.Errorf("%s actions have no %s", strings.ToLower(getEventSourceName(source)), key)
+		}
+		list := []map[string]interface{}{}
+		for i, op := range ops {
+			if !containsString(allowed, op.Type) {
+				return nil, fmt.Errorf("%s[%d]: invalid type %q (expected %s)", key, i, op.Type, strings.Join(allowed, ", "))
+			}
+			o, err := op.params(r, key)
+			if err != nil {
+				return nil, fmt.Errorf("%s[%d]: %w", key, i, err)
+			}
+			list = append(list, o)
+		}
+		params[key] = list
+	}
+
+	return params, nil
+}
+
+func (s *actionSpec) filter(r *actionResolver, source string) (map[string]interface{}, error) {
+	evalType := s.EvalType
+	if evalType == "" {
+		evalType = "and/or"
+	}
+	code, ok := actionEvalTypes[evalType]
+	if !ok {
+		return nil, fmt.Errorf("invalid evaltype: %s (use and/or, and, or or custom)", evalType)
+	}
+	if (code == "3") != (s.Formula != "") {
+		return nil, fmt.Errorf("formula is required with evaltype custom and only allowed with it")
+	}
+
+	filter := map[string]interface{}{"evaltype": code}
+	if code == "3" {
+		filter["formula"] = s.Formula
+	}
+	conditions := []map[string]interface{}{}
+	for i, c := range s.Conditions {
+		if !containsString(actionSourceConditions[source], c.Type) {
+			return nil, fmt.Errorf("conditions[%d]: invalid type %q for %s actions (expected %s)", i, c.Type, strings.ToLower(getEventSourceName(source)), strings.Join(actionSourceConditions[source], ", "))
+		}
+		condition, err := c.params(r)
+		if err != nil {
+			return nil, fmt.Errorf("conditions[%d]: %w", i, err)
+		}
+		if code == "3" {
+			if c.ID == "" {
+				return nil, fmt.Errorf("conditions[%d]: id is required with evaltype custom", i)
+			}
+			condition["formulaid"] = c.ID
+		}
+		conditions = append(conditions, condition)
+	}
+	filter["conditions"] = conditions
+	return filter, nil
+}
+
+func (c *actionConditionSpec) params(r *actionResolver) (map[string]interface{}, error) {
+	ctype := actionConditionTypes[c.Type]
+
+	operator := c.Operator
+	if operator == "" {
+		switch c.Type {
+		case "suppressed":
+			operator = "yes"
+		case "time_period":
+			operator = "in"
+		default:
+			operator = "equals"
+		}
+	}
+	opCode, ok := actionOperators[strings.ToLower(operator)]
+	if !ok {
+		return nil, fmt.Errorf("invalid operator: %s", operator)
+	}
+	condition := map[string]interface{}{
+		"conditiontype": ctype,
+		"operator":      opCode,
+	}
+	if c.Type == "suppressed" {
+		return condition, nil
+	}
+
+	value := c.Value
+	switch {
+	case value == "":
+		return nil, fmt.Errorf("%s condition needs a value", c.Type)
+	case actionConditionObjects[ctype] != "":
+		id, err := r.id(actionConditionObjects[ctype], value)
+		if err != nil {
+			return nil, err
+		}
+		value = id
+	case c.Type == "severity":
+		n, err := parseSeverity(value)
+		if err != nil {
+			return nil, err
+		}
+		value = strconv.Itoa(n)
+	case actionConditionValues[ctype] != nil:
+		if _, err := strconv.Atoi(value); err != nil {
+			code, ok := actionConditionValues[ctype][strings.ToLower(value)]
+			if !ok {
+				return nil, fmt.Errorf("invalid %s: %s", c.Type, value)
+			}
+			value = code
+		}
+	}
+	condition["value"] = value
+
+	if c.Type == "tag_value" {
+		if c.Tag == "" {
+			return nil, fmt.Errorf("tag_value condition needs a tag")
+		}
+		condition["value2"] = c.Tag
+	} else if c.Tag != "" {
+		return nil, fmt.Errorf("tag is only used by tag_value conditions")
+	}
+	return condition, nil
+}
+
+// params converts an operation; kind is operations, recovery_operations or update_operations.
+func (o *actionOperationSpec) params(r *actionResolver, kind string) (map[string]interface{}, error) {
+	opType := actionOperationTypes[o.Type]
+	// "Notify all involved" has its own code in update operations.
+	if o.Type == "notify_all" && kind == "update_operations" {
+		opType = "12"
+	}
+	op := map[string]interface{}{"operationtype": opType}
+
+	if kind == "operations" {
+		from, to, err := parseActionSteps(o.Steps)
+		if err != nil {
+			return nil, err
+		}
+		op["esc_step_from"] = from
+		op["esc_step_to"] = to
+		op["esc_period"] = "0"
+		if o.StepDuration != "" {
+			op["esc_period"] = o.StepDuration
+		}
+		if o.Acknowledged != nil {
+			op["opconditions"] = []map[string]interface{}{{
+				"conditiontype": "14",
+				"operator":      "0",
+				"value":         boolCode(*o.Acknowledged),
+			}}
+		}
+	} else if o.Steps != "" || o.StepDuration != "" || o.Acknowledged != nil {
+		return nil, fmt.Errorf("steps, step_duration and acknowledged only apply to problem operations")
+	}
+
+	refs := func(kind string, names []string, idField string) ([]map[string]interface{}, error) {
+		list := []map[string]interface{}{}
+		for _, name := range names {
+			id := "0"
+			if kind != "host" || name != "current" {
+				var err error
+				if id, err = r.id(kind, name); err != nil {
+					return nil, err
+				}
+			}
+			list = append(list, map[string]interface{}{idField: id})
+		}
+		return list, nil
+	}
+
+	var err error
+	switch o.Type {
+	case "message", "notify_all":
+		msg := map[string]interface{}{"default_msg": "1"}
+		if o.Subject != "" || o.Message != "" {
+			msg["default_msg"] = "0"
+			msg["subject"] = o.Subject
+			msg["message"] = o.Message
+		}
+		if o.Type == "message" {
+			msg["mediatypeid"] = "0"
+			if o.MediaType != "" {
+				if msg["mediatypeid"], err = r.id("mediatype", o.MediaType); err != nil {
+					return nil, err
+				}
+			}
+			if len(o.Groups) == 0 && len(o.Users) == 0 {
+				return nil, fmt.Errorf("message needs groups or users to send to")
+			}
+			if op["opmessage_grp"], err = refs("usergroup", o.Groups, "usrgrpid"); err != nil {
+				return nil, err
+			}
+			if op["opmessage_usr"], err = refs("user", o.Users, "userid"); err != nil {
+				return nil, err
+			}
+		}
+		op["opmessage"] = msg
+	case "script":
+		if o.Script == "" {
+			return nil, fmt.Errorf("script operation needs a script")
+		}
+		if len(o.Hosts) == 0 && len(o.HostGroups) == 0 {
+			return nil, fmt.Errorf("script operation needs hosts or hostgroups to run on")
+		}
+		scriptID, err := r.id("script", o.Script)
+		if err != nil {
+			return nil, err
+		}
+		op["opcommand"] = map[string]interface{}{"scriptid": scriptID}
+		if op["opcommand_hst"], err = refs("host", o.Hosts, "hostid"); err != nil {
+			return nil, err
+		}
+		if op["opcommand_grp"], err = refs("hostgroup", o.HostGroups, "groupid"); err != nil {
+			return nil, err
+		}
+	case "add_to_hostgroup", "remove_from_hostgroup":
+		if len(o.HostGroups) == 0 {
+			return nil, fmt.Errorf("%s needs hostgroups", o.Type)
+		}
+		if op["opgroup"], err = refs("hostgroup", o.HostGroups, "groupid"); err != nil {
+			return nil, err
+		}
+	case "link_template", "unlink_template":
+		if len(o.Templates) == 0 {
+			return nil, fmt.Errorf("%s needs templates", o.Type)
+		}
+		if op["optemplate"], err = refs("template", o.Templates, "templateid"); err != nil {
+			return nil, err
+		}
+	case "inventory_mode":
+		mode, ok := inventoryModes[o.InventoryMode]
+		if !ok {
+			return nil, fmt.Errorf("invalid inventory_mode: %q (use manual, automatic or disabled)", o.InventoryMode)
+		}
+		op["opinventory"] = map[string]interface{}{"inventory_mode": mode}
+	}
+	return op, nil
+}
+
+// parseActionSteps parses "3", "2-4" or "5-" (all further steps) into esc_step_from and esc_step_to.
+func parseActionSteps(steps string) (string, string, error) {
+	if steps == "" {
+		return "1", "1", nil
+	}
+	from, to, isRange := strings.Cut(steps, "-")
+	if !isRange {
+		to = from
+	} else if to == "" {
+		to = "0"
+	}
+	f, err1 := strconv.Atoi(strings.TrimSpace(from))
+	t, err2 := strconv.Atoi(strings.TrimSpace(to))
+	if err1 != nil || err2 != nil || f < 1 || (t != 0 && t < f) {
+		return "", "", fmt.Errorf("invalid steps: %s (expected e.g. 1, 2-4 or 5-)", steps)
+	}
+	return strconv.Itoa(f), strconv.Itoa(t), nil
+}
@@ -0,0 +1,33 @@
+package commands
+
+import "testing"
+
+func TestParseActionSteps(t *testing.T) {
+	tests := []struct {
+		steps    string
+		from, to string
+		err      bool
+	}{
+		{steps: "", from: "1", to: "1"},
+		{steps: "3", from: "3", to: "3"},
+		{steps: "2-4", from: "2", to: "4"},
+		{steps: "5-", from: "5", to: "0"},
+		{steps: " 2 - 4 ", from: "2", to: "4"},
+		{steps: "0", err: true},
+		{steps: "4-2", err: true},
+		{steps: "-3", err: true},
+		{steps: "a", err: true},
+	}
+	for _, tt := range tests {
+		from, to, err := parseActionSteps(tt.steps)
+		if tt.err {
+			if err == nil {
+				t.Errorf("parseActionSteps(%q) = %s, %s, want error", tt.steps, from, to)
+			}
+			continue
+		}
+		if err != nil || from != tt.from || to != tt.to {
+			t.Errorf("parseActionSteps(%q) = %s, %s, %v, want %s, %s", tt.steps, from, to, err, tt.from, tt.to)
+		}
+	}
+}
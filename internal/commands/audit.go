@@ -0,0 +1,566 @@
+package commands
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"zabbix-dna/internal/api"
+
+	"github.com/spf13/cobra"
+)
+
+// auditActions maps audit log action codes to names.
+var auditActions = map[string]string{
+	"0":  "add",
+	"1":  "update",
+	"2":  "delete",
+	"4":  "logout",
+	"7":  "execute",
+	"8":  "login",
+	"9":  "failed-login",
+	"10": "history-clear",
+	"11": "config-refresh",
+	"12": "push",
+}
+
+// auditResources maps audit log resource type codes to names.
+var auditResources = map[string]string{
+	"0":  "user",
+	"3":  "mediatype",
+	"4":  "host",
+	"5":  "action",
+	"6":  "graph",
+	"11": "usergroup",
+	"13": "trigger",
+	"14": "hostgroup",
+	"15": "item",
+	"16": "image",
+	"17": "valuemap",
+	"18": "service",
+	"19": "map",
+	"22": "webscenario",
+	"23": "discoveryrule",
+	"25": "script",
+	"26": "proxy",
+	"27": "maintenance",
+	"28": "regexp",
+	"29": "macro",
+	"30": "template",
+	"31": "triggerprototype",
+	"32": "iconmap",
+	"33": "dashboard",
+	"34": "correlation",
+	"35": "graphprototype",
+	"36": "itemprototype",
+	"37": "hostprototype",
+	"38": "autoregistration",
+	"39": "module",
+	"40": "settings",
+	"41": "housekeeping",
+	"42": "authentication",
+	"43": "templatedashboard",
+	"44": "role",
+	"45": "token",
+	"46": "report",
+	"47": "hanode",
+	"48": "sla",
+	"49": "userdirectory",
+	"50": "templategroup",
+	"51": "connector",
+	"52": "lldrule",
+	"53": "history",
+}
+
+// auditChange is one field change parsed from the details of an audit entry.
+type auditChange struct {
+	Field     string `json:"field"`
+	Operation string `json:"operation"`
+	Old       string `json:"old,omitempty"`
+	New       string `json:"new,omitempty"`
+}
+
+// auditEntry is an audit log record with names resolved and details parsed.
+type auditEntry struct {
+	AuditID      string        `json:"auditid"`
+	Time         time.Time     `json:"time"`
+	Username     string        `json:"username"`
+	IP           string        `json:"ip"`
+	Action       string        `json:"action"`
+	Resource     string        `json:"resource"`
+	ResourceID   string        `json:"resourceid"`
+	ResourceName string        `json:"resourcename"`
+	RecordsetID  string        `json:"recordsetid"`
+	Changes      []auditChange `json:"changes,omitempty"`
+}
+
+func newAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Report who changed what in Zabbix (audit log)",
+	}
+
+	cmd.AddCommand(newAuditLogCmd())
+	cmd.AddCommand(newAuditSummaryCmd())
+
+	return cmd
+}
+
+// auditFilter holds the audit log selection shared by audit log and audit summary.
+type auditFilter struct {
+	since     string
+	until     string
+	users     []string
+	resources []string
+	actions   []string
+	search    string
+}
+
+func (f *auditFilter) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.since, "since", "7d", "Start of the period (7d, -24h, 2006-01-02, ...)")
+	cmd.Flags().StringVar(&f.until, "until", "now", "End of the period")
+	cmd.Flags().StringSliceVar(&f.users, "user", []string{}, "Only entries of these usernames")
+	cmd.Flags().StringSliceVar(&f.resources, "resource", []string{}, "Only these resource types (host, item, trigger, user, ...)")
+	cmd.Flags().StringSliceVar(&f.actions, "action", []string{}, "Only these actions (add, update, delete, login, failed-login, execute, ...)")
+	cmd.Flags().StringVar(&f.search, "name", "", "Only resources whose name contains this text")
+}
+
+// params builds auditlog.get parameters and returns the period.
+func (f *auditFilter) params(now time.Time) (map[string]interface{}, time.Time, time.Time, error) {
+	since := f.since
+	// "--since 7d" reads naturally; treat a bare duration as relative to now.
+	if _, err := parseDurationSpec(since); err == nil {
+		since = "-" + since
+	}
+	start, err := parseTimeSpec(since, now)
+	if err != nil {
+		return nil, start, start, err
+	}
+	end, err := parseTimeSpec(f.until, now)
+	if err != nil {
+		return nil, start, end, err
+	}
+
+	params := map[string]interface{}{
+		"output":    "extend",
+		"time_from": start.Unix(),
+		"time_till": end.Unix(),
+		"sortfield": "clock",
+		"sortorder": "DESC",
+	}
+	filter := map[string]interface{}{}
+	if len(f.users) > 0 {
+		filter["username"] = f.users
+	}
+	if len(f.resources) > 0 {
+		codes, err := auditCodes(auditResources, f.resources, "resource")
+		if err != nil {
+			return nil, start, end, err
+		}
+		filter["resourcetype"] = codes
+	}
+	if len(f.actions) > 0 {
+		codes, err := auditCodes(auditActions, f.actions, "action")
+		if err != nil {
+			return nil, start, end, err
+		}
+		filter["action"] = codes
+	}
+	if len(filter) > 0 {
+		params["filter"] = filter
+	}
+	if f.search != "" {
+		params["search"] = map[string]interface{}{"resourcename": f.search}
+	}
+	return params, start, end, nil
+}
+
+func newAuditLogCmd() *cobra.Command {
+	var filter auditFilter
+	var limit int
+	var details bool
+	var format string
+	var outFile string
+
+	cmd := &cobra.Command{
+		Use:   "log",
+		Short: "Show audit log entries",
+		Long: `Show audit log entries (auditlog.get, Zabbix 5.4+), newest first.
+
+With --details the field changes of each entry are listed below it, as "old → new" for
+updates. CSV output has one line per changed field; JSON output has the parsed changes.`,
+		Example: `  zabbix-dna audit log --since 7d --user jdoe --resource host --action update --details
+  zabbix-dna audit log --since 2026-10-01 --until 2026-11-01 -f csv -O audit-october.csv
+  zabbix-dna audit log --action failed-login --since 24h`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !containsString([]string{"table", "csv", "json"}, format) {
+				handleError(fmt.Errorf("invalid format: %s (expected table, csv or json)", format))
+			}
+			params, _, _, err := filter.params(time.Now())
+			handleError(err)
+
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			entries, err := fetchAuditLog(client, params, limit)
+			handleError(err)
+
+			if format == "table" {
+				headers := []string{"Time", "User", "IP", "Action", "Resource", "Name", "Changes"}
+				var rows [][]string
+				for _, e := range entries {
+					changes := ""
+					if len(e.Changes) > 0 {
+						changes = fmt.Sprintf("%d", len(e.Changes))
+					}
+					rows = append(rows, []string{
+						e.Time.Format("2006-01-02 15:04:05"),
+						e.Username,
+						e.IP,
+						e.Action,
+						e.Resource,
+						e.ResourceName,
+						changes,
+					})
+					if details {
+						for _, c := range e.Changes {
+							rows = append(rows, []string{"", "", "", "", "", "  " + c.Field, formatAuditChange(c)})
+						}
+					}
+				}
+				outputResult(cmd, entries, headers, rows)
+				return
+			}
+
+			w := io.Writer(os.Stdout)
+			if outFile != "" && outFile != "-" {
+				f, err := os.Create(outFile)
+				handleError(err)
+				defer f.Close()
+				w = f
+			}
+			handleError(writeAuditLog(w, format, entries))
+			if outFile != "" && outFile != "-" {
+				fmt.Fprintf(os.Stderr, "%d audit entries written to %s\n", len(entries), outFile)
+			}
+		},
+	}
+
+	filter.addFlags(cmd)
+	cmd.Flags().IntVarP(&limit, "limit", "l", 1000, "Maximum number of entries (0 for all in the period)")
+	cmd.Flags().BoolVar(&details, "details", false, "List the field changes of each entry")
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, csv, json)")
+	cmd.Flags().StringVarP(&outFile, "output-file", "O", "-", "File for csv or json output (default: stdout)")
+
+	return cmd
+}
+
+func newAuditSummaryCmd() *cobra.Command {
+	var filter auditFilter
+	var by string
+
+	cmd := &cobra.Command{
+		Use:   "summary",
+		Short: "Count audit log changes by user and resource type",
+		Long: `Aggregate the audit log of a period: the number of adds, updates, deletes and other
+actions per user and resource type, busiest first. Use --by user or --by resource for a
+single dimension.`,
+		Example: `  zabbix-dna audit summary --since 30d
+  zabbix-dna audit summary --since 2026-10-01 --by user --action add,update,delete`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !containsString([]string{"user,resource", "user", "resource"}, by) {
+				handleError(fmt.Errorf("invalid --by: %s (expected user,resource, user or resource)", by))
+			}
+			params, start, end, err := filter.params(time.Now())
+			handleError(err)
+
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			entries, err := fetchAuditLog(client, params, 0)
+			handleError(err)
+
+			type summaryRow struct {
+				User     string `json:"user,omitempty"`
+				Resource string `json:"resource,omitempty"`
+				Add      int    `json:"add"`
+				Update   int    `json:"update"`
+				Delete   int    `json:"delete"`
+				Other    int    `json:"other"`
+				Total    int    `json:"total"`
+			}
+			index := map[string]*summaryRow{}
+			var summary []*summaryRow
+			for _, e := range entries {
+				row := summaryRow{}
+				if by != "resource" {
+					row.User = e.Username
+				}
+				if by != "user" {
+					row.Resource = e.Resource
+				}
+				key := row.User + "\x00" + row.Resource
+				r, ok := index[key]
+				if !ok {
+					r = &row
+					index[key] = r
+					summary = append(summary, r)
+				}
+				switch e.Action {
+				case "add":
+					r.Add++
+				case "update":
+					r.Update++
+				case "delete":
+					r.Delete++
+				default:
+					r.Other++
+				}
+				r.Total++
+			}
+			sort.SliceStable(summary, func(i, j int) bool {
+				if summary[i].Total != summary[j].Total {
+					return summary[i].Total > summary[j].Total
+				}
+				return summary[i].User+summary[i].Resource < summary[j].User+summary[j].Resource
+			})
+
+			var headers []string
+			if by != "resource" {
+				headers = append(headers, "User")
+			}
+			if by != "user" {
+				headers = append(headers, "Resource")
+			}
+			headers = append(headers, "Add", "Update", "Delete", "Other", "Total")
+			var rows [][]string
+			for _, r := range summary {
+				var row []string
+				if by != "resource" {
+					row = append(row, r.User)
+				}
+				if by != "user" {
+					row = append(row, r.Resource)
+				}
+				row = append(row, strconv.Itoa(r.Add), strconv.Itoa(r.Update), strconv.Itoa(r.Delete), strconv.Itoa(r.Other), strconv.Itoa(r.Total))
+				rows = append(rows, row)
+			}
+
+			if getOutputFormat(cmd) == "table" && len(rows) > 0 {
+				fmt.Printf("Audit log %s – %s: %d entries\n", start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04"), len(entries))
+			}
+			outputResult(cmd, summary, headers, rows)
+		},
+	}
+
+	filter.addFlags(cmd)
+	cmd.Flags().StringVar(&by, "by", "user,resource", "Group by user,resource, user or resource")
+
+	return cmd
+}
+
+// fetchAuditLog reads audit entries newest first, paging backwards in time so periods with
+// more entries than one request returns are complete. A limit of 0 reads the whole period.
+func fetchAuditLog(client *api.ZabbixClient, params map[string]interface{}, limit int) ([]auditEntry, error) {
+	const pageSize = 5000
+	var entries []auditEntry
+	seen := map[string]bool{}
+	add := func(records []map[string]interface{}) int {
+		added := 0
+		for _, r := range records {
+			id := fmt.Sprintf("%v", r["auditid"])
+			if seen[id] || (limit > 0 && len(entries) >= limit) {
+				continue
+			}
+			seen[id] = true
+			entries = append(entries, parseAuditEntry(r))
+			added++
+		}
+		return added
+	}
+	for {
+		page := pageSize
+		if limit > 0 && limit-len(entries) < page {
+			page = limit - len(entries)
+		}
+		params["limit"] = page
+
+		records, err := callGetList(client, "auditlog.get", params)
+		if err != nil {
+			return nil, err
+		}
+		added := add(records)
+		if len(records) < page || added == 0 || (limit > 0 && len(entries) >= limit) {
+			return entries, nil
+		}
+		oldest := parseClock(records[len(records)-1]["clock"])
+		if parseClock(records[0]["clock"]) == oldest {
+			// A whole page shares one second, so paging by clock cannot move past it:
+			// read that second without a limit and continue before it.
+			second := map[string]interface{}{}
+			for k, v := range params {
+				second[k] = v
+			}
+			delete(second, "limit")
+			second["time_from"] = oldest
+			second["time_till"] = oldest
+			records, err := callGetList(client, "auditlog.get", second)
+			if err != nil {
+				return nil, err
+			}
+			add(records)
+			if limit > 0 && len(entries) >= limit {
+				return entries, nil
+			}
+			oldest--
+		}
+		// Continue from the oldest clock seen; entries sharing it are skipped through seen.
+		params["time_till"] = oldest
+	}
+}
+
+func parseAuditEntry(r map[string]interface{}) auditEntry {
+	e := auditEntry{
+		AuditID:      fmt.Sprintf("%v", r["auditid"]),
+		Time:         time.Unix(parseClock(r["clock"]), 0),
+		Username:     exportString(r, "username"),
+		IP:           exportString(r, "ip"),
+		Action:       auditName(auditActions, exportString(r, "action")),
+		Resource:     auditName(auditResources, exportString(r, "resourcetype")),
+		ResourceID:   exportString(r, "resourceid"),
+		ResourceName: exportString(r, "resourcename"),
+		RecordsetID:  exportString(r, "recordsetid"),
+	}
+	e.Changes = parseAuditDetails(exportString(r, "details"))
+	return e
+}
+
+// parseAuditDetails decodes the details of an audit entry, a JSON object mapping fields to
+// ["update", new, old], ["add", value], ["attach", value], ["detach", value] or ["delete"].
+func parseAuditDetails(details string) []auditChange {
+	if details == "" || details == "[]" {
+		return nil
+	}
+	var raw map[string][]interface{}
+	if err := json.Unmarshal([]byte(details), &raw); err != nil {
+		return []auditChange{{Field: "details", Operation: "raw", New: details}}
+	}
+	changes := make([]auditChange, 0, len(raw))
+	for field, v := range raw {
+		if len(v) == 0 {
+			continue
+		}
+		c := auditChange{Field: field, Operation: fmt.Sprintf("%v", v[0])}
+		if len(v) > 1 {
+			c.New = auditValue(v[1])
+		}
+		if len(v) > 2 {
+			c.Old = auditValue(v[2])
+		}
+		changes = append(changes, c)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func auditValue(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case nil:
+		return ""
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// formatAuditChange renders a change as a diff line.
+func formatAuditChange(c auditChange) string {
+	switch c.Operation {
+	case "update":
+		return fmt.Sprintf("%s → %s", quoteAuditValue(c.Old), quoteAuditValue(c.New))
+	case "add", "attach":
+		if c.New == "" {
+			return c.Operation
+		}
+		return "+ " + quoteAuditValue(c.New)
+	case "delete", "detach":
+		if c.New == "" {
+			return c.Operation
+		}
+		return "- " + quoteAuditValue(c.New)
+	}
+	return c.Operation + " " + c.New
+}
+
+func quoteAuditValue(v string) string {
+	if v == "" {
+		return `""`
+	}
+	return v
+}
+
+func writeAuditLog(w io.Writer, format string, entries []auditEntry) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "username", "ip", "action", "resource", "resourceid", "resourcename", "recordsetid", "field", "operation", "old", "new"})
+	for _, e := range entries {
+		base := []string{e.Time.Format(time.RFC3339), e.Username, e.IP, e.Action, e.Resource, e.ResourceID, e.ResourceName, e.RecordsetID}
+		if len(e.Changes) == 0 {
+			cw.Write(append(base, "", "", "", ""))
+			continue
+		}
+		for _, c := range e.Changes {
+			cw.Write(append(append([]string{}, base...), c.Field, c.Operation, c.Old, c.New))
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// auditCodes converts action or resource names (or codes) to codes.
+func auditCodes(names map[string]string, values []string, kind string) ([]string, error) {
+	var codes []string
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if _, ok := names[v]; ok {
+			codes = append(codes, v)
+			continue
+		}
+		found := false
+		for code, name := range names {
+			if name == v {
+				codes = append(codes, code)
+				found = true
+				break
+			}
+		}
+		if !found {
+			var valid []string
+			for _, name := range names {
+				valid = append(valid, name)
+			}
+			sort.Strings(valid)
+			return nil, fmt.Errorf("invalid %s: %s (expected one of %s)", kind, v, strings.Join(valid, ", "))
+		}
+	}
+	return codes, nil
+}
+
+func auditName(names map[string]string, code string) string {
+	if name, ok := names[code]; ok {
+		return name
+	}
+	return code
+}
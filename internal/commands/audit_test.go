@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAuditDetails(t *testing.T) {
+	tests := []struct {
+		name    string
+		details string
+		want    []auditChange
+	}{
+		{"empty", "", nil},
+		{"empty list", "[]", nil},
+		{
+			name:    "update",
+			details: `{"host.name":["update","web01","web-01"]}`,
+			want:    []auditChange{{Field: "host.name", Operation: "update", New: "web01", Old: "web-01"}},
+		},
+		{
+			name:    "sorted by field",
+			details: `{"host.status":["update","1","0"],"host.description":["update","",null]}`,
+			want: []auditChange{
+				{Field: "host.description", Operation: "update"},
+				{Field: "host.status", Operation: "update", New: "1", Old: "0"},
+			},
+		},
+		{
+			name:    "add, attach and delete",
+			details: `{"host.groups[4]":["attach"],"host.tags[12].tag":["add","service"],"host.macros[3]":["delete"]}`,
+			want: []auditChange{
+				{Field: "host.groups[4]", Operation: "attach"},
+				{Field: "host.macros[3]", Operation: "delete"},
+				{Field: "host.tags[12].tag", Operation: "add", New: "service"},
+			},
+		},
+		{
+			name:    "non-string values",
+			details: `{"item.delay":["update",60,{"a":1}]}`,
+			want:    []auditChange{{Field: "item.delay", Operation: "update", New: "60", Old: `{"a":1}`}},
+		},
+		{
+			name:    "entry without operation",
+			details: `{"host.name":[]}`,
+			want:    []auditChange{},
+		},
+		{
+			name:    "not JSON",
+			details: "garbage",
+			want:    []auditChange{{Field: "details", Operation: "raw", New: "garbage"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseAuditDetails(tt.details); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAuditDetails(%s) = %+v, want %+v", tt.details, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,59 +1,616 @@
 package commands
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
+	"zabbix-dna/internal/api"
+
+	"github.com/klauspost/compress/zstd"
 	"github.com/spf13/cobra"
 )
 
+const backupManifestFile = "manifest.json"
+
+// backupObject describes how a configuration object type is enumerated and exported.
+type backupObject struct {
+	Name      string // CLI name, also used as file prefix
+	Method    string // get method used to enumerate IDs
+	IDField   string
+	NameField string
+	Option    string // configuration.export option key
+	Rules     []string
+}
+
+var backupObjects = []backupObject{
+	{"hostgroups", "hostgroup.get", "groupid", "name", "host_groups", []string{"host_groups"}},
+	{"templategroups", "templategroup.get", "groupid", "name", "template_groups", []string{"template_groups"}},
+	{"templates", "template.get", "templateid", "host", "templates",
+		[]string{"templates", "templateLinkage", "templateDashboards", "items", "discoveryRules", "triggers", "graphs", "httptests", "valueMaps"}},
+	{"hosts", "host.get", "hostid", "host", "hosts",
+		[]string{"hosts", "templateLinkage", "items", "discoveryRules", "triggers", "graphs", "httptests", "valueMaps"}},
+	{"maps", "map.get", "sysmapid", "name", "maps", []string{"maps"}},
+	{"mediatypes", "mediatype.get", "mediatypeid", "name", "mediaTypes", []string{"mediaTypes"}},
+	{"images", "image.get", "imageid", "name", "images", []string{"images"}},
+}
+
+type backupManifest struct {
+	CreatedAt     string       `json:"created_at"`
+	ServerVersion string       `json:"server_version"`
+	Format        string       `json:"format"`
+	Compression   string       `json:"compression"`
+	Split         string       `json:"split"`
+	Files         []backupFile `json:"files"`
+}
+
+type backupFile struct {
+	Path    string   `json:"path"`
+	Objects []string `json:"objects"`
+	Names   []string `json:"names"`
+	SHA256  string   `json:"sha256"`
+	Size    int64    `json:"size"`
+}
+
 func newBackupCmd() *cobra.Command {
-	return &cobra.Command{
+	var dir string
+	var objects []string
+	var format string
+	var split string
+	var compress string
+	var keep int
+	var gitRepo string
+
+	cmd := &cobra.Command{
 		Use:   "backup",
 		Short: "Perform a backup of Zabbix configurations",
+		Long: `Export Zabbix configuration objects into a timestamped backup directory.
+
+Each run creates <dir>/zabbix_backup_<timestamp> with the exported files and a
+manifest.json holding checksums and the server version. Use 'restore' to reimport it.
+
+With --git, templates, hosts, actions, media types and global macros are written as
+sorted YAML files into a git repository and committed instead.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			client, err := getZabbixClient(cmd)
 			handleError(err)
 
-			// Export major configuration objects for Zabbix 7.0
-			params := map[string]interface{}{
-				"options": map[string]interface{}{
-					"host_groups":     []string{},
-					"template_groups": []string{},
-					"hosts":           []string{},
-					"templates":       []string{},
-					"maps":            []string{},
-					"mediaTypes":      []string{},
-					"images":          []string{},
-				},
-				"format": "json",
-			}
-
-			result, err := client.Call("configuration.export", params)
-			if err != nil {
-				// Fallback for older versions or simplified export
-				params["options"] = map[string]interface{}{
-					"groups":    []string{},
-					"hosts":     []string{},
-					"templates": []string{},
-				}
-				result, err = client.Call("configuration.export", params)
+			if gitRepo != "" {
+				changes, rev, err := writeGitSnapshot(client, gitRepo)
 				handleError(err)
+				if len(changes) == 0 {
+					outputResult(cmd, fmt.Sprintf("No configuration changes in %s.", gitRepo), nil, nil)
+					return
+				}
+				headers := []string{"Object", "Change", "Commit"}
+				var rows [][]string
+				for _, c := range changes {
+					rows = append(rows, []string{c.Object, c.Status, rev})
+				}
+				outputResult(cmd, changes, headers, rows)
+				return
 			}
 
-			filename := fmt.Sprintf("zabbix_backup_%s.json", time.Now().Format("20060102_150405"))
-			err = os.WriteFile(filename, result, 0644)
+			selected, err := selectBackupObjects(objects)
 			handleError(err)
 
-			headers := []string{"Component", "Status", "Info"}
-			rows := [][]string{
-				{"Zabbix API", "Connected", "OK"},
-				{"Export Format", "JSON", "OK"},
-				{"Configuration", "Full Export", "OK"},
-				{"File Path", "Success", filename},
+			switch split {
+			case "none", "type", "object":
+			default:
+				handleError(fmt.Errorf("invalid split mode: %s (expected none, type or object)", split))
 			}
-			outputResult(cmd, nil, headers, rows)
+			switch format {
+			case "json", "yaml", "xml":
+			default:
+				handleError(fmt.Errorf("invalid format: %s (expected json, yaml or xml)", format))
+			}
+			_, err = backupFileExt(format, compress)
+			handleError(err)
+
+			version := getAPIVersion(client)
+			legacy := version != "" && !apiVersionAtLeast(version, 6, 2)
+
+			setDir, err := createBackupSetDir(dir, "zabbix_backup_")
+			handleError(err)
+
+			manifest := backupManifest{
+				CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+				ServerVersion: version,
+				Format:        format,
+				Compression:   compress,
+				Split:         split,
+			}
+
+			headers := []string{"Object Type", "Objects", "Files", "Status"}
+			var rows [][]string
+			allOptions := make(map[string]interface{})
+			var allNames []string
+			var allTypes []string
+
+			for _, obj := range selected {
+				if legacy && obj.Name == "templategroups" {
+					rows = append(rows, []string{obj.Name, "0", "0", "Skipped (requires 6.2+)"})
+					continue
+				}
+				option := obj.Option
+				if legacy && obj.Name == "hostgroups" {
+					option = "groups"
+				}
+
+				ids, names, err := listBackupObjectIDs(client, obj)
+				handleError(err)
+				if len(ids) == 0 {
+					rows = append(rows, []string{obj.Name, "0", "0", "Empty"})
+					continue
+				}
+
+				files := 0
+				switch split {
+				case "none":
+					allOptions[option] = ids
+					allNames = append(allNames, names...)
+					allTypes = append(allTypes, obj.Name)
+					rows = append(rows, []string{obj.Name, fmt.Sprintf("%d", len(ids)), "-", "Queued"})
+					continue
+				case "type":
+					f, err := writeBackupExport(client, setDir, obj.Name, map[string]interface{}{option: ids}, format, compress)
+					handleError(err)
+					f.Objects = []string{obj.Name}
+					f.Names = names
+					manifest.Files = append(manifest.Files, f)
+					files = 1
+				case "object":
+					used := make(map[string]bool)
+					for i, id := range ids {
+						fileName := sanitizeBackupName(names[i])
+						if used[fileName] {
+							fileName += "_" + id
+						}
+						used[fileName] = true
+						base := filepath.Join(obj.Name, fileName)
+						f, err := writeBackupExport(client, setDir, base, map[string]interface{}{option: []string{id}}, format, compress)
+						handleError(err)
+						f.Objects = []string{obj.Name}
+						f.Names = []string{names[i]}
+						manifest.Files = append(manifest.Files, f)
+						files++
+					}
+				}
+				rows = append(rows, []string{obj.Name, fmt.Sprintf("%d", len(ids)), fmt.Sprintf("%d", files), "OK"})
+			}
+
+			if split == "none" && len(allOptions) > 0 {
+				f, err := writeBackupExport(client, setDir, "zabbix_config", allOptions, format, compress)
+				handleError(err)
+				f.Objects = allTypes
+				f.Names = allNames
+				manifest.Files = append(manifest.Files, f)
+				for i := range rows {
+					if rows[i][3] == "Queued" {
+						rows[i][2] = "1"
+						rows[i][3] = "OK"
+					}
+				}
+			}
+
+			manifestData, _ := json.MarshalIndent(manifest, "", "  ")
+			handleError(os.WriteFile(filepath.Join(setDir, backupManifestFile), manifestData, 0644))
+
+			removed, err := rotateBackups(dir, keep)
+			handleError(err)
+
+			rows = append(rows, []string{"Backup Path", setDir, fmt.Sprintf("%d", len(manifest.Files)), "Success"})
+			if len(removed) > 0 {
+				rows = append(rows, []string{"Retention", fmt.Sprintf("keep %d", keep), fmt.Sprintf("%d", len(removed)), "Removed"})
+			}
+			outputResult(cmd, manifest, headers, rows)
 		},
 	}
+
+	cmd.Flags().StringVarP(&dir, "dir", "d", "backups", "Directory where backup sets are written")
+	cmd.Flags().StringSliceVarP(&objects, "objects", "o", []string{}, "Object types to export (hostgroups,templategroups,templates,hosts,maps,mediatypes,images)")
+	cmd.Flags().StringVarP(&format, "format", "f", "json", "Export format (json, yaml, xml)")
+	cmd.Flags().StringVar(&split, "split", "type", "Split output per object type, per object, or none")
+	cmd.Flags().StringVar(&compress, "compress", "none", "Compression (none, gzip, zstd)")
+	cmd.Flags().IntVar(&keep, "keep", 0, "Number of backup sets to keep in the directory (0 keeps all)")
+	cmd.Flags().StringVar(&gitRepo, "git", "", "Write a YAML snapshot into this git repository and commit it")
+
+	return cmd
+}
+
+func newRestoreCmd() *cobra.Command {
+	var objects []string
+	var names []string
+	var preset string
+	var overrides []string
+	var dryRun bool
+	var noVerify bool
+
+	cmd := &cobra.Command{
+		Use:   "restore [backup directory]",
+		Short: "Restore Zabbix configurations from a backup set",
+		Long: `Reimport a backup set created by 'backup'.
+
+The manifest checksums are verified before anything is imported; files without a checksum
+are refused unless --no-verify is given. Use --objects to
+restore only some object types and --name to restore single objects (requires a
+backup taken with --split object).`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			manifestData, err := os.ReadFile(filepath.Join(args[0], backupManifestFile))
+			handleError(err)
+			var manifest backupManifest
+			if err := json.Unmarshal(manifestData, &manifest); err != nil {
+				handleError(fmt.Errorf("failed to parse manifest: %w", err))
+			}
+
+			selected, err := selectBackupObjects(objects)
+			handleError(err)
+			if len(names) > 0 && manifest.Split != "object" {
+				handleError(fmt.Errorf("--name requires a backup taken with --split object (this one uses %q)", manifest.Split))
+			}
+
+			rules, err := buildImportRules(preset, overrides)
+			handleError(err)
+			maskImportRules(rules, selected)
+			rules = versionImportRules(rules, getAPIVersion(client))
+
+			selectedTypes := make(map[string]bool)
+			for _, obj := range selected {
+				selectedTypes[obj.Name] = true
+			}
+			selectedNames := make(map[string]bool)
+			for _, n := range names {
+				selectedNames[n] = true
+			}
+
+			headers := []string{"File", "Object Type", "Added", "Updated", "Removed", "Status"}
+			var rows [][]string
+			var results []map[string]interface{}
+
+			for _, f := range manifest.Files {
+				if !backupFileMatches(f, selectedTypes, selectedNames) {
+					continue
+				}
+
+				source, err := readBackupFile(args[0], f.Path, f.SHA256, manifest.Compression, !noVerify)
+				handleError(err)
+
+				changes, err := compareImport(client, manifest.Format, source, rules)
+				handleError(err)
+
+				status := "Pending"
+				if !dryRun {
+					_, err = client.Call("configuration.import", map[string]interface{}{
+						"format": manifest.Format,
+						"source": source,
+						"rules":  rules,
+					})
+					handleError(err)
+					status = "Restored"
+				}
+
+				fileRows := importChangeRows(changes, status)
+				if len(fileRows) == 0 {
+					rows = append(rows, []string{f.Path, "-", "0", "0", "0", "Unchanged"})
+				}
+				for _, r := range fileRows {
+					rows = append(rows, append([]string{f.Path}, r...))
+				}
+				results = append(results, map[string]interface{}{"file": f.Path, "changes": changes, "status": status})
+			}
+
+			if len(results) == 0 {
+				handleError(fmt.Errorf("no files in %s match the selection", args[0]))
+			}
+
+			outputResult(cmd, results, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&objects, "objects", "o", []string{}, "Object types to restore (default: all in the backup)")
+	cmd.Flags().StringSliceVarP(&names, "name", "n", []string{}, "Object names to restore")
+	cmd.Flags().StringVarP(&preset, "preset", "p", "sync", "Import rule preset (safe, sync, mirror)")
+	cmd.Flags().StringArrayVar(&overrides, "rule", []string{}, "Override rules for an object type (type=create,update,delete|none)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only show what would change")
+	cmd.Flags().BoolVar(&noVerify, "no-verify", false, "Do not verify file checksums against the manifest")
+
+	return cmd
+}
+
+// selectBackupObjects resolves --objects names; an empty list selects every type.
+func selectBackupObjects(names []string) ([]backupObject, error) {
+	if len(names) == 0 {
+		return backupObjects, nil
+	}
+
+	var selected []backupObject
+	for _, n := range names {
+		n = strings.ToLower(strings.TrimSpace(n))
+		found := false
+		for _, obj := range backupObjects {
+			if obj.Name == n {
+				selected = append(selected, obj)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown object type: %s", n)
+		}
+	}
+	return selected, nil
+}
+
+func listBackupObjectIDs(client *api.ZabbixClient, obj backupObject) ([]string, []string, error) {
+	// Not every get method can sort by name (mediatype.get only sorts by ID), so sort here.
+	params := map[string]interface{}{
+		"output": []string{obj.IDField, obj.NameField},
+	}
+	result, err := client.Call(obj.Method, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list %s: %w", obj.Name, err)
+	}
+
+	var items []map[string]interface{}
+	json.Unmarshal(result, &items)
+	sort.SliceStable(items, func(i, j int) bool {
+		return fmt.Sprintf("%v", items[i][obj.NameField]) < fmt.Sprintf("%v", items[j][obj.NameField])
+	})
+
+	var ids, names []string
+	for _, item := range items {
+		ids = append(ids, fmt.Sprintf("%v", item[obj.IDField]))
+		names = append(names, fmt.Sprintf("%v", item[obj.NameField]))
+	}
+	return ids, names, nil
+}
+
+// writeBackupExport runs configuration.export and writes the (optionally compressed) result below dir.
+func writeBackupExport(client *api.ZabbixClient, dir, base string, options map[string]interface{}, format, compress string) (backupFile, error) {
+	result, err := client.Call("configuration.export", map[string]interface{}{
+		"options": options,
+		"format":  format,
+	})
+	if err != nil {
+		return backupFile{}, err
+	}
+
+	var source string
+	if err := json.Unmarshal(result, &source); err != nil {
+		return backupFile{}, fmt.Errorf("unexpected export result: %w", err)
+	}
+
+	data, err := compressBackupData([]byte(source), compress)
+	if err != nil {
+		return backupFile{}, err
+	}
+
+	ext, _ := backupFileExt(format, compress)
+	relPath := base + ext
+	fullPath := filepath.Join(dir, relPath)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return backupFile{}, err
+	}
+	if err := os.WriteFile(fullPath, data, 0644); err != nil {
+		return backupFile{}, err
+	}
+
+	sum := sha256.Sum256(data)
+	return backupFile{
+		Path:   filepath.ToSlash(relPath),
+		SHA256: hex.EncodeToString(sum[:]),
+		Size:   int64(len(data)),
+	}, nil
+}
+
+func backupFileExt(format, compress string) (string, error) {
+	ext := "." + format
+	switch compress {
+	case "none", "":
+	case "gzip":
+		ext += ".gz"
+	case "zstd":
+		ext += ".zst"
+	default:
+		return "", fmt.Errorf("invalid compression: %s (expected none, gzip or zstd)", compress)
+	}
+	return ext, nil
+}
+
+func compressBackupData(data []byte, compress string) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+
+	switch compress {
+	case "none", "":
+		return data, nil
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zstd":
+		zw, err := zstd.NewWriter(&buf)
+		if err != nil {
+			return nil, err
+		}
+		w = zw
+	default:
+		return nil, fmt.Errorf("invalid compression: %s", compress)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// createBackupSetDir creates a new timestamped backup set directory below parent. A numeric
+// suffix is added when a set was already created in the same second.
+func createBackupSetDir(parent, prefix string) (string, error) {
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", err
+	}
+	base := filepath.Join(parent, prefix+time.Now().Format("20060102_150405"))
+	for i := 1; ; i++ {
+		dir := base
+		if i > 1 {
+			dir = fmt.Sprintf("%s_%d", base, i)
+		}
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			return dir, nil
+		}
+		if !os.IsExist(err) {
+			return "", err
+		}
+	}
+}
+
+// readBackupFile reads a file of a backup set, verifies it against its manifest checksum unless
+// verify is false, and returns the decompressed source. Paths must stay inside the set.
+func readBackupFile(dir, relPath, checksum, compress string, verify bool) (string, error) {
+	if !filepath.IsLocal(relPath) {
+		return "", fmt.Errorf("invalid file path in manifest: %s", relPath)
+	}
+	path := filepath.Join(dir, relPath)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	if verify {
+		if checksum == "" {
+			return "", fmt.Errorf("no checksum for %s in the manifest (use --no-verify to restore it anyway)", relPath)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != checksum {
+			return "", fmt.Errorf("checksum mismatch for %s", path)
+		}
+	}
+
+	var r io.Reader
+	switch compress {
+	case "none", "":
+		return string(data), nil
+	case "gzip":
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+		defer gr.Close()
+		r = gr
+	case "zstd":
+		zr, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return "", fmt.Errorf("invalid compression in manifest: %s", compress)
+	}
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress %s: %w", path, err)
+	}
+	return string(out), nil
+}
+
+// maskImportRules disables rules for object types that were not selected for restore.
+func maskImportRules(rules map[string]interface{}, selected []backupObject) {
+	keep := make(map[string]bool)
+	for _, obj := range selected {
+		for _, r := range obj.Rules {
+			keep[r] = true
+		}
+	}
+	for ruleType, opts := range rules {
+		if keep[ruleType] {
+			continue
+		}
+		if set, ok := opts.(map[string]bool); ok {
+			for opt := range set {
+				set[opt] = false
+			}
+		}
+	}
+}
+
+func backupFileMatches(f backupFile, types map[string]bool, names map[string]bool) bool {
+	typeMatch := false
+	for _, o := range f.Objects {
+		if types[o] {
+			typeMatch = true
+		}
+	}
+	if !typeMatch {
+		return false
+	}
+	if len(names) == 0 {
+		return true
+	}
+	for _, n := range f.Names {
+		if names[n] {
+			return true
+		}
+	}
+	return false
+}
+
+// rotateBackups removes the oldest backup sets in dir so that at most keep remain.
+func rotateBackups(dir string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var sets []string
+	for _, e := range entries {
+		if e.IsDir() && strings.HasPrefix(e.Name(), "zabbix_backup_") {
+			sets = append(sets, e.Name())
+		}
+	}
+	// Timestamped names sort chronologically
+	sort.Strings(sets)
+
+	var removed []string
+	for len(sets) > keep {
+		path := filepath.Join(dir, sets[0])
+		if err := os.RemoveAll(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+		sets = sets[1:]
+	}
+	return removed, nil
+}
+
+var unsafeBackupName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func sanitizeBackupName(name string) string {
+	s := strings.Trim(unsafeBackupName.ReplaceAllString(name, "_"), "_")
+	if s == "" {
+		s = "unnamed"
+	}
+	return s
 }
@@ -0,0 +1,861 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"zabbix-dna/internal/api"
+	"zabbix-dna/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+// cleanupChecks lists the checks of cleanup scan in the order they run.
+var cleanupChecks = []string{
+	"disabled-hosts",
+	"unavailable-hosts",
+	"empty-hostgroups",
+	"empty-templategroups",
+	"unused-templates",
+	"inactive-users",
+	"unused-mediatypes",
+	"broken-actions",
+}
+
+// cleanupFinding is one stale or orphaned object and the fix cleanup apply performs.
+type cleanupFinding struct {
+	ID       string `json:"id"`
+	Check    string `json:"check"`
+	Type     string `json:"type"`
+	ObjectID string `json:"objectid"`
+	Name     string `json:"name"`
+	Detail   string `json:"detail"`
+	Fix      string `json:"fix"` // "delete" or "disable"
+	// Unverified findings rely on an audit log that does not reach back far enough.
+	Unverified bool `json:"unverified,omitempty"`
+}
+
+// cleanupOptions holds the scan thresholds shared by cleanup scan and cleanup apply.
+type cleanupOptions struct {
+	checks          []string
+	disabledDays    int
+	unavailableDays int
+	inactiveDays    int
+	exclude         string
+}
+
+func (o *cleanupOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&o.checks, "check", []string{}, "Checks to run (default: all): "+strings.Join(cleanupChecks, ", "))
+	cmd.Flags().IntVar(&o.disabledDays, "disabled-days", 90, "Report disabled hosts not changed for this many days")
+	cmd.Flags().IntVar(&o.unavailableDays, "unavailable-days", 30, "Report hosts with every interface unavailable for this many days")
+	cmd.Flags().IntVar(&o.inactiveDays, "inactive-days", 90, "Report users without a login for this many days")
+	cmd.Flags().StringVar(&o.exclude, "exclude", "", "Regular expression of object names to leave out")
+}
+
+func newCleanupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Find and remove stale or orphaned configuration",
+	}
+
+	cmd.AddCommand(newCleanupScanCmd())
+	cmd.AddCommand(newCleanupApplyCmd())
+
+	return cmd
+}
+
+func newCleanupScanCmd() *cobra.Command {
+	var opts cleanupOptions
+	var outFile string
+
+	cmd := &cobra.Command{
+		Use:   "scan",
+		Short: "Find stale and orphaned objects",
+		Long: `Find configuration that is probably junk:
+
+  disabled-hosts        disabled hosts not changed for --disabled-days (from the audit log)
+  unavailable-hosts     enabled hosts whose interfaces all failed for --unavailable-days
+  empty-hostgroups      host groups without hosts (or templates before Zabbix 6.2)
+  empty-templategroups  template groups without templates (Zabbix 6.2+)
+  unused-templates      templates linked to no host and no other template
+  inactive-users        users without a login for --inactive-days (from the audit log)
+  unused-mediatypes     media types used by no user media and no action
+  broken-actions        actions referencing host groups, user groups, users, hosts or
+                        templates that no longer exist, or sending to nobody
+
+Checks based on the audit log are skipped when audit logging is disabled, and their findings
+are marked unverified when audit housekeeping deletes entries sooner than --disabled-days or
+--inactive-days. Users whose API tokens were used within --inactive-days count as active.
+Nothing is changed; write the findings with -O and review them before cleanup apply --from.`,
+		Example: `  zabbix-dna cleanup scan
+  zabbix-dna cleanup scan --check empty-hostgroups,unused-templates --exclude '^Templates/' -O findings.json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			findings, err := runCleanupScan(client, &opts, time.Now())
+			handleError(err)
+
+			if outFile != "" {
+				data, _ := json.MarshalIndent(findings, "", "  ")
+				handleError(os.WriteFile(outFile, data, 0644))
+				fmt.Fprintf(os.Stderr, "%d findings written to %s\n", len(findings), outFile)
+			}
+			outputResult(cmd, findings, cleanupHeaders, cleanupRows(findings))
+		},
+	}
+
+	opts.addFlags(cmd)
+	cmd.Flags().StringVarP(&outFile, "output-file", "O", "", "Also write the findings as JSON to this file")
+
+	return cmd
+}
+
+func newCleanupApplyCmd() *cobra.Command {
+	var opts cleanupOptions
+	var from string
+	var only []string
+	var backupDir string
+	var yes bool
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Delete or disable findings of cleanup scan",
+		Long: `Fix findings: delete disabled hosts, empty groups and unused templates; disable unavailable
+hosts, inactive users, unused media types and broken actions.
+
+Findings come from a file written by cleanup scan -O (--from), or from a new scan with the
+same flags. Findings read from a file are checked again with the thresholds given to apply;
+those the new scan no longer reports are skipped as "no longer applies". Select findings with
+--only (finding IDs or object names); unverified findings are only applied when selected this
+way. After confirmation, the affected objects are exported to a backup set that 'restore' can
+read (users and actions, which configuration.export does not cover, are saved as JSON next to
+it), then fixed.`,
+		Example: `  zabbix-dna cleanup apply --from findings.json --only empty-hostgroups:42,unused-templates:10050
+  zabbix-dna cleanup apply --check empty-hostgroups --yes --backup-dir /srv/backups`,
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			var findings []cleanupFinding
+			if from != "" {
+				data, err := os.ReadFile(from)
+				handleError(err)
+				handleError(json.Unmarshal(data, &findings))
+				if len(opts.checks) > 0 {
+					var kept []cleanupFinding
+					for _, f := range findings {
+						if containsString(opts.checks, f.Check) {
+							kept = append(kept, f)
+						}
+					}
+					findings = kept
+				}
+			} else {
+				findings, err = runCleanupScan(client, &opts, time.Now())
+				handleError(err)
+			}
+			selected := func(f cleanupFinding) bool {
+				return containsString(only, f.ID) || containsString(only, f.Name)
+			}
+			if len(only) > 0 {
+				var kept []cleanupFinding
+				for _, f := range findings {
+					if selected(f) {
+						kept = append(kept, f)
+					}
+				}
+				findings = kept
+			}
+
+			var skipped []cleanupResult
+			if from != "" && len(findings) > 0 {
+				current, stale, err := reverifyCleanupFindings(client, &opts, findings, time.Now())
+				handleError(err)
+				for _, f := range stale {
+					skipped = append(skipped, cleanupResult{finding: f, status: "Skipped: no longer applies"})
+				}
+				findings = current
+			}
+			var kept []cleanupFinding
+			for _, f := range findings {
+				if f.Unverified && !selected(f) {
+					skipped = append(skipped, cleanupResult{finding: f, status: "Skipped: unverified, select it with --only"})
+					continue
+				}
+				kept = append(kept, f)
+			}
+			findings = kept
+
+			if len(findings) == 0 || dryRun {
+				for _, r := range skipped {
+					fmt.Fprintf(os.Stderr, "%s (%s): %s\n", r.finding.ID, r.finding.Name, r.status)
+				}
+			}
+			if len(findings) == 0 {
+				outputResult(cmd, "Nothing to clean up.", nil, nil)
+				return
+			}
+
+			if dryRun {
+				outputResult(cmd, findings, cleanupHeaders, cleanupRows(findings))
+				return
+			}
+			if !yes {
+				output.NewTableRenderer(cleanupHeaders, cleanupRows(findings)).Render()
+				if !confirmAction(fmt.Sprintf("Apply %d fixes?", len(findings))) {
+					handleError(fmt.Errorf("aborted"))
+				}
+			}
+
+			setDir, err := createBackupSetDir(backupDir, "zabbix_cleanup_")
+			handleError(err)
+			handleError(writeCleanupBackup(client, setDir, findings))
+			fmt.Fprintf(os.Stderr, "Backup written to %s\n", setDir)
+
+			results := applyCleanupFindings(cmd, client, findings)
+			headers := []string{"ID", "Name", "Fix", "Status"}
+			var rows [][]string
+			failed := 0
+			for _, r := range append(results, skipped...) {
+				rows = append(rows, []string{r.finding.ID, r.finding.Name, r.finding.Fix, r.status})
+				if r.err != nil {
+					failed++
+				}
+			}
+			var skippedFindings []cleanupFinding
+			for _, r := range skipped {
+				skippedFindings = append(skippedFindings, r.finding)
+			}
+			outputResult(cmd, map[string]interface{}{"backup": setDir, "findings": findings, "skipped": skippedFindings}, headers, rows)
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.addFlags(cmd)
+	cmd.Flags().StringVar(&from, "from", "", "Read findings from a cleanup scan -O file instead of scanning")
+	cmd.Flags().StringSliceVar(&only, "only", []string{}, "Only apply these finding IDs or object names")
+	cmd.Flags().StringVar(&backupDir, "backup-dir", "backups", "Directory for the backup set written before changes")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Do not ask for confirmation")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only show what would change")
+
+	return cmd
+}
+
+var cleanupHeaders = []string{"ID", "Check", "Name", "Detail", "Fix"}
+
+func cleanupRows(findings []cleanupFinding) [][]string {
+	var rows [][]string
+	for _, f := range findings {
+		rows = append(rows, []string{f.ID, f.Check, f.Name, f.Detail, f.Fix})
+	}
+	return rows
+}
+
+// runCleanupScan runs the selected checks.
+func runCleanupScan(client *api.ZabbixClient, opts *cleanupOptions, now time.Time) ([]cleanupFinding, error) {
+	checks := opts.checks
+	if len(checks) == 0 {
+		checks = cleanupChecks
+	}
+	for _, c := range checks {
+		if !containsString(cleanupChecks, c) {
+			return nil, fmt.Errorf("invalid check: %s (expected %s)", c, strings.Join(cleanupChecks, ", "))
+		}
+	}
+	var exclude *regexp.Regexp
+	if opts.exclude != "" {
+		re, err := regexp.Compile(opts.exclude)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --exclude: %w", err)
+		}
+		exclude = re
+	}
+
+	s := cleanupScanner{client: client, opts: opts, now: now, modern: apiVersionAtLeast(getAPIVersion(client), 6, 2)}
+	for _, c := range cleanupChecks {
+		if !containsString(checks, c) {
+			continue
+		}
+		var err error
+		switch c {
+		case "disabled-hosts":
+			err = s.disabledHosts()
+		case "unavailable-hosts":
+			err = s.unavailableHosts()
+		case "empty-hostgroups":
+			err = s.emptyHostGroups()
+		case "empty-templategroups":
+			err = s.emptyTemplateGroups()
+		case "unused-templates":
+			err = s.unusedTemplates()
+		case "inactive-users":
+			err = s.inactiveUsers()
+		case "unused-mediatypes":
+			err = s.unusedMediaTypes()
+		case "broken-actions":
+			err = s.brokenActions()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c, err)
+		}
+	}
+
+	var findings []cleanupFinding
+	for _, f := range s.findings {
+		if exclude != nil && exclude.MatchString(f.Name) {
+			continue
+		}
+		findings = append(findings, f)
+	}
+	return findings, nil
+}
+
+// reverifyCleanupFindings runs the checks of findings read from a file again and returns the
+// findings the new scan still reports, with their current details, and those it does not.
+func reverifyCleanupFindings(client *api.ZabbixClient, opts *cleanupOptions, findings []cleanupFinding, now time.Time) (current, stale []cleanupFinding, err error) {
+	scanOpts := *opts
+	scanOpts.checks = nil
+	scanOpts.exclude = ""
+	for _, f := range findings {
+		if !containsString(scanOpts.checks, f.Check) {
+			scanOpts.checks = append(scanOpts.checks, f.Check)
+		}
+	}
+	live, err := runCleanupScan(client, &scanOpts, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := map[string]cleanupFinding{}
+	for _, f := range live {
+		byID[f.ID] = f
+	}
+	for _, f := range findings {
+		if l, ok := byID[f.ID]; ok && l.Fix == f.Fix {
+			current = append(current, l)
+		} else {
+			stale = append(stale, f)
+		}
+	}
+	return current, stale, nil
+}
+
+type cleanupScanner struct {
+	client   *api.ZabbixClient
+	opts     *cleanupOptions
+	now      time.Time
+	modern   bool // Zabbix 6.2+: template groups and separate group rights
+	findings []cleanupFinding
+
+	auditRead    bool
+	auditEnabled bool
+	auditKeep    string // audit housekeeping period, "" when entries are kept for ever
+}
+
+func (s *cleanupScanner) add(check, objType string, obj map[string]interface{}, idField, nameField, detail, fix string) {
+	id := fmt.Sprintf("%v", obj[idField])
+	s.findings = append(s.findings, cleanupFinding{
+		ID:       check + ":" + id,
+		Check:    check,
+		Type:     objType,
+		ObjectID: id,
+		Name:     fmt.Sprintf("%v", obj[nameField]),
+		Detail:   detail,
+		Fix:      fix,
+	})
+}
+
+func (s *cleanupScanner) since(days int) time.Time {
+	return s.now.Add(-time.Duration(days) * 24 * time.Hour)
+}
+
+// auditWindow reports whether the audit log can tell what happened in the last days days. A
+// check cannot run when audit logging is disabled; when housekeeping deletes audit entries
+// sooner, the check runs but note explains why its findings are unverified.
+func (s *cleanupScanner) auditWindow(check string, days int) (ok bool, note string, err error) {
+	if !s.auditRead {
+		result, err := s.client.Call("settings.get", map[string]interface{}{"output": []string{"auditlog_enabled"}})
+		if err != nil {
+			return false, "", err
+		}
+		var settings map[string]interface{}
+		json.Unmarshal(result, &settings)
+		s.auditEnabled = exportString(settings, "auditlog_enabled") != "0"
+
+		result, err = s.client.Call("housekeeping.get", map[string]interface{}{"output": []string{"hk_audit_mode", "hk_audit"}})
+		if err != nil {
+			return false, "", err
+		}
+		var hk map[string]interface{}
+		json.Unmarshal(result, &hk)
+		if exportString(hk, "hk_audit_mode") == "1" {
+			s.auditKeep = exportString(hk, "hk_audit")
+		}
+		s.auditRead = true
+	}
+
+	if !s.auditEnabled {
+		fmt.Fprintf(os.Stderr, "Warning: %s skipped: audit logging is disabled, so changes and logins cannot be checked\n", check)
+		return false, "", nil
+	}
+	if s.auditKeep != "" {
+		keep, err := parseRetention(s.auditKeep)
+		if err != nil {
+			return false, "", fmt.Errorf("invalid audit log retention %q: %w", s.auditKeep, err)
+		}
+		if keep < time.Duration(days)*24*time.Hour {
+			return true, fmt.Sprintf("unverified: the audit log keeps only %s", s.auditKeep), nil
+		}
+	}
+	return true, "", nil
+}
+
+// addAudited adds a finding of an audit log based check, marking it unverified with note.
+func (s *cleanupScanner) addAudited(note, check, objType string, obj map[string]interface{}, idField, nameField, detail, fix string) {
+	if note != "" {
+		detail += " (" + note + ")"
+	}
+	s.add(check, objType, obj, idField, nameField, detail, fix)
+	s.findings[len(s.findings)-1].Unverified = note != ""
+}
+
+func (s *cleanupScanner) disabledHosts() error {
+	ok, note, err := s.auditWindow("disabled-hosts", s.opts.disabledDays)
+	if !ok || err != nil {
+		return err
+	}
+	hosts, err := callGetList(s.client, "host.get", map[string]interface{}{
+		"output": []string{"hostid", "host"},
+		"filter": map[string]interface{}{"status": "1"},
+	})
+	if err != nil || len(hosts) == 0 {
+		return err
+	}
+	var ids []string
+	for _, h := range hosts {
+		ids = append(ids, fmt.Sprintf("%v", h["hostid"]))
+	}
+	entries, err := fetchAuditLog(s.client, map[string]interface{}{
+		"output":    "extend",
+		"time_from": s.since(s.opts.disabledDays).Unix(),
+		"filter":    map[string]interface{}{"resourcetype": "4", "resourceid": ids},
+	}, 0)
+	if err != nil {
+		return err
+	}
+	touched := map[string]bool{}
+	for _, e := range entries {
+		touched[e.ResourceID] = true
+	}
+	for _, h := range hosts {
+		if !touched[fmt.Sprintf("%v", h["hostid"])] {
+			s.addAudited(note, "disabled-hosts", "host", h, "hostid", "host", fmt.Sprintf("disabled, unchanged for %dd", s.opts.disabledDays), "delete")
+		}
+	}
+	return nil
+}
+
+func (s *cleanupScanner) unavailableHosts() error {
+	hosts, err := callGetList(s.client, "host.get", map[string]interface{}{
+		"output":           []string{"hostid", "host"},
+		"filter":           map[string]interface{}{"status": "0"},
+		"selectInterfaces": []string{"available", "errors_from"},
+	})
+	if err != nil {
+		return err
+	}
+	limit := s.since(s.opts.unavailableDays).Unix()
+	for _, h := range hosts {
+		ifaces := exportList(h["interfaces"])
+		if len(ifaces) == 0 {
+			continue
+		}
+		var latest int64
+		down := true
+		for _, i := range ifaces {
+			from := parseClock(i["errors_from"])
+			if exportString(i, "available") != "2" || from == 0 || from > limit {
+				down = false
+				break
+			}
+			if from > latest {
+				latest = from
+			}
+		}
+		if down {
+			s.add("unavailable-hosts", "host", h, "hostid", "host", "unavailable since "+time.Unix(latest, 0).Format("2006-01-02"), "disable")
+		}
+	}
+	return nil
+}
+
+func (s *cleanupScanner) emptyHostGroups() error {
+	params := map[string]interface{}{
+		"output":      []string{"groupid", "name"},
+		"selectHosts": "count",
+	}
+	if !s.modern {
+		params["selectTemplates"] = "count"
+	}
+	groups, err := callGetList(s.client, "hostgroup.get", params)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		if exportString(g, "hosts") == "0" && (s.modern || exportString(g, "templates") == "0") {
+			s.add("empty-hostgroups", "hostgroup", g, "groupid", "name", "no hosts", "delete")
+		}
+	}
+	return nil
+}
+
+func (s *cleanupScanner) emptyTemplateGroups() error {
+	if !s.modern {
+		return nil
+	}
+	groups, err := callGetList(s.client, "templategroup.get", map[string]interface{}{
+		"output":          []string{"groupid", "name"},
+		"selectTemplates": "count",
+	})
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		if exportString(g, "templates") == "0" {
+			s.add("empty-templategroups", "templategroup", g, "groupid", "name", "no templates", "delete")
+		}
+	}
+	return nil
+}
+
+func (s *cleanupScanner) unusedTemplates() error {
+	templates, err := callGetList(s.client, "template.get", map[string]interface{}{
+		"output":          []string{"templateid", "host"},
+		"selectHosts":     "count",
+		"selectTemplates": "count",
+	})
+	if err != nil {
+		return err
+	}
+	for _, t := range templates {
+		if exportString(t, "hosts") == "0" && exportString(t, "templates") == "0" {
+			s.add("unused-templates", "template", t, "templateid", "host", "linked to no host or template", "delete")
+		}
+	}
+	return nil
+}
+
+func (s *cleanupScanner) inactiveUsers() error {
+	ok, note, err := s.auditWindow("inactive-users", s.opts.inactiveDays)
+	if !ok || err != nil {
+		return err
+	}
+	users, err := callGetList(s.client, "user.get", map[string]interface{}{
+		"output":        []string{"userid", "username"},
+		"selectUsrgrps": []string{"users_status"},
+	})
+	if err != nil {
+		return err
+	}
+	// Logins, and creations of users that had no chance to log in yet.
+	entries, err := fetchAuditLog(s.client, map[string]interface{}{
+		"output":    "extend",
+		"time_from": s.since(s.opts.inactiveDays).Unix(),
+		"filter":    map[string]interface{}{"resourcetype": "0", "action": []string{"0", "8"}},
+	}, 0)
+	if err != nil {
+		return err
+	}
+	active := map[string]bool{}
+	for _, e := range entries {
+		if e.Action == "login" {
+			active[e.Username] = true
+		} else {
+			active[e.ResourceName] = true
+		}
+	}
+	// API token requests do not log in, so token use counts as activity too.
+	tokens, err := callGetList(s.client, "token.get", map[string]interface{}{
+		"output": []string{"userid", "lastaccess"},
+	})
+	if err != nil {
+		return err
+	}
+	tokenUsers := map[string]bool{}
+	for _, t := range tokens {
+		if parseClock(t["lastaccess"]) >= s.since(s.opts.inactiveDays).Unix() {
+			tokenUsers[fmt.Sprintf("%v", t["userid"])] = true
+		}
+	}
+	for _, u := range users {
+		name := fmt.Sprintf("%v", u["username"])
+		if active[name] || tokenUsers[fmt.Sprintf("%v", u["userid"])] || name == "guest" || getUserStatus(u) == "Disabled" {
+			continue
+		}
+		s.addAudited(note, "inactive-users", "user", u, "userid", "username", fmt.Sprintf("no login for %dd", s.opts.inactiveDays), "disable")
+	}
+	return nil
+}
+
+func (s *cleanupScanner) unusedMediaTypes() error {
+	mediaTypes, err := callGetList(s.client, "mediatype.get", map[string]interface{}{
+		"output": []string{"mediatypeid", "name"},
+		"filter": map[string]interface{}{"status": "0"},
+	})
+	if err != nil || len(mediaTypes) == 0 {
+		return err
+	}
+	used := map[string]bool{}
+	users, err := callGetList(s.client, "user.get", map[string]interface{}{
+		"output":       []string{"userid"},
+		"selectMedias": []string{"mediatypeid"},
+	})
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		for _, m := range exportList(u["medias"]) {
+			used[fmt.Sprintf("%v", m["mediatypeid"])] = true
+		}
+	}
+	actions, err := getActionsWithOperations(s.client, nil)
+	if err != nil {
+		return err
+	}
+	for _, a := range actions {
+		for _, op := range actionOperations(a) {
+			if msg, ok := op["opmessage"].(map[string]interface{}); ok {
+				used[fmt.Sprintf("%v", msg["mediatypeid"])] = true
+			}
+		}
+	}
+	// mediatypeid 0 in an operation means all media types.
+	if used["0"] {
+		return nil
+	}
+	for _, m := range mediaTypes {
+		if !used[fmt.Sprintf("%v", m["mediatypeid"])] {
+			s.add("unused-mediatypes", "mediatype", m, "mediatypeid", "name", "no user media or action uses it", "disable")
+		}
+	}
+	return nil
+}
+
+// cleanupActionKinds are the referenced object kinds broken-actions checks.
+var cleanupActionKinds = []string{"hostgroup", "host", "template", "usergroup", "user"}
+
+func (s *cleanupScanner) brokenActions() error {
+	actions, err := getActionsWithOperations(s.client, map[string]interface{}{"status": "0"})
+	if err != nil {
+		return err
+	}
+	refs := actionReferences(actions)
+	missing := map[string]map[string]bool{}
+	for kind, ids := range refs {
+		if len(ids) == 0 || !containsString(cleanupActionKinds, kind) {
+			continue
+		}
+		k := actionObjectKinds[kind]
+		existing, err := callGetList(s.client, k.method, map[string]interface{}{
+			"output":        []string{k.idField},
+			k.idField + "s": ids,
+		})
+		if err != nil {
+			return err
+		}
+		found := map[string]bool{}
+		for _, e := range existing {
+			found[fmt.Sprintf("%v", e[k.idField])] = true
+		}
+		missing[kind] = map[string]bool{}
+		for _, id := range ids {
+			if !found[id] {
+				missing[kind][id] = true
+			}
+		}
+	}
+
+	for _, a := range actions {
+		var problems []string
+		for kind, ids := range actionReferences([]map[string]interface{}{a}) {
+			for _, id := range ids {
+				if missing[kind][id] {
+					problems = append(problems, fmt.Sprintf("%s %s missing", kind, id))
+				}
+			}
+		}
+		for _, op := range actionOperations(a) {
+			if _, ok := op["opmessage"]; !ok {
+				continue
+			}
+			if len(exportList(op["opmessage_grp"])) == 0 && len(exportList(op["opmessage_usr"])) == 0 && exportString(op, "operationtype") == "0" {
+				problems = append(problems, "message sent to nobody")
+				break
+			}
+		}
+		if len(problems) > 0 {
+			sort.Strings(problems)
+			s.add("broken-actions", "action", a, "actionid", "name", strings.Join(problems, "; "), "disable")
+		}
+	}
+	return nil
+}
+
+// writeCleanupBackup exports the objects of the findings into a backup set readable by restore.
+func writeCleanupBackup(client *api.ZabbixClient, dir string, findings []cleanupFinding) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	version := getAPIVersion(client)
+	legacy := version != "" && !apiVersionAtLeast(version, 6, 2)
+
+	exportOption := map[string]string{
+		"host":          "hosts",
+		"template":      "templates",
+		"hostgroup":     "host_groups",
+		"templategroup": "template_groups",
+		"mediatype":     "mediaTypes",
+	}
+	if legacy {
+		exportOption["hostgroup"] = "groups"
+	}
+	backupName := map[string]string{
+		"host": "hosts", "template": "templates", "hostgroup": "hostgroups",
+		"templategroup": "templategroups", "mediatype": "mediatypes",
+	}
+
+	options := map[string]interface{}{}
+	var names, types []string
+	raw := map[string][]string{}
+	for _, f := range findings {
+		option, ok := exportOption[f.Type]
+		if !ok {
+			raw[f.Type] = append(raw[f.Type], f.ObjectID)
+			continue
+		}
+		ids, _ := options[option].([]string)
+		options[option] = append(ids, f.ObjectID)
+		names = append(names, f.Name)
+		if !containsString(types, backupName[f.Type]) {
+			types = append(types, backupName[f.Type])
+		}
+	}
+
+	manifest := backupManifest{
+		CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+		ServerVersion: version,
+		Format:        "yaml",
+		Compression:   "none",
+		Split:         "none",
+	}
+	if len(options) > 0 {
+		f, err := writeBackupExport(client, dir, "zabbix_config", options, "yaml", "none")
+		if err != nil {
+			return fmt.Errorf("backup failed, nothing was changed: %w", err)
+		}
+		f.Objects = types
+		f.Names = names
+		manifest.Files = append(manifest.Files, f)
+	}
+
+	// Users and actions are not covered by configuration.export.
+	for objType, ids := range raw {
+		method, idField := "user.get", "userids"
+		params := map[string]interface{}{"output": "extend", "selectUsrgrps": []string{"usrgrpid", "name"}, "selectMedias": "extend"}
+		if objType == "action" {
+			method, idField = "action.get", "actionids"
+			params = map[string]interface{}{
+				"output":                   "extend",
+				"selectFilter":             "extend",
+				"selectOperations":         "extend",
+				"selectRecoveryOperations": "extend",
+				"selectUpdateOperations":   "extend",
+			}
+		}
+		params[idField] = ids
+		list, err := callGetList(client, method, params)
+		if err != nil {
+			return fmt.Errorf("backup failed, nothing was changed: %w", err)
+		}
+		data, _ := json.MarshalIndent(list, "", "  ")
+		if err := os.WriteFile(filepath.Join(dir, objType+"s.json"), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	manifestData, _ := json.MarshalIndent(manifest, "", "  ")
+	return os.WriteFile(filepath.Join(dir, backupManifestFile), manifestData, 0644)
+}
+
+type cleanupResult struct {
+	finding cleanupFinding
+	status  string
+	err     error
+}
+
+// applyCleanupFindings fixes the findings one by one so a failure does not stop the rest.
+func applyCleanupFindings(cmd *cobra.Command, client *api.ZabbixClient, findings []cleanupFinding) []cleanupResult {
+	var results []cleanupResult
+	var disabledID string
+	for _, f := range findings {
+		var err error
+		switch f.Type + "/" + f.Fix {
+		case "host/delete":
+			_, err = client.Call("host.delete", []string{f.ObjectID})
+		case "host/disable":
+			_, err = client.Call("host.update", map[string]interface{}{"hostid": f.ObjectID, "status": "1"})
+		case "hostgroup/delete":
+			_, err = client.Call("hostgroup.delete", []string{f.ObjectID})
+		case "templategroup/delete":
+			_, err = client.Call("templategroup.delete", []string{f.ObjectID})
+		case "template/delete":
+			_, err = client.Call("template.delete", []string{f.ObjectID})
+		case "mediatype/disable":
+			_, err = client.Call("mediatype.update", map[string]interface{}{"mediatypeid": f.ObjectID, "status": "1"})
+		case "action/disable":
+			_, err = client.Call("action.update", map[string]interface{}{"actionid": f.ObjectID, "status": "1"})
+		case "user/disable":
+			if disabledID == "" {
+				disabledID, _, err = ensureDisabledGroup(client, disabledGroupName(cmd))
+			}
+			if err == nil {
+				err = addUserToGroup(client, f.Name, disabledID)
+			}
+		default:
+			err = fmt.Errorf("unsupported fix %s for %s", f.Fix, f.Type)
+		}
+
+		status := "Success"
+		if err != nil {
+			status = "Failed: " + err.Error()
+		}
+		results = append(results, cleanupResult{finding: f, status: status, err: err})
+	}
+	return results
+}
+
+// addUserToGroup adds a user group to a user, keeping its other groups.
+func addUserToGroup(client *api.ZabbixClient, username, groupID string) error {
+	user, err := getUser(client, username, map[string]interface{}{"selectUsrgrps": []string{"usrgrpid"}})
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, g := range exportList(user["usrgrps"]) {
+		ids = append(ids, fmt.Sprintf("%v", g["usrgrpid"]))
+	}
+	_, err = client.Call("user.update", map[string]interface{}{
+		"userid":  user["userid"],
+		"usrgrps": userGroupRefs(appendUnique(ids, groupID)),
+	})
+	return err
+}
@@ -69,8 +69,18 @@ func AddCommands(rootCmd *cobra.Command) {
 	rootCmd.AddCommand(aliasCmd(userGroupCmd, "show_usergroup", "show"))
 	rootCmd.AddCommand(aliasCmd(userGroupCmd, "show_usergroups", "list"))
 
+	// ROLE
+	rootCmd.AddCommand(newRoleCmd())
+
+	// AUDIT
+	rootCmd.AddCommand(newAuditCmd())
+
+	// CLEANUP
+	rootCmd.AddCommand(newCleanupCmd())
+
 	// MONITORING
 	rootCmd.AddCommand(newMonitoringCmd())
+	rootCmd.AddCommand(newReportCmd())
 
 	itemCmd := newItemCmd()
 	rootCmd.AddCommand(itemCmd)
@@ -113,7 +123,11 @@ func AddCommands(rootCmd *cobra.Command) {
 
 	// CONFIG / EXPORT
 	rootCmd.AddCommand(newBackupCmd())
+	rootCmd.AddCommand(newRestoreCmd())
+	rootCmd.AddCommand(newHistoryCmd())
+	rootCmd.AddCommand(newCompareCmd())
 	rootCmd.AddCommand(newExportCmd())
+	rootCmd.AddCommand(newImportCmd())
 	rootCmd.AddCommand(newExporterCmd())
 
 	// Alias para comandos legados ou compatibilidade se necessário
@@ -1,9 +1,13 @@
 package commands
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"zabbix-dna/internal/api"
 	"zabbix-dna/internal/config"
@@ -27,13 +31,12 @@ type Result struct {
 }
 
 func outputResult(cmd *cobra.Command, data interface{}, headers []string, rows [][]string) {
-	cfgPath, _ := cmd.Flags().GetString("config")
-	cfg, _ := config.LoadConfig(cfgPath)
+	outputResultAs(getOutputFormat(cmd), data, headers, rows)
+}
 
-	format := "table"
-	if cfg != nil && cfg.App.Output.Format != "" {
-		format = cfg.App.Output.Format
-	}
+// outputResultAs renders a result in the given format ("table" or "json") regardless of the
+// configured one, for commands with their own --format flag.
+func outputResultAs(format string, data interface{}, headers []string, rows [][]string) {
 
 	// Always wrap in Result for JSON
 	res := Result{
@@ -80,13 +83,40 @@ func getZabbixClient(cmd *cobra.Command) (*api.ZabbixClient, error) {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	client := api.NewClient(cfg.API.URL, cfg.API.AuthToken, cfg.API.Timeout)
-	if cfg.API.AuthToken == "" && cfg.API.Username != "" {
-		err := client.Login(cfg.API.Username, cfg.API.Password)
+	return newAPIClient(cfg.API)
+}
+
+// getNamedZabbixClient returns a client for a server defined under [servers.<name>].
+// "default" refers to the main [api] section.
+func getNamedZabbixClient(cmd *cobra.Command, name string) (*api.ZabbixClient, error) {
+	cfgPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.LoadConfig(cfgPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if name == "" || name == "default" {
+		return newAPIClient(cfg.API)
+	}
+	server, ok := cfg.Servers[name]
+	if !ok {
+		return nil, fmt.Errorf("server not defined in config: %s (add a [servers.%s] section)", name, name)
+	}
+	client, err := newAPIClient(server)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return client, nil
+}
+
+func newAPIClient(apiCfg config.APIConfig) (*api.ZabbixClient, error) {
+	client := api.NewClient(apiCfg.URL, apiCfg.AuthToken, apiCfg.Timeout)
+	if apiCfg.AuthToken == "" && apiCfg.Username != "" {
+		err := client.Login(apiCfg.Username, apiCfg.Password)
 		if err != nil {
 			return nil, fmt.Errorf("login failed: %w", err)
 		}
-	} else if cfg.API.AuthToken == "" && cfg.API.Username == "" {
+	} else if apiCfg.AuthToken == "" && apiCfg.Username == "" {
 		return nil, fmt.Errorf("no authentication provided (token or username/password)")
 	}
 
@@ -212,3 +242,173 @@ func getEventForTrigger(client *api.ZabbixClient, triggerID string) string {
 	}
 	return ""
 }
+
+func getAPIVersion(client *api.ZabbixClient) string {
+	result, err := client.Call("apiinfo.version", map[string]interface{}{})
+	if err != nil {
+		return ""
+	}
+	var version string
+	json.Unmarshal(result, &version)
+	return version
+}
+
+// apiVersionAtLeast reports whether a Zabbix version string such as "7.0.5" is at least major.minor.
+func apiVersionAtLeast(version string, major, minor int) bool {
+	var vMajor, vMinor int
+	if _, err := fmt.Sscanf(version, "%d.%d", &vMajor, &vMinor); err != nil {
+		return false
+	}
+	if vMajor != major {
+		return vMajor > major
+	}
+	return vMinor >= minor
+}
+
+// parseTagFlags converts repeated "name=value" flags into Zabbix tag objects.
+// A flag without "=" produces a tag with an empty value.
+func parseTagFlags(flags []string) ([]map[string]string, error) {
+	var tags []map[string]string
+	for _, f := range flags {
+		parts := strings.SplitN(f, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if name == "" {
+			return nil, fmt.Errorf("invalid tag: %q (expected name=value)", f)
+		}
+		value := ""
+		if len(parts) == 2 {
+			value = strings.TrimSpace(parts[1])
+		}
+		tags = append(tags, map[string]string{"tag": name, "value": value})
+	}
+	return tags, nil
+}
+
+// getOutputFormat returns the output format configured for the CLI ("table" or "json").
+func getOutputFormat(cmd *cobra.Command) string {
+	cfgPath, _ := cmd.Flags().GetString("config")
+	cfg, _ := config.LoadConfig(cfgPath)
+	if cfg != nil && cfg.App.Output.Format != "" {
+		return cfg.App.Output.Format
+	}
+	return "table"
+}
+
+// parseSeverity accepts a severity number (0-5) or its name, e.g. "high" or "not classified".
+func parseSeverity(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= 5 {
+		return n, nil
+	}
+	for i := 0; i <= 5; i++ {
+		name := strings.ToLower(getPriorityName(strconv.Itoa(i)))
+		if s == name || s == strings.ReplaceAll(name, " ", "_") || s == strings.ReplaceAll(name, " ", "-") {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid severity: %s (expected 0-5 or not classified, information, warning, average, high, disaster)", s)
+}
+
+// confirmAction asks a yes/no question on the terminal and defaults to no.
+func confirmAction(prompt string) bool {
+	fmt.Printf("%s [y/N]: ", prompt)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// formatDuration renders a duration in the compact form used by the Zabbix frontend, e.g. "2d 4h" or "3h 10m".
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 && days == 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	if len(parts) == 0 {
+		return "0m"
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseDurationSpec extends time.ParseDuration with day (d) and week (w) units, e.g. "7d" or "1w2d".
+func parseDurationSpec(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	var total time.Duration
+	rest := s
+	for _, unit := range []struct {
+		suffix string
+		size   time.Duration
+	}{{"w", 7 * 24 * time.Hour}, {"d", 24 * time.Hour}} {
+		if i := strings.Index(rest, unit.suffix); i > 0 {
+			n, err := strconv.Atoi(rest[:i])
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration: %s", s)
+			}
+			total += time.Duration(n) * unit.size
+			rest = rest[i+1:]
+		}
+	}
+	if rest != "" {
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		total += d
+	}
+	return total, nil
+}
+
+// parseTimeSpec parses "now", relative offsets such as "-6h" or "-7d", Unix timestamps and
+// absolute dates ("2006-01-02", "2006-01-02 15:04", RFC3339) in the local time zone.
+func parseTimeSpec(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "" || s == "now":
+		return now, nil
+	case s == "today":
+		y, m, d := now.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, now.Location()), nil
+	case strings.HasPrefix(s, "-"):
+		d, err := parseDurationSpec(s[1:])
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(-d), nil
+	case strings.HasPrefix(s, "+"):
+		d, err := parseDurationSpec(s[1:])
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(d), nil
+	}
+
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil && len(s) >= 9 {
+		return time.Unix(sec, 0).In(now.Location()), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %s (use now, -6h, -7d, a Unix timestamp or 2006-01-02 15:04)", s)
+}
@@ -0,0 +1,324 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"zabbix-dna/internal/api"
+
+	"github.com/spf13/cobra"
+)
+
+// compareRefFields maps ID fields found in action filters and operations to the get method
+// used to resolve them into names.
+var compareRefFields = map[string][3]string{
+	"groupid":     {"hostgroup.get", "groupid", "name"},
+	"templateid":  {"template.get", "templateid", "host"},
+	"hostid":      {"host.get", "hostid", "host"},
+	"usrgrpid":    {"usergroup.get", "usrgrpid", "name"},
+	"userid":      {"user.get", "userid", "username"},
+	"mediatypeid": {"mediatype.get", "mediatypeid", "name"},
+	"scriptid":    {"script.get", "scriptid", "name"},
+}
+
+// compareConditionRefs maps action condition types whose value is an ID to the ref field.
+var compareConditionRefs = map[string]string{
+	"0":  "groupid",
+	"1":  "hostid",
+	"13": "templateid",
+}
+
+var compareActionStripKeys = []string{"actionid", "operationid", "conditionid", "opmessage_grpid", "opmessage_usrid",
+	"opcommand_hstid", "opcommand_grpid", "opconditionid", "optemplateid", "opgroupid"}
+
+var compareObjectTypes = []string{"templates", "hostgroups", "actions", "mediatypes", "globalmacros"}
+
+type driftEntry struct {
+	Type   string `json:"type"`
+	Object string `json:"object"`
+	Status string `json:"status"`
+	Field  string `json:"field,omitempty"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+}
+
+func newCompareCmd() *cobra.Command {
+	var from string
+	var to string
+	var objects []string
+
+	cmd := &cobra.Command{
+		Use:   "compare",
+		Short: "Detect configuration drift between two Zabbix servers",
+		Long: `Compare configuration objects between two servers defined in the config file.
+
+Servers are referenced by name from [servers.<name>] sections; "default" is the main
+[api] section. IDs are resolved to names before comparing, so objects created
+independently on each side still match. The command exits with status 2 when drift is found.`,
+		Example: `  zabbix-dna compare --from staging --to prod --objects templates,actions`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if from == to {
+				handleError(fmt.Errorf("--from and --to must reference different servers"))
+			}
+			for _, o := range objects {
+				if !containsString(compareObjectTypes, o) {
+					handleError(fmt.Errorf("unknown object type: %s (expected %s)", o, strings.Join(compareObjectTypes, ", ")))
+				}
+			}
+
+			fromClient, err := getNamedZabbixClient(cmd, from)
+			handleError(err)
+			toClient, err := getNamedZabbixClient(cmd, to)
+			handleError(err)
+
+			var drift []driftEntry
+			for _, objType := range objects {
+				fromDocs, err := fetchCompareObjects(fromClient, objType)
+				handleError(err)
+				toDocs, err := fetchCompareObjects(toClient, objType)
+				handleError(err)
+				drift = append(drift, compareDocuments(objType, fromDocs, toDocs)...)
+			}
+
+			if len(drift) == 0 {
+				outputResult(cmd, fmt.Sprintf("No drift between %s and %s.", from, to), nil, nil)
+				return
+			}
+
+			headers := []string{"Type", "Object", "Status", "Field", from, to}
+			var rows [][]string
+			for _, d := range drift {
+				rows = append(rows, []string{d.Type, d.Object, d.Status, d.Field, d.From, d.To})
+			}
+			outputResult(cmd, drift, headers, rows)
+			os.Exit(2)
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "default", "Source server name")
+	cmd.Flags().StringVar(&to, "to", "", "Target server name")
+	cmd.Flags().StringSliceVar(&objects, "objects", compareObjectTypes, "Object types to compare")
+	cmd.MarkFlagRequired("to")
+
+	return cmd
+}
+
+func compareDocuments(objType string, fromDocs, toDocs map[string]interface{}) []driftEntry {
+	var drift []driftEntry
+	for _, name := range sortedKeys(fromDocs) {
+		toDoc, ok := toDocs[name]
+		if !ok {
+			drift = append(drift, driftEntry{Type: objType, Object: name, Status: "missing"})
+			continue
+		}
+		for _, d := range diffDocuments(name, fromDocs[name], toDoc) {
+			drift = append(drift, driftEntry{Type: objType, Object: name, Status: "differs", Field: d.Field, From: d.Before, To: d.After})
+		}
+	}
+	for _, name := range sortedKeys(toDocs) {
+		if _, ok := fromDocs[name]; !ok {
+			drift = append(drift, driftEntry{Type: objType, Object: name, Status: "extra"})
+		}
+	}
+	return drift
+}
+
+// fetchCompareObjects returns the name-normalized documents of one object type, keyed by name.
+func fetchCompareObjects(client *api.ZabbixClient, objType string) (map[string]interface{}, error) {
+	docs := make(map[string]interface{})
+
+	switch objType {
+	case "templates":
+		exported, _, err := fetchSnapshotObjects(client, snapshotObject{
+			Dir: "templates", Method: "template.get", IDField: "templateid", NameField: "host", Export: "templates",
+		})
+		if err != nil {
+			return nil, err
+		}
+		for name, doc := range exported {
+			// Compare the template body only; UUIDs differ between independently created objects
+			docs[name] = stripSnapshotKeys(exportedTemplate(doc), []string{"uuid"})
+		}
+
+	case "hostgroups":
+		items, err := callGetList(client, "hostgroup.get", map[string]interface{}{"output": []string{"name"}})
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range items {
+			docs[fmt.Sprintf("%v", g["name"])] = map[string]interface{}{"name": g["name"]}
+		}
+
+	case "actions":
+		items, err := callGetList(client, "action.get", map[string]interface{}{
+			"output":                   "extend",
+			"selectFilter":             "extend",
+			"selectOperations":         "extend",
+			"selectRecoveryOperations": "extend",
+			"selectUpdateOperations":   "extend",
+		})
+		if err != nil {
+			return nil, err
+		}
+		refs := make(map[string]map[string]string)
+		for _, a := range items {
+			normalized := normalizeCompareRefs(client, a, refs).(map[string]interface{})
+			// Without a custom expression, formula IDs only follow the stored condition order.
+			if filter, ok := normalized["filter"].(map[string]interface{}); ok && exportString(filter, "evaltype") != "3" {
+				delete(filter, "eval_formula")
+				normalized["filter"] = stripSnapshotKeys(filter, []string{"formulaid"})
+			}
+			docs[fmt.Sprintf("%v", a["name"])] = sortCompareLists(stripSnapshotKeys(normalized, compareActionStripKeys))
+		}
+
+	case "mediatypes":
+		items, err := callGetList(client, "mediatype.get", map[string]interface{}{"output": "extend"})
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range items {
+			docs[fmt.Sprintf("%v", m["name"])] = stripSnapshotKeys(m, []string{"mediatypeid"})
+		}
+
+	case "globalmacros":
+		items, err := callGetList(client, "usermacro.get", map[string]interface{}{"output": "extend", "globalmacro": true})
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range items {
+			docs[fmt.Sprintf("%v", m["macro"])] = stripSnapshotKeys(m, []string{"globalmacroid"})
+		}
+	}
+
+	return docs, nil
+}
+
+// sortCompareLists orders the lists of an action, whose order Zabbix does not preserve, so equal
+// actions compare equal: conditions by type, operator and value, other lists by their content.
+func sortCompareLists(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{})
+		for k, child := range val {
+			out[k] = sortCompareLists(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		keys := make(map[int]string)
+		for i, child := range val {
+			out[i] = sortCompareLists(child)
+			if m, ok := out[i].(map[string]interface{}); ok && m["conditiontype"] != nil {
+				keys[i] = strings.Join([]string{exportString(m, "conditiontype"), exportString(m, "operator"), exportString(m, "value"), exportString(m, "value2")}, "\x00")
+			} else {
+				data, _ := json.Marshal(out[i])
+				keys[i] = string(data)
+			}
+		}
+		order := make([]int, len(out))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool { return keys[order[a]] < keys[order[b]] })
+		sorted := make([]interface{}, len(out))
+		for i, j := range order {
+			sorted[i] = out[j]
+		}
+		return sorted
+	default:
+		return v
+	}
+}
+
+func exportedTemplate(doc interface{}) interface{} {
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return doc
+	}
+	export, ok := root["zabbix_export"].(map[string]interface{})
+	if !ok {
+		return doc
+	}
+	if templates, ok := export["templates"].([]interface{}); ok && len(templates) > 0 {
+		return templates[0]
+	}
+	return doc
+}
+
+// normalizeCompareRefs replaces ID references with object names, loading lookup tables into refs on demand.
+func normalizeCompareRefs(client *api.ZabbixClient, v interface{}, refs map[string]map[string]string) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{})
+		for k, child := range val {
+			out[k] = normalizeCompareRefs(client, child, refs)
+			if id, ok := child.(string); ok {
+				if _, isRef := compareRefFields[k]; isRef {
+					out[k] = resolveCompareRef(client, k, id, refs)
+				}
+			}
+		}
+		if condType, ok := val["conditiontype"].(string); ok {
+			if field, ok := compareConditionRefs[condType]; ok {
+				if id, ok := val["value"].(string); ok {
+					out["value"] = resolveCompareRef(client, field, id, refs)
+				}
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, child := range val {
+			out[i] = normalizeCompareRefs(client, child, refs)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func resolveCompareRef(client *api.ZabbixClient, field, id string, refs map[string]map[string]string) string {
+	if id == "" || id == "0" {
+		return id
+	}
+	names, ok := refs[field]
+	if !ok {
+		names = make(map[string]string)
+		ref := compareRefFields[field]
+		items, err := callGetList(client, ref[0], map[string]interface{}{"output": []string{ref[1], ref[2]}})
+		if err == nil {
+			for _, item := range items {
+				names[fmt.Sprintf("%v", item[ref[1]])] = fmt.Sprintf("%v", item[ref[2]])
+			}
+		}
+		refs[field] = names
+	}
+	if name, ok := names[id]; ok {
+		return name
+	}
+	return "unknown:" + id
+}
+
+func callGetList(client *api.ZabbixClient, method string, params map[string]interface{}) ([]map[string]interface{}, error) {
+	result, err := client.Call(method, params)
+	if err != nil {
+		return nil, err
+	}
+	var items []map[string]interface{}
+	if err := json.Unmarshal(result, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse %s result: %w", method, err)
+	}
+	return items, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,429 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"zabbix-dna/internal/api"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// snapshotObject describes one directory of the git configuration snapshot.
+type snapshotObject struct {
+	Dir       string
+	Method    string
+	IDField   string
+	NameField string
+	Export    string // configuration.export option key; empty when the get result itself is stored
+	Params    map[string]interface{}
+	StripKeys []string // volatile IDs removed from get results
+}
+
+var snapshotObjects = []snapshotObject{
+	{Dir: "templates", Method: "template.get", IDField: "templateid", NameField: "host", Export: "templates"},
+	{Dir: "hosts", Method: "host.get", IDField: "hostid", NameField: "host", Export: "hosts"},
+	{Dir: "mediatypes", Method: "mediatype.get", IDField: "mediatypeid", NameField: "name", Export: "mediaTypes"},
+	{
+		Dir: "actions", Method: "action.get", IDField: "actionid", NameField: "name",
+		Params: map[string]interface{}{
+			"output":                   "extend",
+			"selectFilter":             "extend",
+			"selectOperations":         "extend",
+			"selectRecoveryOperations": "extend",
+			"selectUpdateOperations":   "extend",
+		},
+		StripKeys: []string{"actionid", "operationid", "conditionid", "opmessage_grpid", "opmessage_usrid",
+			"opcommand_hstid", "opcommand_grpid", "opconditionid", "optemplateid", "opgroupid"},
+	},
+	{
+		Dir: "globalmacros", Method: "usermacro.get", IDField: "globalmacroid", NameField: "macro",
+		Params:    map[string]interface{}{"output": "extend", "globalmacro": true},
+		StripKeys: []string{"globalmacroid"},
+	},
+}
+
+type snapshotChange struct {
+	Status string `json:"status"`
+	Object string `json:"object"`
+}
+
+type historyDiffEntry struct {
+	Object string `json:"object"`
+	Change string `json:"change"`
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// writeGitSnapshot exports every snapshot object as sorted YAML into repo and commits the result.
+func writeGitSnapshot(client *api.ZabbixClient, repo string) ([]snapshotChange, string, error) {
+	if err := os.MkdirAll(repo, 0755); err != nil {
+		return nil, "", err
+	}
+	if _, err := os.Stat(filepath.Join(repo, ".git")); os.IsNotExist(err) {
+		if _, err := runGit(repo, "init", "-q"); err != nil {
+			return nil, "", err
+		}
+	}
+
+	for _, obj := range snapshotObjects {
+		dir := filepath.Join(repo, obj.Dir)
+		// Recreate the directory so objects deleted in Zabbix disappear from the tree
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, "", err
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, "", err
+		}
+
+		docs, ids, err := fetchSnapshotObjects(client, obj)
+		if err != nil {
+			return nil, "", err
+		}
+
+		// Names that sanitize to the same file name get their object ID appended, so each
+		// object keeps its file name from one snapshot to the next.
+		sanitized := make(map[string]int)
+		for name := range docs {
+			sanitized[sanitizeBackupName(name)]++
+		}
+		for _, name := range sortedKeys(docs) {
+			fileName := sanitizeBackupName(name)
+			if sanitized[fileName] > 1 {
+				fileName += "_" + ids[name]
+			}
+
+			data, err := yaml.Marshal(docs[name])
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to encode %s/%s: %w", obj.Dir, name, err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, fileName+".yaml"), data, 0644); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	if _, err := runGit(repo, "add", "-A"); err != nil {
+		return nil, "", err
+	}
+	status, err := runGit(repo, "status", "--porcelain")
+	if err != nil {
+		return nil, "", err
+	}
+
+	changes := parseGitStatus(status)
+	if len(changes) == 0 {
+		return nil, "", nil
+	}
+
+	message := snapshotCommitMessage(changes)
+	commitArgs := []string{"commit", "-q", "-m", message}
+	if _, err := runGit(repo, "config", "user.name"); err != nil {
+		commitArgs = append([]string{"-c", "user.name=zabbix-dna", "-c", "user.email=zabbix-dna@localhost"}, commitArgs...)
+	}
+	if _, err := runGit(repo, commitArgs...); err != nil {
+		return nil, "", err
+	}
+
+	rev, err := runGit(repo, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return nil, "", err
+	}
+	return changes, strings.TrimSpace(rev), nil
+}
+
+// fetchSnapshotObjects returns the normalized document of every object and the object IDs,
+// both keyed by name.
+func fetchSnapshotObjects(client *api.ZabbixClient, obj snapshotObject) (map[string]interface{}, map[string]string, error) {
+	params := obj.Params
+	if params == nil {
+		params = map[string]interface{}{"output": []string{obj.IDField, obj.NameField}}
+	}
+	result, err := client.Call(obj.Method, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list %s: %w", obj.Dir, err)
+	}
+
+	var items []map[string]interface{}
+	json.Unmarshal(result, &items)
+
+	docs := make(map[string]interface{})
+	ids := make(map[string]string)
+	for _, item := range items {
+		name := fmt.Sprintf("%v", item[obj.NameField])
+		ids[name] = fmt.Sprintf("%v", item[obj.IDField])
+
+		if obj.Export == "" {
+			docs[name] = stripSnapshotKeys(item, obj.StripKeys)
+			continue
+		}
+
+		exported, err := client.Call("configuration.export", map[string]interface{}{
+			"options": map[string]interface{}{obj.Export: []string{fmt.Sprintf("%v", item[obj.IDField])}},
+			"format":  "json",
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to export %s %s: %w", obj.Dir, name, err)
+		}
+		var source string
+		if err := json.Unmarshal(exported, &source); err != nil {
+			return nil, nil, fmt.Errorf("unexpected export result for %s: %w", name, err)
+		}
+		var doc interface{}
+		if err := json.Unmarshal([]byte(source), &doc); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse export of %s: %w", name, err)
+		}
+		// The export date changes on every run (6.0); drop it so unchanged objects stay unchanged.
+		if m, ok := doc.(map[string]interface{}); ok {
+			if export, ok := m["zabbix_export"].(map[string]interface{}); ok {
+				delete(export, "date")
+			}
+		}
+		docs[name] = doc
+	}
+	return docs, ids, nil
+}
+
+func stripSnapshotKeys(v interface{}, keys []string) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{})
+		for k, child := range val {
+			skip := false
+			for _, s := range keys {
+				if k == s {
+					skip = true
+				}
+			}
+			if !skip {
+				out[k] = stripSnapshotKeys(child, keys)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, child := range val {
+			out[i] = stripSnapshotKeys(child, keys)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func runGit(repo string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+func parseGitStatus(status string) []snapshotChange {
+	var changes []snapshotChange
+	for _, line := range strings.Split(status, "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		path := strings.TrimSpace(line[3:])
+		if i := strings.Index(path, " -> "); i >= 0 {
+			path = path[i+4:]
+		}
+		state := "modified"
+		switch strings.TrimSpace(line[:2]) {
+		case "A", "??":
+			state = "added"
+		case "D":
+			state = "deleted"
+		case "R":
+			state = "renamed"
+		}
+		changes = append(changes, snapshotChange{Status: state, Object: strings.TrimSuffix(path, ".yaml")})
+	}
+	return changes
+}
+
+func snapshotCommitMessage(changes []snapshotChange) string {
+	perDir := make(map[string]int)
+	for _, c := range changes {
+		perDir[strings.SplitN(c.Object, "/", 2)[0]]++
+	}
+	var parts []string
+	for _, dir := range sortedKeys(perDir) {
+		parts = append(parts, fmt.Sprintf("%s: %d", dir, perDir[dir]))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Zabbix snapshot %s: %d objects changed (%s)\n\n",
+		time.Now().Format("2006-01-02 15:04"), len(changes), strings.Join(parts, ", "))
+	for _, c := range changes {
+		fmt.Fprintf(&b, "%s %s\n", c.Status, c.Object)
+	}
+	return b.String()
+}
+
+func newHistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Inspect git-backed configuration history",
+	}
+
+	cmd.AddCommand(newHistoryDiffCmd())
+
+	return cmd
+}
+
+func newHistoryDiffCmd() *cobra.Command {
+	var repo string
+
+	cmd := &cobra.Command{
+		Use:   "diff [rev1] [rev2]",
+		Short: "Show a field-by-field diff between two configuration snapshots",
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			rev1 := args[0]
+			rev2 := "HEAD"
+			if len(args) > 1 {
+				rev2 = args[1]
+			}
+
+			out, err := runGit(repo, "diff", "--name-status", "--no-renames", rev1, rev2, "--", "*.yaml")
+			handleError(err)
+
+			var entries []historyDiffEntry
+			for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+				fields := strings.Fields(line)
+				if len(fields) < 2 {
+					continue
+				}
+				path := strings.Join(fields[1:], " ")
+				object := strings.TrimSuffix(path, ".yaml")
+
+				switch fields[0] {
+				case "A":
+					entries = append(entries, historyDiffEntry{Object: object, Change: "added"})
+				case "D":
+					entries = append(entries, historyDiffEntry{Object: object, Change: "deleted"})
+				default:
+					before, err := loadSnapshotRevision(repo, rev1, path)
+					handleError(err)
+					after, err := loadSnapshotRevision(repo, rev2, path)
+					handleError(err)
+					entries = append(entries, diffDocuments(object, before, after)...)
+				}
+			}
+
+			headers := []string{"Object", "Change", "Field", "Before", "After"}
+			var rows [][]string
+			for _, e := range entries {
+				rows = append(rows, []string{e.Object, e.Change, e.Field, e.Before, e.After})
+			}
+			outputResult(cmd, entries, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringVar(&repo, "git", ".", "Path of the snapshot git repository")
+
+	return cmd
+}
+
+func loadSnapshotRevision(repo, rev, path string) (interface{}, error) {
+	data, err := runGit(repo, "show", rev+":"+path)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal([]byte(data), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s at %s: %w", path, rev, err)
+	}
+	return doc, nil
+}
+
+// diffDocuments compares two documents leaf by leaf and reports changed fields.
+func diffDocuments(object string, before, after interface{}) []historyDiffEntry {
+	a := make(map[string]string)
+	b := make(map[string]string)
+	flattenDocument("", before, a)
+	flattenDocument("", after, b)
+
+	keys := make(map[string]bool)
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+
+	var entries []historyDiffEntry
+	for _, k := range sortedKeys(keys) {
+		va, inA := a[k]
+		vb, inB := b[k]
+		switch {
+		case inA && !inB:
+			entries = append(entries, historyDiffEntry{Object: object, Change: "removed", Field: k, Before: va})
+		case !inA && inB:
+			entries = append(entries, historyDiffEntry{Object: object, Change: "added", Field: k, After: vb})
+		case va != vb:
+			entries = append(entries, historyDiffEntry{Object: object, Change: "changed", Field: k, Before: va, After: vb})
+		}
+	}
+	return entries
+}
+
+// flattenDocument turns nested maps and lists into dotted paths. List elements are
+// addressed by their name, key or macro when present so reordering does not show up as a change.
+func flattenDocument(prefix string, v interface{}, out map[string]string) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			flattenDocument(joinDocumentPath(prefix, k), child, out)
+		}
+	case []interface{}:
+		for i, child := range val {
+			flattenDocument(prefix+listElementKey(child, i), child, out)
+		}
+	default:
+		out[prefix] = fmt.Sprintf("%v", val)
+	}
+}
+
+func joinDocumentPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// listElementKey names a list element by its most specific identifying field, so elements are
+// paired by identity rather than position; names come last as they need not be unique.
+func listElementKey(v interface{}, index int) string {
+	if m, ok := v.(map[string]interface{}); ok {
+		for _, field := range []string{"uuid", "key", "key_", "expression", "macro", "tag", "name"} {
+			if id, ok := m[field]; ok {
+				return fmt.Sprintf("[%s=%v]", field, id)
+			}
+		}
+	}
+	return fmt.Sprintf("[%d]", index)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -381,8 +381,9 @@ func newHostGroupPermissionsCmd() *cobra.Command {
 			}
 
 			// Get user groups and their permissions
+			keys := getUserGroupRights(client)
 			res, err := client.Call("usergroup.get", map[string]interface{}{
-				"selectRights": "extend",
+				keys.hostParam: "extend",
 				"output":       []string{"usrgrpid", "name"},
 			})
 			handleError(err)
@@ -393,7 +394,7 @@ func newHostGroupPermissionsCmd() *cobra.Command {
 			var rows [][]string
 
 			permissionMap := map[string]string{
-				"0": "None",
+				"0": "Deny",
 				"2": "Read-only",
 				"3": "Read-write",
 			}
@@ -410,7 +411,7 @@ func newHostGroupPermissionsCmd() *cobra.Command {
 				gid := groups[0]["groupid"].(string)
 
 				for _, ug := range userGroups {
-					if rights, ok := ug["rights"].([]interface{}); ok {
+					if rights, ok := ug[keys.hostKey].([]interface{}); ok {
 						for _, r := range rights {
 							right := r.(map[string]interface{})
 							if right["id"].(string) == gid {
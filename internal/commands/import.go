@@ -0,0 +1,302 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"zabbix-dna/internal/api"
+
+	"github.com/spf13/cobra"
+)
+
+// importRuleOptions lists which rule options each configuration.import object type accepts.
+var importRuleOptions = map[string][]string{
+	"host_groups":        {"createMissing", "updateExisting"},
+	"template_groups":    {"createMissing", "updateExisting"},
+	"hosts":              {"createMissing", "updateExisting"},
+	"templates":          {"createMissing", "updateExisting"},
+	"templateLinkage":    {"createMissing", "deleteMissing"},
+	"templateDashboards": {"createMissing", "updateExisting", "deleteMissing"},
+	"items":              {"createMissing", "updateExisting", "deleteMissing"},
+	"discoveryRules":     {"createMissing", "updateExisting", "deleteMissing"},
+	"triggers":           {"createMissing", "updateExisting", "deleteMissing"},
+	"graphs":             {"createMissing", "updateExisting", "deleteMissing"},
+	"httptests":          {"createMissing", "updateExisting", "deleteMissing"},
+	"valueMaps":          {"createMissing", "updateExisting", "deleteMissing"},
+	"maps":               {"createMissing", "updateExisting"},
+	"mediaTypes":         {"createMissing", "updateExisting"},
+	"images":             {"createMissing", "updateExisting"},
+}
+
+// importPresets maps a preset name to the rule options it enables on every object type.
+var importPresets = map[string][]string{
+	"safe":   {"createMissing"},
+	"sync":   {"createMissing", "updateExisting"},
+	"mirror": {"createMissing", "updateExisting", "deleteMissing"},
+}
+
+func newImportCmd() *cobra.Command {
+	var preset string
+	var format string
+	var overrides []string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "import [file]",
+		Short: "Import Zabbix configurations from a json, yaml or xml file",
+		Long: `Import Zabbix configurations using configuration.import.
+
+Rules are selected through presets:
+  safe    create missing objects only
+  sync    create missing and update existing objects
+  mirror  create, update and delete objects so the server matches the file
+
+Individual object types can be overridden with --rule, e.g.
+  --rule items=create,update --rule templateLinkage=none`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			fileFormat, source, err := readImportSource(args[0], format)
+			handleError(err)
+
+			rules, err := buildImportRules(preset, overrides)
+			handleError(err)
+			rules = versionImportRules(rules, getAPIVersion(client))
+
+			changes, err := compareImport(client, fileFormat, source, rules)
+			handleError(err)
+
+			headers := []string{"Object Type", "Added", "Updated", "Removed", "Status"}
+			if dryRun {
+				outputResult(cmd, changes, headers, importChangeRows(changes, "Pending"))
+				return
+			}
+
+			_, err = client.Call("configuration.import", map[string]interface{}{
+				"format": fileFormat,
+				"source": source,
+				"rules":  rules,
+			})
+			handleError(err)
+
+			rows := importChangeRows(changes, "Imported")
+			if len(rows) == 0 {
+				outputResult(cmd, fmt.Sprintf("Imported %s, no changes were needed.", args[0]), nil, nil)
+				return
+			}
+			outputResult(cmd, changes, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringVarP(&preset, "preset", "p", "safe", "Import rule preset (safe, sync, mirror)")
+	cmd.Flags().StringVarP(&format, "format", "f", "", "Source format (json, yaml, xml; detected from extension by default)")
+	cmd.Flags().StringArrayVar(&overrides, "rule", []string{}, "Override rules for an object type (type=create,update,delete|none)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only show what would change")
+
+	return cmd
+}
+
+// readImportSource loads an import file and determines its format.
+// Files written by older backups contain the export as a JSON string; those are unwrapped.
+func readImportSource(path, format string) (string, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			format = "json"
+		case ".yaml", ".yml":
+			format = "yaml"
+		case ".xml":
+			format = "xml"
+		default:
+			return "", "", fmt.Errorf("cannot detect format of %s, use --format", path)
+		}
+	}
+
+	switch format {
+	case "json", "yaml", "xml":
+	default:
+		return "", "", fmt.Errorf("unsupported format: %s", format)
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if format == "json" && len(trimmed) > 0 && trimmed[0] == '"' {
+		var source string
+		if err := json.Unmarshal(trimmed, &source); err != nil {
+			return "", "", fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return format, source, nil
+	}
+
+	return format, string(data), nil
+}
+
+// buildImportRules expands a preset into configuration.import rules and applies per-type overrides.
+func buildImportRules(preset string, overrides []string) (map[string]interface{}, error) {
+	enabled, ok := importPresets[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown preset: %s (expected safe, sync or mirror)", preset)
+	}
+
+	rules := make(map[string]interface{})
+	for objType := range importRuleOptions {
+		rules[objType] = importRuleSet(objType, enabled)
+	}
+
+	for _, o := range overrides {
+		parts := strings.SplitN(o, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid rule override: %s (expected type=create,update,delete)", o)
+		}
+		objType := strings.TrimSpace(parts[0])
+		if _, ok := importRuleOptions[objType]; !ok {
+			return nil, fmt.Errorf("unknown object type in rule override: %s", objType)
+		}
+
+		var opts []string
+		for _, v := range strings.Split(parts[1], ",") {
+			switch strings.TrimSpace(v) {
+			case "create":
+				opts = append(opts, "createMissing")
+			case "update":
+				opts = append(opts, "updateExisting")
+			case "delete":
+				opts = append(opts, "deleteMissing")
+			case "none", "":
+			default:
+				return nil, fmt.Errorf("invalid rule option in %s: %s", o, v)
+			}
+		}
+		rules[objType] = importRuleSet(objType, opts)
+	}
+
+	return rules, nil
+}
+
+// versionImportRules adapts rules to servers before Zabbix 6.2, which know a single "groups"
+// rule (createMissing only) instead of host_groups and template_groups.
+func versionImportRules(rules map[string]interface{}, version string) map[string]interface{} {
+	if version == "" || apiVersionAtLeast(version, 6, 2) {
+		return rules
+	}
+	create := false
+	for _, key := range []string{"host_groups", "template_groups"} {
+		if set, ok := rules[key].(map[string]bool); ok && set["createMissing"] {
+			create = true
+		}
+		delete(rules, key)
+	}
+	rules["groups"] = map[string]bool{"createMissing": create}
+	return rules
+}
+
+func importRuleSet(objType string, enabled []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, opt := range importRuleOptions[objType] {
+		set[opt] = false
+		for _, e := range enabled {
+			if e == opt {
+				set[opt] = true
+			}
+		}
+	}
+	return set
+}
+
+// compareImport runs configuration.importcompare and returns its change tree.
+func compareImport(client *api.ZabbixClient, format, source string, rules map[string]interface{}) (map[string]interface{}, error) {
+	result, err := client.Call("configuration.importcompare", map[string]interface{}{
+		"format": format,
+		"source": source,
+		"rules":  rules,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]interface{})
+	// An empty comparison is returned as [] instead of {}
+	if bytes.HasPrefix(bytes.TrimSpace(result), []byte("{")) {
+		if err := json.Unmarshal(result, &changes); err != nil {
+			return nil, fmt.Errorf("failed to parse import comparison: %w", err)
+		}
+	}
+	return changes, nil
+}
+
+// importChangeRows flattens the importcompare tree into per-type added/updated/removed counts.
+func importChangeRows(changes map[string]interface{}, status string) [][]string {
+	counts := make(map[string][3]int)
+	countImportChanges(changes, "", counts)
+
+	var types []string
+	for t := range counts {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	var rows [][]string
+	for _, t := range types {
+		c := counts[t]
+		if c[0] == 0 && c[1] == 0 && c[2] == 0 {
+			continue
+		}
+		rows = append(rows, []string{t, fmt.Sprintf("%d", c[0]), fmt.Sprintf("%d", c[1]), fmt.Sprintf("%d", c[2]), status})
+	}
+	return rows
+}
+
+func countImportChanges(node map[string]interface{}, prefix string, counts map[string][3]int) {
+	for objType, v := range node {
+		if objType == "before" || objType == "after" {
+			continue
+		}
+		entry, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := objType
+		if prefix != "" {
+			path = prefix + "/" + objType
+		}
+
+		c := counts[path]
+		if added, ok := entry["added"].([]interface{}); ok {
+			c[0] += len(added)
+		}
+		if removed, ok := entry["removed"].([]interface{}); ok {
+			c[2] += len(removed)
+		}
+		if updated, ok := entry["updated"].([]interface{}); ok {
+			for _, u := range updated {
+				upd, ok := u.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				// Entries that only carry nested changes are not themselves modified
+				if !jsonEqual(upd["before"], upd["after"]) {
+					c[1]++
+				}
+				countImportChanges(upd, path, counts)
+			}
+		}
+		counts[path] = c
+	}
+}
+
+func jsonEqual(a, b interface{}) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return bytes.Equal(ja, jb)
+}
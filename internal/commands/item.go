@@ -3,10 +3,57 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"zabbix-dna/internal/api"
 
 	"github.com/spf13/cobra"
 )
 
+// preprocessingTypes maps CLI names of preprocessing steps to their Zabbix type codes.
+var preprocessingTypes = map[string]int{
+	"multiplier":                  1,
+	"rtrim":                       2,
+	"ltrim":                       3,
+	"trim":                        4,
+	"regex":                       5,
+	"bool-to-decimal":             6,
+	"octal-to-decimal":            7,
+	"hex-to-decimal":              8,
+	"simple-change":               9,
+	"change-per-second":           10,
+	"xmlpath":                     11,
+	"jsonpath":                    12,
+	"in-range":                    13,
+	"matches-regex":               14,
+	"not-matches-regex":           15,
+	"check-json-error":            16,
+	"check-xml-error":             17,
+	"check-regex-error":           18,
+	"discard-unchanged":           19,
+	"discard-unchanged-heartbeat": 20,
+	"javascript":                  21,
+	"prometheus-pattern":          22,
+	"prometheus-to-json":          23,
+	"csv-to-json":                 24,
+	"replace":                     25,
+	"check-unsupported":           26,
+	"xml-to-json":                 27,
+	"snmp-walk-value":             28,
+	"snmp-walk-to-json":           29,
+	"snmp-get-value":              30,
+}
+
+// itemInterfaceTypes maps item types that require an interface to the host interface type.
+var itemInterfaceTypes = map[int]string{
+	0:  "1", // Zabbix agent
+	20: "2", // SNMP agent
+	12: "3", // IPMI agent
+	16: "4", // JMX agent
+}
+
 func newItemCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "item",
@@ -15,6 +62,10 @@ func newItemCmd() *cobra.Command {
 
 	cmd.AddCommand(newItemListCmd())
 	cmd.AddCommand(newItemCreateCmd())
+	cmd.AddCommand(newItemUpdateCmd())
+	cmd.AddCommand(newItemDeleteCmd())
+	cmd.AddCommand(newItemTestCmd())
+	cmd.AddCommand(newItemClearHistoryCmd())
 
 	return cmd
 }
@@ -76,31 +127,123 @@ func newItemListCmd() *cobra.Command {
 	return cmd
 }
 
+// itemOptions holds the item properties shared by create and update.
+type itemOptions struct {
+	delay       string
+	units       string
+	history     string
+	trends      string
+	valueMap    string
+	description string
+	tags        []string
+	preprocess  []string
+}
+
+func (o *itemOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.delay, "delay", "d", "1m", "Update interval")
+	cmd.Flags().StringVar(&o.units, "units", "", "Value units (e.g. B, bps, %)")
+	cmd.Flags().StringVar(&o.history, "history", "", "History storage period (e.g. 31d)")
+	cmd.Flags().StringVar(&o.trends, "trends", "", "Trends storage period (e.g. 365d)")
+	cmd.Flags().StringVar(&o.valueMap, "valuemap", "", "Value map name defined on the host")
+	cmd.Flags().StringVar(&o.description, "description", "", "Item description")
+	cmd.Flags().StringArrayVar(&o.tags, "tag", []string{}, "Item tag as name=value (repeatable)")
+	cmd.Flags().StringArrayVar(&o.preprocess, "preprocess", []string{}, `Preprocessing step as type[:params], params separated by \n (repeatable, e.g. multiplier:8)`)
+}
+
+// apply copies the options that were set on the command line into params.
+func (o *itemOptions) apply(cmd *cobra.Command, client *api.ZabbixClient, hostID string, params map[string]interface{}) error {
+	flags := cmd.Flags()
+	if flags.Changed("delay") {
+		params["delay"] = o.delay
+	}
+	if flags.Changed("units") {
+		params["units"] = o.units
+	}
+	if flags.Changed("history") {
+		params["history"] = o.history
+	}
+	if flags.Changed("trends") {
+		params["trends"] = o.trends
+	}
+	if flags.Changed("description") {
+		params["description"] = o.description
+	}
+	if flags.Changed("valuemap") {
+		valueMapID := "0"
+		if o.valueMap != "" {
+			id, err := getValueMapID(client, hostID, o.valueMap)
+			if err != nil {
+				return err
+			}
+			valueMapID = id
+		}
+		params["valuemapid"] = valueMapID
+	}
+	if flags.Changed("tag") {
+		tags, err := parseTagFlags(o.tags)
+		if err != nil {
+			return err
+		}
+		params["tags"] = tags
+	}
+	if flags.Changed("preprocess") {
+		steps, err := parsePreprocessingSteps(o.preprocess)
+		if err != nil {
+			return err
+		}
+		params["preprocessing"] = steps
+	}
+	return nil
+}
+
 func newItemCreateCmd() *cobra.Command {
 	var hostID string
+	var hostName string
 	var key string
 	var itemType int
 	var valueType int
 	var interfaceID string
-	var delay string
+	var opts itemOptions
 
 	cmd := &cobra.Command{
 		Use:   "create [item name]",
 		Short: "Create a new Zabbix item",
-		Args:  cobra.ExactArgs(1),
+		Example: `  zabbix-dna item create "Inbound traffic" --host web01 --key 'net.if.in[eth0]' \
+    --units bps --preprocess change-per-second --preprocess multiplier:8 --tag component=network`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			client, err := getZabbixClient(cmd)
 			handleError(err)
 
+			if hostID == "" {
+				if hostName == "" {
+					handleError(fmt.Errorf("either --host or --hostid is required"))
+				}
+				hostID = getHostID(client, hostName)
+				if hostID == "" {
+					handleError(fmt.Errorf("host not found: %s", hostName))
+				}
+			}
+
+			if interfaceID == "" {
+				if ifaceType, ok := itemInterfaceTypes[itemType]; ok {
+					interfaceID, err = getMainInterfaceID(client, hostID, ifaceType)
+					handleError(err)
+				}
+			}
+
 			params := map[string]interface{}{
-				"name":        args[0],
-				"key_":        key,
-				"hostid":      hostID,
-				"type":        itemType,
-				"value_type":  valueType,
-				"interfaceid": interfaceID,
-				"delay":       delay,
+				"name":       args[0],
+				"key_":       key,
+				"hostid":     hostID,
+				"type":       itemType,
+				"value_type": valueType,
+				"delay":      opts.delay,
 			}
+			if interfaceID != "" {
+				params["interfaceid"] = interfaceID
+			}
+			handleError(opts.apply(cmd, client, hostID, params))
 
 			result, err := client.Call("item.create", params)
 			handleError(err)
@@ -116,14 +259,308 @@ func newItemCreateCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&hostID, "hostid", "H", "", "Host ID for the item")
+	cmd.Flags().StringVar(&hostName, "host", "", "Host name for the item")
 	cmd.Flags().StringVarP(&key, "key", "k", "", "Key for the item")
 	cmd.Flags().IntVarP(&itemType, "type", "t", 0, "Item type (default: 0 - Zabbix agent)")
 	cmd.Flags().IntVarP(&valueType, "value-type", "v", 3, "Value type (default: 3 - Numeric unsigned)")
-	cmd.Flags().StringVarP(&interfaceID, "interfaceid", "i", "", "Interface ID for the item")
-	cmd.Flags().StringVarP(&delay, "delay", "d", "1m", "Update interval (default: 1m)")
-	cmd.MarkFlagRequired("hostid")
+	cmd.Flags().StringVarP(&interfaceID, "interfaceid", "i", "", "Interface ID for the item (default: host main interface for the item type)")
+	opts.addFlags(cmd)
 	cmd.MarkFlagRequired("key")
-	cmd.MarkFlagRequired("interfaceid")
 
 	return cmd
 }
+
+func newItemUpdateCmd() *cobra.Command {
+	var name string
+	var status string
+	var opts itemOptions
+
+	cmd := &cobra.Command{
+		Use:   "update [host name] [item key]",
+		Short: "Update a Zabbix item",
+		Long:  "Update a Zabbix item. Only the flags given are changed; --tag and --preprocess replace the existing lists.",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			hostID := getHostID(client, args[0])
+			if hostID == "" {
+				handleError(fmt.Errorf("host not found: %s", args[0]))
+			}
+			item, err := getItemByKey(client, hostID, args[1])
+			handleError(err)
+
+			params := map[string]interface{}{
+				"itemid": item["itemid"],
+			}
+			if name != "" {
+				params["name"] = name
+			}
+			switch status {
+			case "":
+			case "enable":
+				params["status"] = "0"
+			case "disable":
+				params["status"] = "1"
+			default:
+				handleError(fmt.Errorf("invalid status: %s (expected enable or disable)", status))
+			}
+			handleError(opts.apply(cmd, client, hostID, params))
+
+			result, err := client.Call("item.update", params)
+			handleError(err)
+
+			var resp map[string]interface{}
+			json.Unmarshal(result, &resp)
+
+			headers := []string{"Host", "Key", "Action", "Status"}
+			rows := [][]string{{args[0], args[1], "Update", "Success"}}
+			outputResult(cmd, resp, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringVarP(&name, "name", "n", "", "New item name")
+	cmd.Flags().StringVar(&status, "status", "", "Set item status (enable/disable)")
+	opts.addFlags(cmd)
+
+	return cmd
+}
+
+func newItemDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete [host name] [item key...]",
+		Short: "Delete Zabbix items by key",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			hostID := getHostID(client, args[0])
+			if hostID == "" {
+				handleError(fmt.Errorf("host not found: %s", args[0]))
+			}
+
+			var itemIDs []string
+			for _, key := range args[1:] {
+				item, err := getItemByKey(client, hostID, key)
+				handleError(err)
+				itemIDs = append(itemIDs, fmt.Sprintf("%v", item["itemid"]))
+			}
+
+			result, err := client.Call("item.delete", itemIDs)
+			handleError(err)
+
+			var resp map[string]interface{}
+			json.Unmarshal(result, &resp)
+
+			headers := []string{"Host", "Key", "Action", "Status", "ID"}
+			var rows [][]string
+			for i, key := range args[1:] {
+				rows = append(rows, []string{args[0], key, "Delete", "Success", itemIDs[i]})
+			}
+			outputResult(cmd, resp, headers, rows)
+		},
+	}
+}
+
+func newItemTestCmd() *cobra.Command {
+	var wait time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "test [host name] [item key]",
+		Short: "Request an immediate check of an item and show the new value",
+		Long: `Create a "check now" task for the item and poll until the server stores a newer value.
+
+The Zabbix API has no public call to evaluate an item directly, so the live result
+is read back from the item once the forced check has completed. Use --wait 0 to only
+schedule the check.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			hostID := getHostID(client, args[0])
+			if hostID == "" {
+				handleError(fmt.Errorf("host not found: %s", args[0]))
+			}
+			item, err := getItemByKey(client, hostID, args[1])
+			handleError(err)
+			itemID := fmt.Sprintf("%v", item["itemid"])
+			previousClock := fmt.Sprintf("%v", item["lastclock"])
+
+			_, err = client.Call("task.create", []map[string]interface{}{
+				{
+					"type":    6, // Check now
+					"request": map[string]interface{}{"itemid": itemID},
+				},
+			})
+			handleError(err)
+
+			if wait <= 0 {
+				outputResult(cmd, fmt.Sprintf("Scheduled check for %s on %s.", args[1], args[0]), nil, nil)
+				return
+			}
+
+			deadline := time.Now().Add(wait)
+			for time.Now().Before(deadline) {
+				time.Sleep(2 * time.Second)
+				item, err = getItemByKey(client, hostID, args[1])
+				handleError(err)
+				if fmt.Sprintf("%v", item["lastclock"]) != previousClock || item["state"] == "1" {
+					break
+				}
+			}
+
+			result := "Value received"
+			if item["state"] == "1" {
+				result = "Not supported"
+			} else if fmt.Sprintf("%v", item["lastclock"]) == previousClock {
+				result = "No new value before timeout"
+			}
+
+			headers := []string{"Property", "Value"}
+			rows := [][]string{
+				{"ItemID", itemID},
+				{"Key", args[1]},
+				{"Result", result},
+				{"Last Value", fmt.Sprintf("%v %v", item["lastvalue"], item["units"])},
+				{"Last Check", formatUnixTime(fmt.Sprintf("%v", item["lastclock"]))},
+			}
+			if errMsg, ok := item["error"].(string); ok && errMsg != "" {
+				rows = append(rows, []string{"Error", errMsg})
+			}
+			outputResult(cmd, item, headers, rows)
+		},
+	}
+
+	cmd.Flags().DurationVarP(&wait, "wait", "w", 30*time.Second, "How long to wait for the new value")
+
+	return cmd
+}
+
+func newItemClearHistoryCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clear-history [host name] [item key...]",
+		Short: "Clear history and trends of items",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			hostID := getHostID(client, args[0])
+			if hostID == "" {
+				handleError(fmt.Errorf("host not found: %s", args[0]))
+			}
+
+			var itemIDs []string
+			for _, key := range args[1:] {
+				item, err := getItemByKey(client, hostID, key)
+				handleError(err)
+				itemIDs = append(itemIDs, fmt.Sprintf("%v", item["itemid"]))
+			}
+
+			result, err := client.Call("history.clear", itemIDs)
+			handleError(err)
+
+			var resp map[string]interface{}
+			json.Unmarshal(result, &resp)
+
+			headers := []string{"Host", "Key", "Action", "Status"}
+			var rows [][]string
+			for _, key := range args[1:] {
+				rows = append(rows, []string{args[0], key, "Clear History", "Success"})
+			}
+			outputResult(cmd, resp, headers, rows)
+		},
+	}
+}
+
+func getItemByKey(client *api.ZabbixClient, hostID, key string) (map[string]interface{}, error) {
+	result, err := client.Call("item.get", map[string]interface{}{
+		"output":  "extend",
+		"hostids": []string{hostID},
+		"filter":  map[string]interface{}{"key_": key},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var items []map[string]interface{}
+	json.Unmarshal(result, &items)
+	if len(items) == 0 {
+		return nil, fmt.Errorf("item not found: %s", key)
+	}
+	return items[0], nil
+}
+
+func getMainInterfaceID(client *api.ZabbixClient, hostID, ifaceType string) (string, error) {
+	result, err := client.Call("hostinterface.get", map[string]interface{}{
+		"output":  []string{"interfaceid", "main"},
+		"hostids": []string{hostID},
+		"filter":  map[string]interface{}{"type": ifaceType, "main": "1"},
+	})
+	if err != nil {
+		return "", err
+	}
+	var interfaces []map[string]interface{}
+	json.Unmarshal(result, &interfaces)
+	if len(interfaces) == 0 {
+		return "", fmt.Errorf("host has no main %s interface, use --interfaceid", getInterfaceTypeName(ifaceType))
+	}
+	return fmt.Sprintf("%v", interfaces[0]["interfaceid"]), nil
+}
+
+func getValueMapID(client *api.ZabbixClient, hostID, name string) (string, error) {
+	result, err := client.Call("valuemap.get", map[string]interface{}{
+		"output":  []string{"valuemapid", "name"},
+		"hostids": []string{hostID},
+		"filter":  map[string]interface{}{"name": name},
+	})
+	if err != nil {
+		return "", err
+	}
+	var maps []map[string]interface{}
+	json.Unmarshal(result, &maps)
+	if len(maps) == 0 {
+		return "", fmt.Errorf("value map not found on host: %s", name)
+	}
+	return fmt.Sprintf("%v", maps[0]["valuemapid"]), nil
+}
+
+// parsePreprocessingSteps converts "type[:params]" flags into item preprocessing objects.
+func parsePreprocessingSteps(flags []string) ([]map[string]interface{}, error) {
+	var steps []map[string]interface{}
+	for _, f := range flags {
+		parts := strings.SplitN(f, ":", 2)
+		name := strings.ToLower(strings.TrimSpace(parts[0]))
+
+		stepType, ok := preprocessingTypes[name]
+		if !ok {
+			n, err := strconv.Atoi(name)
+			if err != nil {
+				return nil, fmt.Errorf("unknown preprocessing type: %s", parts[0])
+			}
+			stepType = n
+		}
+
+		params := ""
+		if len(parts) == 2 {
+			params = strings.ReplaceAll(parts[1], `\n`, "\n")
+		}
+		steps = append(steps, map[string]interface{}{
+			"type":                 stepType,
+			"params":               params,
+			"error_handler":        0,
+			"error_handler_params": "",
+		})
+	}
+	return steps, nil
+}
+
+func formatUnixTime(clock string) string {
+	sec, err := strconv.ParseInt(clock, 10, 64)
+	if err != nil || sec == 0 {
+		return "-"
+	}
+	return time.Unix(sec, 0).Format("2006-01-02 15:04:05")
+}
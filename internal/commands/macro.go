@@ -1,12 +1,29 @@
 package commands
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"zabbix-dna/internal/api"
 
 	"github.com/spf13/cobra"
 )
 
+// macroTypes maps CLI names of user macro types to their Zabbix codes.
+var macroTypes = map[string]string{
+	"text":   "0",
+	"secret": "1",
+	"vault":  "2",
+}
+
+// sensitiveMacroWords mark text macros that are masked like secret ones.
+var sensitiveMacroWords = []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "APIKEY", "API_KEY", "PRIVATE"}
+
 func newMacroCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "macro",
@@ -15,30 +32,82 @@ func newMacroCmd() *cobra.Command {
 
 	cmd.AddCommand(newMacroListCmd())   // show_host_macros -> macro list
 	cmd.AddCommand(newMacroCreateCmd()) // create_global_macro -> macro create
+	cmd.AddCommand(newMacroSetCmd())
+	cmd.AddCommand(newMacroUpdateCmd())
+	cmd.AddCommand(newMacroDeleteCmd())
+	cmd.AddCommand(newMacroBulkSetCmd())
+	cmd.AddCommand(newMacroResolveCmd())
 
 	return cmd
 }
 
+// macroScope selects the host, template or global scope of a macro command.
+type macroScope struct {
+	hostName     string
+	templateName string
+}
+
+func (s *macroScope) addFlags(cmd *cobra.Command, verb string) {
+	cmd.Flags().StringVarP(&s.hostName, "host", "H", "", "Host name to "+verb+" macros for")
+	cmd.Flags().StringVarP(&s.templateName, "template", "T", "", "Template name to "+verb+" macros for")
+}
+
+// resolve returns the host or template ID, or "" for global macros.
+func (s *macroScope) resolve(client *api.ZabbixClient) (string, error) {
+	switch {
+	case s.hostName != "" && s.templateName != "":
+		return "", fmt.Errorf("use either --host or --template")
+	case s.hostName != "":
+		id := getHostID(client, s.hostName)
+		if id == "" {
+			return "", fmt.Errorf("host not found: %s", s.hostName)
+		}
+		return id, nil
+	case s.templateName != "":
+		id := getTemplateID(client, s.templateName)
+		if id == "" {
+			return "", fmt.Errorf("template not found: %s", s.templateName)
+		}
+		return id, nil
+	}
+	return "", nil
+}
+
+func (s *macroScope) String() string {
+	switch {
+	case s.hostName != "":
+		return "host " + s.hostName
+	case s.templateName != "":
+		return "template " + s.templateName
+	}
+	return "global"
+}
+
 func newMacroListCmd() *cobra.Command {
-	var hostName string
-	var templateName string
+	var scope macroScope
+	var reveal bool
 
 	cmd := &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"show_host_macros"},
 		Short:   "List macros for a host or template",
+		Long: `List global macros, or the macros of a host or template.
+
+Secret and vault macros, and text macros whose name looks like a password or token, are
+masked unless --reveal is given. Zabbix never returns the value of secret macros.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			client, err := getZabbixClient(cmd)
 			handleError(err)
 
+			hostID, err := scope.resolve(client)
+			handleError(err)
+
 			params := map[string]interface{}{
 				"output": "extend",
 			}
 
-			if hostName != "" {
-				params["hostids"] = []string{getHostID(client, hostName)}
-			} else if templateName != "" {
-				params["templateids"] = []string{getTemplateID(client, templateName)}
+			if hostID != "" {
+				params["hostids"] = []string{hostID}
 			} else {
 				params["globalmacro"] = true
 			}
@@ -50,12 +119,15 @@ func newMacroListCmd() *cobra.Command {
 			var macros []map[string]interface{}
 			json.Unmarshal(result, &macros)
 
-			headers := []string{"Macro", "Value"}
+			headers := []string{"Macro", "Value", "Type", "Description"}
 			var rows [][]string
 			for _, m := range macros {
+				m["value"] = maskMacroValue(m, reveal)
 				rows = append(rows, []string{
 					fmt.Sprintf("%v", m["macro"]),
 					fmt.Sprintf("%v", m["value"]),
+					getMacroTypeName(m),
+					exportString(m, "description"),
 				})
 			}
 
@@ -63,38 +135,601 @@ func newMacroListCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&hostName, "host", "H", "", "Host name to list macros for")
-	cmd.Flags().StringVarP(&templateName, "template", "T", "", "Template name to list macros for")
+	scope.addFlags(cmd, "list")
+	cmd.Flags().BoolVar(&reveal, "reveal", false, "Show values of sensitive text macros and vault paths")
 
 	return cmd
 }
 
+// macroOptions holds the flags shared by macro create, set and update.
+type macroOptions struct {
+	scope       macroScope
+	macroType   string
+	description string
+}
+
+func (o *macroOptions) addFlags(cmd *cobra.Command) {
+	o.scope.addFlags(cmd, "set")
+	cmd.Flags().StringVar(&o.macroType, "type", "text", "Macro type: text, secret or vault (value is a vault path)")
+	cmd.Flags().StringVar(&o.description, "description", "", "Macro description")
+}
+
+// apply adds the changed type and description to usermacro params.
+func (o *macroOptions) apply(cmd *cobra.Command, params map[string]interface{}) error {
+	code, ok := macroTypes[o.macroType]
+	if !ok {
+		return fmt.Errorf("invalid macro type: %s (use text, secret or vault)", o.macroType)
+	}
+	if cmd.Flags().Changed("type") {
+		params["type"] = code
+	}
+	if cmd.Flags().Changed("description") {
+		params["description"] = o.description
+	}
+	return nil
+}
+
 func newMacroCreateCmd() *cobra.Command {
-	return &cobra.Command{
+	var opts macroOptions
+
+	cmd := &cobra.Command{
 		Use:     "create [macro] [value]",
 		Aliases: []string{"create_global_macro"},
-		Short:   "Create a new global macro",
-		Args:    cobra.ExactArgs(2),
+		Short:   "Create a new global, host or template macro",
+		Long: `Create a macro. Without --host or --template a global macro is created.
+Use "-" as value to read it from standard input, keeping secrets out of shell history.`,
+		Args: cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
 			client, err := getZabbixClient(cmd)
 			handleError(err)
 
-			params := map[string]interface{}{
-				"macro": args[0],
-				"value": args[1],
+			hostID, err := opts.scope.resolve(client)
+			handleError(err)
+			value, err := readMacroValue(args[1])
+			handleError(err)
+
+			macro := normalizeMacroName(args[0])
+			_, _, _, err = parseMacro(macro)
+			handleError(err)
+			handleError(createMacro(cmd, client, &opts, hostID, macro, value))
+
+			headers := []string{"Macro", "Scope", "Action", "Status"}
+			rows := [][]string{{macro, opts.scope.String(), "Create", "Success"}}
+			outputResult(cmd, map[string]string{"macro": macro, "scope": opts.scope.String()}, headers, rows)
+		},
+	}
+
+	opts.addFlags(cmd)
+
+	return cmd
+}
+
+func newMacroSetCmd() *cobra.Command {
+	var opts macroOptions
+
+	cmd := &cobra.Command{
+		Use:   "set [macro] [value]",
+		Short: "Create or update a macro",
+		Long: `Create a macro or update it when it already exists in the scope. Without --host or
+--template the global macro is set. Use "-" as value to read it from standard input.`,
+		Example: `  zabbix-dna macro set {$SNMP_COMMUNITY} public --host switch01
+  zabbix-dna macro set DB.PASSWORD - --template "App MySQL" --type secret < password.txt
+  zabbix-dna macro set {$VAULT.DB} secret/zabbix:password --type vault --description "DB credentials"`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			hostID, err := opts.scope.resolve(client)
+			handleError(err)
+			value, err := readMacroValue(args[1])
+			handleError(err)
+
+			macro := normalizeMacroName(args[0])
+			_, _, _, err = parseMacro(macro)
+			handleError(err)
+			existing, err := findMacro(client, hostID, macro)
+			handleError(err)
+
+			action := "Create"
+			if existing == nil {
+				handleError(createMacro(cmd, client, &opts, hostID, macro, value))
+			} else {
+				action = "Update"
+				handleError(updateMacro(cmd, client, &opts, hostID, existing, &value))
 			}
 
-			result, err := client.Call("usermacro.createglobal", params)
+			headers := []string{"Macro", "Scope", "Action", "Status"}
+			rows := [][]string{{macro, opts.scope.String(), action, "Success"}}
+			outputResult(cmd, map[string]string{"macro": macro, "scope": opts.scope.String(), "action": strings.ToLower(action)}, headers, rows)
+		},
+	}
+
+	opts.addFlags(cmd)
+
+	return cmd
+}
+
+func newMacroUpdateCmd() *cobra.Command {
+	var opts macroOptions
+
+	cmd := &cobra.Command{
+		Use:   "update [macro] [value]",
+		Short: "Update an existing macro",
+		Long: `Update the value, type or description of an existing macro. The value can be omitted to
+change only the type or description. Use "-" as value to read it from standard input.`,
+		Example: `  zabbix-dna macro update {$CPU.UTIL.CRIT} 95 --host web01
+  zabbix-dna macro update {$DB.PASSWORD} --template "App MySQL" --description "Rotated monthly"`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			hostID, err := opts.scope.resolve(client)
+			handleError(err)
+
+			macro := normalizeMacroName(args[0])
+			existing, err := findMacro(client, hostID, macro)
+			handleError(err)
+			if existing == nil {
+				handleError(fmt.Errorf("macro %s not found on %s", macro, opts.scope.String()))
+			}
+
+			var value *string
+			if len(args) == 2 {
+				v, err := readMacroValue(args[1])
+				handleError(err)
+				value = &v
+			} else if !cmd.Flags().Changed("type") && !cmd.Flags().Changed("description") {
+				handleError(fmt.Errorf("nothing to update: give a value, --type or --description"))
+			}
+			handleError(updateMacro(cmd, client, &opts, hostID, existing, value))
+
+			headers := []string{"Macro", "Scope", "Action", "Status"}
+			rows := [][]string{{macro, opts.scope.String(), "Update", "Success"}}
+			outputResult(cmd, map[string]string{"macro": macro, "scope": opts.scope.String()}, headers, rows)
+		},
+	}
+
+	opts.addFlags(cmd)
+
+	return cmd
+}
+
+func newMacroDeleteCmd() *cobra.Command {
+	var scope macroScope
+
+	cmd := &cobra.Command{
+		Use:   "delete [macro...]",
+		Short: "Delete macros",
+		Long:  `Delete macros from the global scope, or from a host or template.`,
+		Example: `  zabbix-dna macro delete {$OLD.THRESHOLD}
+  zabbix-dna macro delete {$A} {$B} --host web01`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			hostID, err := scope.resolve(client)
+			handleError(err)
+
+			var ids []string
+			headers := []string{"Macro", "Scope", "Action", "Status"}
+			var rows [][]string
+			for _, arg := range args {
+				macro := normalizeMacroName(arg)
+				existing, err := findMacro(client, hostID, macro)
+				handleError(err)
+				if existing == nil {
+					handleError(fmt.Errorf("macro %s not found on %s", macro, scope.String()))
+				}
+				ids = append(ids, macroID(existing, hostID))
+				rows = append(rows, []string{macro, scope.String(), "Delete", "Success"})
+			}
+
+			method := "usermacro.delete"
+			if hostID == "" {
+				method = "usermacro.deleteglobal"
+			}
+			result, err := client.Call(method, ids)
 			handleError(err)
 
 			var resp map[string]interface{}
 			json.Unmarshal(result, &resp)
-
-			headers := []string{"Macro", "Value", "Action", "Status"}
-			rows := [][]string{{args[0], args[1], "Create Global Macro", "Success"}}
 			outputResult(cmd, resp, headers, rows)
 		},
 	}
+
+	scope.addFlags(cmd, "delete")
+
+	return cmd
 }
 
+func newMacroResolveCmd() *cobra.Command {
+	var reveal bool
 
+	cmd := &cobra.Command{
+		Use:   "resolve [host] [macro]",
+		Short: "Show the effective value of a macro on a host and where it comes from",
+		Long: `Resolve a user macro in the Zabbix server lookup order: the host first, then its linked
+templates level by level (each level in template ID order), then global macros. For a macro
+with context, {$MACRO:"ctx"}, the macro with that exact context is looked up first, then
+macros with a regex context matching it ({$MACRO:regex:"..."}), and the plain macro is used
+as fallback.
+
+Every definition found is listed; the first one is effective and the rest are overridden.
+Regex contexts are evaluated with Go regular expressions, which cover common PCRE syntax;
+patterns that cannot be compiled (e.g. lookarounds or backreferences) are listed as
+"unverified", and a value found after one is marked "effective (unverified)".`,
+		Example: `  zabbix-dna macro resolve web01 {$CPU.UTIL.CRIT}
+  zabbix-dna macro resolve db01 '{$VFS.FS.PUSED.MAX.CRIT:"/var"}'`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			macro := normalizeMacroName(args[1])
+			hosts, err := callGetList(client, "host.get", map[string]interface{}{
+				"output":                []string{"hostid", "host"},
+				"filter":                map[string]interface{}{"host": args[0]},
+				"selectParentTemplates": []string{"templateid", "host"},
+			})
+			handleError(err)
+			if len(hosts) == 0 {
+				handleError(fmt.Errorf("host not found: %s", args[0]))
+			}
+
+			chain, err := macroResolutionChain(client, hosts[0])
+			handleError(err)
+
+			var ids []string
+			for _, link := range chain {
+				ids = append(ids, link.id)
+			}
+			hostMacros, err := callGetList(client, "usermacro.get", map[string]interface{}{
+				"output":  "extend",
+				"hostids": ids,
+			})
+			handleError(err)
+			globalMacros, err := callGetList(client, "usermacro.get", map[string]interface{}{
+				"output":      "extend",
+				"globalmacro": true,
+			})
+			handleError(err)
+
+			byHost := map[string]map[string]map[string]interface{}{}
+			for _, m := range hostMacros {
+				id := fmt.Sprintf("%v", m["hostid"])
+				if byHost[id] == nil {
+					byHost[id] = map[string]map[string]interface{}{}
+				}
+				byHost[id][macroKey(fmt.Sprintf("%v", m["macro"]))] = m
+			}
+			byHost[""] = map[string]map[string]interface{}{}
+			for _, m := range globalMacros {
+				byHost[""][macroKey(fmt.Sprintf("%v", m["macro"]))] = m
+			}
+			chain = append(chain, macroLink{level: "global", name: "global"})
+
+			name, context, hasContext, err := parseMacro(macro)
+			handleError(err)
+
+			// Lookup passes in priority order: the exact context, regex contexts matching it,
+			// then the macro without context.
+			type match struct {
+				link       macroLink
+				macro      map[string]interface{}
+				unverified bool
+			}
+			var matches []match
+			if hasContext {
+				for _, link := range chain {
+					if m, ok := byHost[link.id][macroKey(macro)]; ok {
+						matches = append(matches, match{link: link, macro: m})
+					}
+				}
+			}
+			if hasContext && !strings.HasPrefix(context, "regex:") {
+				for _, link := range chain {
+					for _, key := range sortedKeys(byHost[link.id]) {
+						m := byHost[link.id][key]
+						mName, mContext, _, err := parseMacro(fmt.Sprintf("%v", m["macro"]))
+						if err != nil || mName != name || !strings.HasPrefix(mContext, "regex:") {
+							continue
+						}
+						re, err := regexp.Compile(strings.TrimPrefix(mContext, "regex:"))
+						if err != nil {
+							matches = append(matches, match{link: link, macro: m, unverified: true})
+						} else if re.MatchString(context) {
+							matches = append(matches, match{link: link, macro: m})
+						}
+					}
+				}
+			}
+			for _, link := range chain {
+				if m, ok := byHost[link.id][macroBaseName(macro)]; ok {
+					matches = append(matches, match{link: link, macro: m})
+				}
+			}
+
+			headers := []string{"Level", "Source", "Macro", "Value", "Type", "Status"}
+			var rows [][]string
+			var found []map[string]interface{}
+			effective, uncertain := false, false
+			for _, mt := range matches {
+				status := "overridden"
+				switch {
+				case mt.unverified:
+					status = "unverified"
+					if !effective {
+						uncertain = true
+					}
+				case !effective:
+					effective = true
+					status = "effective"
+					if uncertain {
+						status = "effective (unverified)"
+					}
+				}
+				value := maskMacroValue(mt.macro, reveal)
+				rows = append(rows, []string{mt.link.level, mt.link.name, fmt.Sprintf("%v", mt.macro["macro"]), value, getMacroTypeName(mt.macro), status})
+				found = append(found, map[string]interface{}{
+					"level": mt.link.level, "source": mt.link.name, "macro": mt.macro["macro"],
+					"value": value, "type": getMacroTypeName(mt.macro), "status": status,
+				})
+			}
+			if uncertain {
+				fmt.Fprintf(os.Stderr, "Warning: some regex contexts use syntax that cannot be evaluated here (listed as unverified); if one matches, the Zabbix server uses it instead\n")
+			}
+
+			if len(found) == 0 {
+				outputResult(cmd, fmt.Sprintf("%s is not defined for %s, its templates or globally; it stays unresolved.", macro, args[0]), nil, nil)
+				return
+			}
+			outputResult(cmd, found, headers, rows)
+		},
+	}
+
+	cmd.Flags().BoolVar(&reveal, "reveal", false, "Show values of sensitive text macros and vault paths")
+
+	return cmd
+}
+
+// macroLink is one step of macro resolution: the host or one of its templates.
+type macroLink struct {
+	level string
+	id    string
+	name  string
+}
+
+// macroResolutionChain returns the host followed by its templates, level by level, each level
+// ordered by template ID.
+func macroResolutionChain(client *api.ZabbixClient, host map[string]interface{}) ([]macroLink, error) {
+	chain := []macroLink{{level: "host", id: fmt.Sprintf("%v", host["hostid"]), name: fmt.Sprintf("%v", host["host"])}}
+	seen := map[string]bool{}
+	level := exportList(host["parentTemplates"])
+	for depth := 1; len(level) > 0; depth++ {
+		sort.Slice(level, func(i, j int) bool {
+			return parseClock(level[i]["templateid"]) < parseClock(level[j]["templateid"])
+		})
+		var ids []string
+		for _, t := range level {
+			id := fmt.Sprintf("%v", t["templateid"])
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			ids = append(ids, id)
+			chain = append(chain, macroLink{level: fmt.Sprintf("template (level %d)", depth), id: id, name: fmt.Sprintf("%v", t["host"])})
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		templates, err := callGetList(client, "template.get", map[string]interface{}{
+			"output":                []string{"templateid"},
+			"templateids":           ids,
+			"selectParentTemplates": []string{"templateid", "host"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		level = nil
+		for _, t := range templates {
+			level = append(level, exportList(t["parentTemplates"])...)
+		}
+	}
+	return chain, nil
+}
+
+func createMacro(cmd *cobra.Command, client *api.ZabbixClient, opts *macroOptions, hostID, macro, value string) error {
+	params := map[string]interface{}{
+		"macro": macro,
+		"value": value,
+	}
+	if err := opts.apply(cmd, params); err != nil {
+		return err
+	}
+
+	method := "usermacro.createglobal"
+	if hostID != "" {
+		method = "usermacro.create"
+		params["hostid"] = hostID
+	}
+	_, err := client.Call(method, params)
+	return err
+}
+
+func updateMacro(cmd *cobra.Command, client *api.ZabbixClient, opts *macroOptions, hostID string, existing map[string]interface{}, value *string) error {
+	params := map[string]interface{}{}
+	if err := opts.apply(cmd, params); err != nil {
+		return err
+	}
+	if value != nil {
+		params["value"] = *value
+	}
+	// Zabbix clears a secret value when the type changes without a new value.
+	if _, ok := params["type"]; ok && value == nil && fmt.Sprintf("%v", params["type"]) != fmt.Sprintf("%v", existing["type"]) {
+		return fmt.Errorf("changing the macro type needs the value as well")
+	}
+
+	method := "usermacro.updateglobal"
+	if hostID != "" {
+		method = "usermacro.update"
+		params["hostmacroid"] = existing["hostmacroid"]
+	} else {
+		params["globalmacroid"] = existing["globalmacroid"]
+	}
+	_, err := client.Call(method, params)
+	return err
+}
+
+// findMacro returns the macro in the scope, or nil when it does not exist. Contexts are
+// compared after unquoting, so {$X:db} finds {$X:"db"}.
+func findMacro(client *api.ZabbixClient, hostID, macro string) (map[string]interface{}, error) {
+	params := map[string]interface{}{
+		"output": "extend",
+		"search": map[string]interface{}{"macro": strings.TrimSuffix(macroBaseName(macro), "}")},
+	}
+	if hostID != "" {
+		params["hostids"] = []string{hostID}
+	} else {
+		params["globalmacro"] = true
+	}
+	macros, err := callGetList(client, "usermacro.get", params)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range macros {
+		if macroKey(fmt.Sprintf("%v", m["macro"])) == macroKey(macro) {
+			return m, nil
+		}
+	}
+	return nil, nil
+}
+
+func macroID(m map[string]interface{}, hostID string) string {
+	if hostID == "" {
+		return fmt.Sprintf("%v", m["globalmacroid"])
+	}
+	return fmt.Sprintf("%v", m["hostmacroid"])
+}
+
+// normalizeMacroName accepts "NAME" or "{$NAME}" and returns "{$NAME}".
+func normalizeMacroName(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.HasPrefix(name, "{$") {
+		return name
+	}
+	return "{$" + strings.TrimPrefix(name, "$") + "}"
+}
+
+// parseMacro splits a user macro into its name and context, validating the syntax Zabbix
+// accepts: {$NAME}, {$NAME:context}, {$NAME:"quoted context"} and {$NAME:regex:"..."}.
+func parseMacro(macro string) (name, context string, hasContext bool, err error) {
+	if !strings.HasPrefix(macro, "{$") || !strings.HasSuffix(macro, "}") || len(macro) < 4 {
+		return "", "", false, fmt.Errorf("invalid macro %s: expected {$NAME} or {$NAME:context}", macro)
+	}
+	inner := macro[2 : len(macro)-1]
+	name, context, hasContext = strings.Cut(inner, ":")
+	if name == "" || strings.IndexFunc(name, func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.')
+	}) >= 0 {
+		return "", "", false, fmt.Errorf("invalid macro %s: names use only A-Z, 0-9, _ and .", macro)
+	}
+	if !hasContext {
+		return name, "", false, nil
+	}
+
+	context = strings.TrimLeft(context, " ")
+	prefix := ""
+	if strings.HasPrefix(context, "regex:") {
+		prefix, context = "regex:", strings.TrimLeft(strings.TrimPrefix(context, "regex:"), " ")
+	}
+	if !strings.HasPrefix(context, `"`) {
+		if strings.Contains(context, `"`) {
+			return "", "", false, fmt.Errorf("invalid macro %s: quote the whole context", macro)
+		}
+		return name, prefix + context, true, nil
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(context); i++ {
+		switch c := context[i]; {
+		case c == '\\' && i+1 < len(context) && context[i+1] == '"':
+			b.WriteByte('"')
+			i++
+		case c == '"':
+			if strings.TrimRight(context[i+1:], " ") != "" {
+				return "", "", false, fmt.Errorf("invalid macro %s: unexpected text after quoted context", macro)
+			}
+			return name, prefix + b.String(), true, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", false, fmt.Errorf("invalid macro %s: unterminated quoted context", macro)
+}
+
+// macroKey returns a comparable form of a macro; equivalent context spellings share a key.
+func macroKey(macro string) string {
+	name, context, hasContext, err := parseMacro(macro)
+	if err != nil || !hasContext {
+		return macro
+	}
+	return name + ":" + context
+}
+
+// macroBaseName strips the context of a macro: {$MACRO:"ctx"} becomes {$MACRO}.
+func macroBaseName(macro string) string {
+	if i := strings.Index(macro, ":"); i > 0 {
+		return macro[:i] + "}"
+	}
+	return macro
+}
+
+// readMacroValue returns the value argument, reading standard input when it is "-".
+func readMacroValue(arg string) (string, error) {
+	if arg != "-" {
+		return arg, nil
+	}
+	reader := bufio.NewReader(os.Stdin)
+	value, err := reader.ReadString('\n')
+	if err != nil && value == "" {
+		return "", fmt.Errorf("failed to read value from stdin: %w", err)
+	}
+	return strings.TrimRight(value, "\r\n"), nil
+}
+
+// maskMacroValue hides secret and vault macros, and text macros named like credentials.
+func maskMacroValue(m map[string]interface{}, reveal bool) string {
+	value := exportString(m, "value")
+	switch fmt.Sprintf("%v", m["type"]) {
+	case "1":
+		return "******"
+	case "2":
+		if reveal {
+			return value
+		}
+		return "vault:******"
+	}
+	if !reveal && value != "" {
+		name := strings.ToUpper(fmt.Sprintf("%v", m["macro"]))
+		for _, word := range sensitiveMacroWords {
+			if strings.Contains(name, word) {
+				return "******"
+			}
+		}
+	}
+	return value
+}
+
+func getMacroTypeName(m map[string]interface{}) string {
+	switch fmt.Sprintf("%v", m["type"]) {
+	case "1":
+		return "secret"
+	case "2":
+		return "vault"
+	}
+	return "text"
+}
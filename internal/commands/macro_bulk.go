@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newMacroBulkSetCmd() *cobra.Command {
+	var hostgroups []string
+	var hosts []string
+	var macroName string
+	var value string
+	var macroType string
+	var description string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "bulk-set",
+		Short: "Set a host macro on every host of host groups",
+		Long: `Create or update one macro on every host of the given host groups (and --host entries).
+
+Only the selected macro is touched: it is created with usermacro.create where missing and
+changed with usermacro.update where it differs, so other host macros are kept, unlike
+host.massupdate which replaces the whole macro list.
+
+Existing macros keep their type (text, secret or vault) unless --type is given; a changed
+type is shown in the Action column.
+
+Macro contexts are matched by meaning, so {$X:db} and {$X:"db"} are the same macro. Quote
+the macro in the shell to keep the context quotes. Use "-" as value to read it from stdin.`,
+		Example: `  zabbix-dna macro bulk-set --hostgroup DB --macro '{$CPU.UTIL.CRIT:"db"}' --value 90 --dry-run
+  zabbix-dna macro bulk-set --hostgroup DB --macro '{$DB.PASSWORD}' --value - --type secret < password.txt`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(hostgroups) == 0 && len(hosts) == 0 {
+				handleError(fmt.Errorf("specify at least one --hostgroup or --host"))
+			}
+			macro := normalizeMacroName(macroName)
+			_, _, _, err := parseMacro(macro)
+			handleError(err)
+			if macroType == "" {
+				macroType = "text"
+			}
+			typeCode, ok := macroTypes[macroType]
+			if !ok {
+				handleError(fmt.Errorf("invalid macro type: %s (use text, secret or vault)", macroType))
+			}
+			value, err = readMacroValue(value)
+			handleError(err)
+
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			targets := map[string]map[string]interface{}{}
+			query := func(params map[string]interface{}) []map[string]interface{} {
+				params["output"] = []string{"hostid", "host"}
+				params["selectMacros"] = "extend"
+				list, err := callGetList(client, "host.get", params)
+				handleError(err)
+				for _, h := range list {
+					targets[fmt.Sprintf("%v", h["hostid"])] = h
+				}
+				return list
+			}
+			if len(hostgroups) > 0 {
+				groupIDs := getHostGroupsIDs(client, hostgroups)
+				if len(groupIDs) != len(hostgroups) {
+					handleError(fmt.Errorf("host group not found in: %v", hostgroups))
+				}
+				query(map[string]interface{}{"groupids": groupIDs})
+			}
+			if len(hosts) > 0 {
+				list := query(map[string]interface{}{"filter": map[string]interface{}{"host": hosts}})
+				var found, missing []string
+				for _, h := range list {
+					found = append(found, fmt.Sprintf("%v", h["host"]))
+				}
+				for _, name := range hosts {
+					if !containsString(found, name) {
+						missing = append(missing, name)
+					}
+				}
+				if len(missing) > 0 {
+					handleError(fmt.Errorf("host not found: %s", strings.Join(missing, ", ")))
+				}
+			}
+			if len(targets) == 0 {
+				handleError(fmt.Errorf("no hosts matched"))
+			}
+
+			ordered := make([]map[string]interface{}, 0, len(targets))
+			for _, h := range targets {
+				ordered = append(ordered, h)
+			}
+			sort.Slice(ordered, func(i, j int) bool {
+				return fmt.Sprintf("%v", ordered[i]["host"]) < fmt.Sprintf("%v", ordered[j]["host"])
+			})
+
+			typeChanged := cmd.Flags().Changed("type")
+			typeNames := map[string]string{}
+			for name, code := range macroTypes {
+				typeNames[code] = name
+			}
+
+			var creates, updates []map[string]interface{}
+			headers := []string{"Host", "Macro", "Before", "After", "Action"}
+			var rows [][]string
+			var changes []map[string]interface{}
+			for _, h := range ordered {
+				host := fmt.Sprintf("%v", h["host"])
+				var existing map[string]interface{}
+				for _, m := range exportList(h["macros"]) {
+					if macroKey(fmt.Sprintf("%v", m["macro"])) == macroKey(macro) {
+						existing = m
+						break
+					}
+				}
+
+				action := "create"
+				before := "-"
+				newType := typeCode
+				if existing != nil && !typeChanged {
+					newType = fmt.Sprintf("%v", existing["type"])
+				}
+				after := maskMacroValue(map[string]interface{}{"macro": macro, "value": value, "type": newType}, false)
+				if existing == nil {
+					params := map[string]interface{}{"hostid": h["hostid"], "macro": macro, "value": value, "type": typeCode}
+					if cmd.Flags().Changed("description") {
+						params["description"] = description
+					}
+					creates = append(creates, params)
+				} else {
+					before = maskMacroValue(existing, false)
+					// Secret values are never returned, so they are always rewritten.
+					oldType := fmt.Sprintf("%v", existing["type"])
+					unchanged := oldType == newType && newType != "1" &&
+						exportString(existing, "value") == value &&
+						(!cmd.Flags().Changed("description") || exportString(existing, "description") == description)
+					if unchanged {
+						action = "unchanged"
+					} else {
+						action = "update"
+						if oldType != newType {
+							action = fmt.Sprintf("update (type %s -> %s)", typeNames[oldType], typeNames[newType])
+						}
+						params := map[string]interface{}{"hostmacroid": existing["hostmacroid"], "value": value}
+						if typeChanged {
+							params["type"] = typeCode
+						}
+						if cmd.Flags().Changed("description") {
+							params["description"] = description
+						}
+						updates = append(updates, params)
+					}
+				}
+
+				rows = append(rows, []string{host, macro, before, after, action})
+				changes = append(changes, map[string]interface{}{"host": host, "macro": macro, "before": before, "after": after, "action": action})
+			}
+
+			if !dryRun {
+				if len(creates) > 0 {
+					_, err := client.Call("usermacro.create", creates)
+					handleError(err)
+				}
+				if len(updates) > 0 {
+					_, err := client.Call("usermacro.update", updates)
+					handleError(err)
+				}
+			}
+
+			outputResult(cmd, changes, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&hostgroups, "hostgroup", []string{}, "Host groups whose hosts get the macro")
+	cmd.Flags().StringSliceVarP(&hosts, "host", "H", []string{}, "Additional host names")
+	cmd.Flags().StringVar(&macroName, "macro", "", "Macro, e.g. {$CPU.UTIL.CRIT} or {$CPU.UTIL.CRIT:\"db\"}")
+	cmd.Flags().StringVar(&value, "value", "", "Macro value (\"-\" reads it from stdin)")
+	cmd.Flags().StringVar(&macroType, "type", "", "Macro type: text, secret or vault (default: text for new macros, unchanged for existing ones)")
+	cmd.Flags().StringVar(&description, "description", "", "Macro description")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only show what would change")
+	cmd.MarkFlagRequired("macro")
+	cmd.MarkFlagRequired("value")
+
+	return cmd
+}
@@ -0,0 +1,69 @@
+package commands
+
+import "testing"
+
+func TestParseMacro(t *testing.T) {
+	tests := []struct {
+		macro      string
+		name       string
+		context    string
+		hasContext bool
+		err        bool
+	}{
+		{macro: `{$PORT}`, name: "PORT"},
+		{macro: `{$NET.IF_MAX}`, name: "NET.IF_MAX"},
+		{macro: `{$PORT:eth0}`, name: "PORT", context: "eth0", hasContext: true},
+		{macro: `{$PORT:}`, name: "PORT", hasContext: true},
+		{macro: `{$PORT: eth0}`, name: "PORT", context: "eth0", hasContext: true},
+		{macro: `{$PORT:"eth0"}`, name: "PORT", context: "eth0", hasContext: true},
+		{macro: `{$PORT:"a:b}"}`, name: "PORT", context: "a:b}", hasContext: true},
+		{macro: `{$PORT:"say \"hi\""}`, name: "PORT", context: `say "hi"`, hasContext: true},
+		{macro: `{$PORT:"eth0" }`, name: "PORT", context: "eth0", hasContext: true},
+		{macro: `{$PORT:regex:^eth}`, name: "PORT", context: "regex:^eth", hasContext: true},
+		{macro: `{$PORT:regex:"^eth[0-9]"}`, name: "PORT", context: "regex:^eth[0-9]", hasContext: true},
+		{macro: `PORT`, err: true},
+		{macro: `{$}`, err: true},
+		{macro: `{$port}`, err: true},
+		{macro: `{$PORT-1}`, err: true},
+		{macro: `{$PORT:a"b}`, err: true},
+		{macro: `{$PORT:"eth0}`, err: true},
+		{macro: `{$PORT:"eth0"x}`, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.macro, func(t *testing.T) {
+			name, context, hasContext, err := parseMacro(tt.macro)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("parseMacro(%q) succeeded, want error", tt.macro)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseMacro(%q): %v", tt.macro, err)
+			}
+			if name != tt.name || context != tt.context || hasContext != tt.hasContext {
+				t.Errorf("parseMacro(%q) = %q, %q, %v, want %q, %q, %v", tt.macro, name, context, hasContext, tt.name, tt.context, tt.hasContext)
+			}
+		})
+	}
+}
+
+func TestMacroKey(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		equal bool
+	}{
+		{`{$PORT:"eth0"}`, `{$PORT:eth0}`, true},
+		{`{$PORT: eth0}`, `{$PORT:eth0}`, true},
+		{`{$PORT:regex:"^eth"}`, `{$PORT:regex:^eth}`, true},
+		{`{$PORT}`, `{$PORT}`, true},
+		{`{$PORT}`, `{$PORT:}`, false},
+		{`{$PORT:eth0}`, `{$PORT:eth1}`, false},
+		{`{$PORT:regex:eth0}`, `{$PORT:eth0}`, false},
+	}
+	for _, tt := range tests {
+		if got := macroKey(tt.a) == macroKey(tt.b); got != tt.equal {
+			t.Errorf("macroKey(%q) == macroKey(%q) is %v, want %v (%q, %q)", tt.a, tt.b, got, tt.equal, macroKey(tt.a), macroKey(tt.b))
+		}
+	}
+}
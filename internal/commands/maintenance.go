@@ -3,12 +3,37 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"zabbix-dna/internal/api"
+
 	"github.com/spf13/cobra"
 )
 
+// Maintenance time period types.
+const (
+	timeperiodOnce    = "0"
+	timeperiodDaily   = "2"
+	timeperiodWeekly  = "3"
+	timeperiodMonthly = "4"
+)
+
+var timeperiodScheduleNames = map[string]string{
+	timeperiodOnce:    "once",
+	timeperiodDaily:   "daily",
+	timeperiodWeekly:  "weekly",
+	timeperiodMonthly: "monthly",
+}
+
+var (
+	weekdayNames     = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+	monthNames       = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	weekOfMonthNames = []string{"first", "second", "third", "fourth", "last"}
+)
+
 func newMaintenanceCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "maintenance",
@@ -17,6 +42,12 @@ func newMaintenanceCmd() *cobra.Command {
 
 	cmd.AddCommand(newMaintenanceListCmd())
 	cmd.AddCommand(newMaintenanceCreateCmd())
+	cmd.AddCommand(newMaintenanceUpdateCmd())
+	cmd.AddCommand(newMaintenanceExtendCmd())
+	cmd.AddCommand(newMaintenanceEndNowCmd())
+	cmd.AddCommand(newMaintenanceRunCmd())
+	cmd.AddCommand(newMaintenanceCalendarCmd())
+	cmd.AddCommand(newMaintenanceCheckCmd())
 	cmd.AddCommand(newMaintenanceDeleteCmd())
 	cmd.AddCommand(newMaintenanceRemoveCmd())
 
@@ -56,8 +87,9 @@ func newMaintenanceListCmd() *cobra.Command {
 			handleError(err)
 
 			params := map[string]interface{}{
-				"output": []string{"maintenanceid", "name", "maintenance_type", "active_since", "active_till"},
-				"limit":  limit,
+				"output":            []string{"maintenanceid", "name", "maintenance_type", "active_since", "active_till"},
+				"selectTimeperiods": "extend",
+				"limit":             limit,
 			}
 
 			result, err := client.Call("maintenance.get", params)
@@ -66,7 +98,7 @@ func newMaintenanceListCmd() *cobra.Command {
 			var periods []map[string]interface{}
 			json.Unmarshal(result, &periods)
 
-			headers := []string{"MaintenanceID", "Name", "Type", "Since", "Till"}
+			headers := []string{"MaintenanceID", "Name", "Type", "Since", "Till", "Schedule"}
 			var rows [][]string
 			for _, p := range periods {
 				mType := "With data"
@@ -83,6 +115,7 @@ func newMaintenanceListCmd() *cobra.Command {
 					mType,
 					time.Unix(sinceSec, 0).Format("2006-01-02 15:04:05"),
 					time.Unix(tillSec, 0).Format("2006-01-02 15:04:05"),
+					describeTimeperiods(p["timeperiods"]),
 				})
 			}
 
@@ -95,9 +128,361 @@ func newMaintenanceListCmd() *cobra.Command {
 	return cmd
 }
 
+// maintenanceOptions holds the flags shared by maintenance create and update.
+type maintenanceOptions struct {
+	hosts       []string
+	hostgroups  []string
+	description string
+	noData      bool
+	tags        []string
+	tagMatch    string
+	schedule    maintenanceSchedule
+}
+
+func (o *maintenanceOptions) addFlags(cmd *cobra.Command) {
+	o.addTargetFlags(cmd)
+	o.schedule.addFlags(cmd)
+}
+
+// addTargetFlags adds the flags selecting what is in maintenance and how.
+func (o *maintenanceOptions) addTargetFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&o.hosts, "host", []string{}, "Host names (comma-separated)")
+	cmd.Flags().StringSliceVar(&o.hostgroups, "hostgroup", []string{}, "Host group names (comma-separated)")
+	cmd.Flags().StringVar(&o.description, "description", "", "Maintenance description")
+	cmd.Flags().BoolVar(&o.noData, "no-data", false, "Do not collect data during the maintenance")
+	cmd.Flags().StringArrayVar(&o.tags, "tag", []string{}, "Only suppress problems with this tag: name=value (equals), name~value (contains) or name (repeatable)")
+	cmd.Flags().StringVar(&o.tagMatch, "tag-match", "and", "How problem tags are combined: and, or")
+}
+
+// apply adds the changed options to maintenance.create/update params.
+func (o *maintenanceOptions) apply(cmd *cobra.Command, client *api.ZabbixClient, params map[string]interface{}) error {
+	flags := cmd.Flags()
+
+	if flags.Changed("host") {
+		ids := getHostsIDs(client, o.hosts)
+		if len(ids) != len(o.hosts) {
+			return fmt.Errorf("host(s) not found: %s", strings.Join(o.hosts, ", "))
+		}
+		var hosts []map[string]string
+		for _, id := range ids {
+			hosts = append(hosts, map[string]string{"hostid": id})
+		}
+		params["hosts"] = hosts
+	}
+	if flags.Changed("hostgroup") {
+		ids := getHostGroupsIDs(client, o.hostgroups)
+		if len(ids) != len(o.hostgroups) {
+			return fmt.Errorf("host group(s) not found: %s", strings.Join(o.hostgroups, ", "))
+		}
+		var groups []map[string]string
+		for _, id := range ids {
+			groups = append(groups, map[string]string{"groupid": id})
+		}
+		params["groups"] = groups
+	}
+	if flags.Changed("description") {
+		params["description"] = o.description
+	}
+	if flags.Changed("no-data") {
+		params["maintenance_type"] = 0
+		if o.noData {
+			params["maintenance_type"] = 1
+			if !flags.Changed("tag") {
+				params["tags"] = []interface{}{}
+			}
+		}
+	}
+	if flags.Changed("tag") {
+		if o.noData && len(o.tags) > 0 {
+			return fmt.Errorf("--tag cannot be used with --no-data maintenance")
+		}
+		tags, err := parseMaintenanceTags(o.tags)
+		if err != nil {
+			return err
+		}
+		params["tags"] = tags
+	}
+	if flags.Changed("tag-match") {
+		switch o.tagMatch {
+		case "and":
+			params["tags_evaltype"] = 0
+		case "or":
+			params["tags_evaltype"] = 2
+		default:
+			return fmt.Errorf("invalid --tag-match: %s (use and or or)", o.tagMatch)
+		}
+	}
+	return nil
+}
+
+// maintenanceSchedule holds the flags describing when a maintenance is active.
+type maintenanceSchedule struct {
+	start       string
+	duration    string
+	until       string
+	tz          string
+	schedule    string
+	every       int
+	weekdays    []string
+	day         int
+	week        string
+	months      []string
+	durationSet bool
+	// Weekdays and month days seeded from an existing time period are already in server time.
+	seededWeekdays bool
+	seededDay      bool
+}
+
+var maintenanceScheduleFlags = []string{"start", "duration", "until", "tz", "schedule", "every", "weekdays", "day", "week", "months", "server-tz"}
+
+func (s *maintenanceSchedule) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&s.start, "start", "now", "Start of the maintenance (now, +1h, \"2026-11-01 22:00\")")
+	cmd.Flags().StringVar(&s.duration, "duration", "1h", "Length of each maintenance window (e.g. 30m, 2h, 1d)")
+	cmd.Flags().StringVar(&s.until, "until", "", "End of the maintenance (once: default start+duration; recurring: default one year)")
+	cmd.Flags().StringVar(&s.tz, "tz", "", "Time zone of --start and --until, e.g. America/Sao_Paulo (default local)")
+	cmd.Flags().StringVar(&s.schedule, "schedule", "once", "Schedule: once, daily, weekly or monthly")
+	cmd.Flags().IntVar(&s.every, "every", 1, "Repeat every N days (daily) or N weeks (weekly)")
+	cmd.Flags().StringSliceVar(&s.weekdays, "weekdays", []string{}, "Weekdays for weekly and monthly schedules (mon,tue,...)")
+	cmd.Flags().IntVar(&s.day, "day", 0, "Day of month for monthly schedules")
+	cmd.Flags().StringVar(&s.week, "week", "", "Week of month for monthly schedules with --weekdays (first, second, third, fourth, last)")
+	cmd.Flags().StringSliceVar(&s.months, "months", []string{}, "Months for monthly schedules (jan,feb,...; default all)")
+	addServerTZFlag(cmd)
+}
+
+func (s *maintenanceSchedule) changed(cmd *cobra.Command) bool {
+	for _, name := range maintenanceScheduleFlags {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// seed fills the schedule flags that were not given from an existing maintenance, so update
+// can change a single aspect of the schedule.
+func (s *maintenanceSchedule) seed(cmd *cobra.Command, m map[string]interface{}, serverLoc *time.Location) error {
+	flags := cmd.Flags()
+	periods := exportList(m["timeperiods"])
+	if len(periods) != 1 {
+		if !flags.Changed("schedule") || !flags.Changed("start") {
+			return fmt.Errorf("maintenance has %d time periods; give the full schedule with --schedule and --start", len(periods))
+		}
+		return nil
+	}
+	tp := periods[0]
+	tpType := fmt.Sprintf("%v", tp["timeperiod_type"])
+	field := func(name string) int {
+		n, _ := strconv.Atoi(fmt.Sprintf("%v", tp[name]))
+		return n
+	}
+
+	if !flags.Changed("tz") {
+		s.tz = serverLoc.String()
+	}
+	if !flags.Changed("schedule") {
+		s.schedule = timeperiodScheduleNames[tpType]
+	}
+	if !flags.Changed("duration") {
+		s.duration = fmt.Sprintf("%ds", field("period"))
+		s.durationSet = true
+	}
+	if !flags.Changed("until") && tpType != timeperiodOnce {
+		s.until = fmt.Sprintf("%v", m["active_till"])
+	}
+	if !flags.Changed("start") {
+		if tpType == timeperiodOnce {
+			s.start = fmt.Sprintf("%v", tp["start_date"])
+		} else {
+			since := time.Unix(parseClock(m["active_since"]), 0).In(serverLoc)
+			y, mo, d := since.Date()
+			start := time.Date(y, mo, d, 0, 0, 0, 0, serverLoc).Add(time.Duration(field("start_time")) * time.Second)
+			s.start = strconv.FormatInt(start.Unix(), 10)
+		}
+	}
+
+	if s.schedule != timeperiodScheduleNames[tpType] {
+		return nil
+	}
+	if !flags.Changed("every") && (tpType == timeperiodDaily || tpType == timeperiodWeekly) {
+		s.every = field("every")
+	}
+	if !flags.Changed("weekdays") {
+		s.weekdays = maskNames(field("dayofweek"), weekdayNames)
+		s.seededWeekdays = true
+	}
+	if tpType == timeperiodMonthly {
+		if !flags.Changed("day") && !flags.Changed("week") {
+			s.day = field("day")
+			if s.day == 0 && field("every") >= 1 && field("every") <= 5 {
+				s.week = weekOfMonthNames[field("every")-1]
+			}
+			s.seededDay = true
+		}
+		if !flags.Changed("months") {
+			s.months = maskNames(field("month"), monthNames)
+		}
+	}
+	return nil
+}
+
+// build returns active_since, active_till and the time periods for the schedule. Recurring
+// windows are evaluated by the Zabbix server in its own time zone, so their time of day is
+// converted to serverLoc.
+func (s *maintenanceSchedule) build(cmd *cobra.Command, serverLoc *time.Location) (int64, int64, []map[string]interface{}, error) {
+	loc := time.Local
+	if s.tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(s.tz); err != nil {
+			return 0, 0, nil, fmt.Errorf("invalid time zone: %s", s.tz)
+		}
+	}
+	now := time.Now().In(loc)
+
+	start, err := parseTimeSpec(s.start, now)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	start = start.Truncate(time.Minute)
+	duration, err := parseDurationSpec(s.duration)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	durationSet := s.durationSet || cmd.Flags().Changed("duration")
+
+	var until time.Time
+	if s.until != "" {
+		if until, err = parseTimeSpec(s.until, now); err != nil {
+			return 0, 0, nil, err
+		}
+		if !until.After(start) {
+			return 0, 0, nil, fmt.Errorf("--until must be after --start")
+		}
+	}
+
+	if s.schedule != "monthly" && (len(s.months) > 0 || s.day != 0 || s.week != "") {
+		return 0, 0, nil, fmt.Errorf("--day, --week and --months only apply to monthly schedules")
+	}
+	if s.schedule != "weekly" && s.schedule != "monthly" && len(s.weekdays) > 0 {
+		return 0, 0, nil, fmt.Errorf("--weekdays only applies to weekly and monthly schedules")
+	}
+
+	if s.schedule == "once" {
+		if until.IsZero() {
+			until = start.Add(duration)
+		} else if !durationSet {
+			duration = until.Sub(start)
+		}
+		if duration < 5*time.Minute {
+			return 0, 0, nil, fmt.Errorf("maintenance windows must be at least 5 minutes long")
+		}
+		return start.Unix(), until.Unix(), []map[string]interface{}{{
+			"timeperiod_type": 0,
+			"start_date":      start.Unix(),
+			"period":          int64(duration.Seconds()),
+		}}, nil
+	}
+
+	if duration < 5*time.Minute {
+		return 0, 0, nil, fmt.Errorf("maintenance windows must be at least 5 minutes long")
+	}
+	if duration > 24*time.Hour*31 {
+		return 0, 0, nil, fmt.Errorf("recurring maintenance windows cannot be longer than 31 days")
+	}
+	if until.IsZero() {
+		until = start.AddDate(1, 0, 0)
+	}
+	if s.every < 1 {
+		return 0, 0, nil, fmt.Errorf("--every must be at least 1")
+	}
+
+	// The day can move when the time of day is converted to the server time zone.
+	server := start.In(serverLoc)
+	shift := dayNumber(server) - dayNumber(start)
+	tp := map[string]interface{}{
+		"start_time": server.Hour()*3600 + server.Minute()*60,
+		"period":     int64(duration.Seconds()),
+	}
+
+	switch s.schedule {
+	case "daily":
+		tp["timeperiod_type"] = 2
+		tp["every"] = s.every
+
+	case "weekly":
+		if len(s.weekdays) == 0 {
+			return 0, 0, nil, fmt.Errorf("weekly schedules need --weekdays")
+		}
+		weekdayShift := shift
+		if s.seededWeekdays {
+			weekdayShift = 0
+		}
+		mask, err := weekdayMask(s.weekdays, weekdayShift)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		tp["timeperiod_type"] = 3
+		tp["every"] = s.every
+		tp["dayofweek"] = mask
+
+	case "monthly":
+		tp["timeperiod_type"] = 4
+		tp["month"] = 4095
+		if len(s.months) > 0 {
+			mask, err := nameMask(s.months, monthNames, "month")
+			if err != nil {
+				return 0, 0, nil, err
+			}
+			tp["month"] = mask
+		}
+		switch {
+		case s.day != 0 && s.week != "":
+			return 0, 0, nil, fmt.Errorf("use either --day or --week, not both")
+		case s.day != 0:
+			if s.day < 1 || s.day > 31 {
+				return 0, 0, nil, fmt.Errorf("--day must be between 1 and 31")
+			}
+			if s.seededDay {
+				shift = 0
+			}
+			if shift != 0 && (s.day+shift < 1 || s.day+shift > 28) {
+				return 0, 0, nil, fmt.Errorf("day %d at this time falls on another month day in the server time zone (%s); pick a start time on the same day", s.day, serverLoc)
+			}
+			tp["day"] = s.day + shift
+		case s.week != "":
+			if len(s.weekdays) == 0 {
+				return 0, 0, nil, fmt.Errorf("--week needs --weekdays")
+			}
+			if shift != 0 && !(s.seededDay && s.seededWeekdays) {
+				return 0, 0, nil, fmt.Errorf("this start time falls on another day in the server time zone (%s); week-of-month schedules cannot be converted, pick a start time on the same day", serverLoc)
+			}
+			week := 0
+			for i, name := range weekOfMonthNames {
+				if strings.EqualFold(s.week, name) {
+					week = i + 1
+				}
+			}
+			if week == 0 {
+				return 0, 0, nil, fmt.Errorf("invalid --week: %s (use first, second, third, fourth or last)", s.week)
+			}
+			mask, err := weekdayMask(s.weekdays, 0)
+			if err != nil {
+				return 0, 0, nil, err
+			}
+			tp["every"] = week
+			tp["dayofweek"] = mask
+		default:
+			return 0, 0, nil, fmt.Errorf("monthly schedules need --day or --week with --weekdays")
+		}
+
+	default:
+		return 0, 0, nil, fmt.Errorf("invalid --schedule: %s (use once, daily, weekly or monthly)", s.schedule)
+	}
+
+	return start.Unix(), until.Unix(), []map[string]interface{}{tp}, nil
+}
+
 func newMaintenanceCreateCmd() *cobra.Command {
-	var hosts []string
-	var hostgroups []string
+	var opts maintenanceOptions
 	var activeSince int64
 	var activeTill int64
 	var period int
@@ -105,40 +490,56 @@ func newMaintenanceCreateCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "create [maintenance name]",
 		Short: "Create a new Zabbix maintenance period",
-		Args:  cobra.ExactArgs(1),
+		Long: `Create a maintenance period, once or on a daily, weekly or monthly schedule.
+
+Times are read in the local time zone unless --tz is given. Recurring windows are run by the
+Zabbix server in the time zone of its host, so their time of day is converted before saving.
+Give that zone with --server-tz; otherwise the frontend default time zone is assumed.
+
+With --tag only problems carrying matching tags are suppressed; tags cannot be combined with
+--no-data.`,
+		Example: `  zabbix-dna maintenance create "DB patching" --hostgroup Databases \
+    --start "2026-11-01 22:00" --duration 2h --tz America/Sao_Paulo --tag service=db
+  zabbix-dna maintenance create "Nightly backup" --host db01 --schedule daily \
+    --start "2026-11-01 01:00" --duration 30m
+  zabbix-dna maintenance create "Patch Tuesday" --hostgroup Windows --schedule monthly \
+    --week second --weekdays tue --start "2026-11-01 23:00" --duration 3h --no-data`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			client, err := getZabbixClient(cmd)
 			handleError(err)
 
-			if activeSince == 0 {
-				activeSince = time.Now().Unix()
-			}
-			if activeTill == 0 {
-				if period == 0 {
-					period = 3600 // 1 hour default
-				}
-				activeTill = activeSince + int64(period)
+			if len(opts.hosts) == 0 && len(opts.hostgroups) == 0 {
+				handleError(fmt.Errorf("specify at least one --host or --hostgroup"))
 			}
 
-			params := map[string]interface{}{
-				"name":         args[0],
-				"active_since": activeSince,
-				"active_till":  activeTill,
-				"timeperiods": []map[string]interface{}{
-					{
-						"timeperiod_type": 0, // One time only
-						"start_date":      activeSince,
-						"period":          activeTill - activeSince,
-					},
-				},
+			// Deprecated Unix timestamp flags
+			if cmd.Flags().Changed("since") {
+				opts.schedule.start = strconv.FormatInt(activeSince, 10)
+			}
+			if cmd.Flags().Changed("till") {
+				opts.schedule.until = strconv.FormatInt(activeTill, 10)
+			}
+			if cmd.Flags().Changed("period") {
+				opts.schedule.duration = fmt.Sprintf("%ds", period)
+				opts.schedule.durationSet = true
 			}
 
-			if len(hosts) > 0 {
-				params["hostids"] = getHostsIDs(client, hosts)
+			serverLoc := time.Local
+			if opts.schedule.schedule != "once" {
+				serverLoc, err = maintenanceServerLocation(cmd, client)
+				handleError(err)
 			}
-			if len(hostgroups) > 0 {
-				params["groupids"] = getHostGroupsIDs(client, hostgroups)
+			since, till, timeperiods, err := opts.schedule.build(cmd, serverLoc)
+			handleError(err)
+
+			params := map[string]interface{}{
+				"name":         args[0],
+				"active_since": since,
+				"active_till":  till,
+				"timeperiods":  timeperiods,
 			}
+			handleError(opts.apply(cmd, client, params))
 
 			result, err := client.Call("maintenance.create", params)
 			handleError(err)
@@ -147,19 +548,182 @@ func newMaintenanceCreateCmd() *cobra.Command {
 			json.Unmarshal(result, &resp)
 			maintenanceIDs := resp["maintenanceids"].([]interface{})
 
-			outputResult(cmd, fmt.Sprintf("Created maintenance definition (%v).", maintenanceIDs[0]), nil, nil)
+			outputResult(cmd, fmt.Sprintf("Created maintenance definition (%v): %s.", maintenanceIDs[0], describeTimeperiods(timeperiods)), nil, nil)
 		},
 	}
 
-	cmd.Flags().StringSliceVar(&hosts, "host", []string{}, "Host names (comma-separated)")
-	cmd.Flags().StringSliceVar(&hostgroups, "hostgroup", []string{}, "Host group names (comma-separated)")
+	opts.addFlags(cmd)
 	cmd.Flags().Int64Var(&activeSince, "since", 0, "Active since (Unix timestamp, defaults to now)")
 	cmd.Flags().Int64Var(&activeTill, "till", 0, "Active till (Unix timestamp)")
 	cmd.Flags().IntVar(&period, "period", 3600, "Period in seconds (default 1 hour if till is not set)")
+	cmd.Flags().MarkDeprecated("since", "use --start")
+	cmd.Flags().MarkDeprecated("till", "use --until")
+	cmd.Flags().MarkDeprecated("period", "use --duration")
 
 	return cmd
 }
 
+func newMaintenanceUpdateCmd() *cobra.Command {
+	var opts maintenanceOptions
+	var name string
+
+	cmd := &cobra.Command{
+		Use:   "update [maintenance id or name]",
+		Short: "Update a maintenance period",
+		Long: `Update a maintenance period. Only the given options change; --host, --hostgroup and
+--tag replace the current lists. Schedule options not given keep their current values.`,
+		Example: `  zabbix-dna maintenance update "DB patching" --duration 3h
+  zabbix-dna maintenance update 42 --schedule weekly --weekdays sat,sun --start "2026-11-07 02:00"`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			m, err := getMaintenance(client, args[0])
+			handleError(err)
+
+			params := map[string]interface{}{"maintenanceid": m["maintenanceid"]}
+			if cmd.Flags().Changed("name") {
+				params["name"] = name
+			}
+			if cmd.Flags().Changed("tag") && !cmd.Flags().Changed("no-data") {
+				opts.noData = fmt.Sprintf("%v", m["maintenance_type"]) == "1"
+			}
+			handleError(opts.apply(cmd, client, params))
+
+			if opts.schedule.changed(cmd) {
+				serverLoc, err := maintenanceServerLocation(cmd, client)
+				handleError(err)
+				handleError(opts.schedule.seed(cmd, m, serverLoc))
+				since, till, timeperiods, err := opts.schedule.build(cmd, serverLoc)
+				handleError(err)
+				params["active_since"] = since
+				params["active_till"] = till
+				params["timeperiods"] = timeperiods
+			}
+
+			if len(params) == 1 {
+				handleError(fmt.Errorf("nothing to update"))
+			}
+
+			_, err = client.Call("maintenance.update", params)
+			handleError(err)
+
+			outputResult(cmd, fmt.Sprintf("Updated maintenance %v (%v).", m["name"], m["maintenanceid"]), nil, nil)
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "New maintenance name")
+	opts.addFlags(cmd)
+
+	return cmd
+}
+
+func newMaintenanceExtendCmd() *cobra.Command {
+	var by string
+	var until string
+
+	cmd := &cobra.Command{
+		Use:   "extend [maintenance id or name]",
+		Short: "Extend a maintenance period",
+		Long: `Extend a maintenance period by a duration or to a given time. The one-time window that is
+running (or the last one) is lengthened along with the active period.`,
+		Example: `  zabbix-dna maintenance extend "DB patching" --by 30m
+  zabbix-dna maintenance extend 42 --until "2026-11-02 02:00"`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			m, err := getMaintenance(client, args[0])
+			handleError(err)
+
+			now := time.Now()
+			till := parseClock(m["active_till"])
+			var newTill int64
+			if until != "" {
+				t, err := parseTimeSpec(until, now)
+				handleError(err)
+				newTill = t.Unix()
+			} else {
+				d, err := parseDurationSpec(by)
+				handleError(err)
+				newTill = till + int64(d.Seconds())
+			}
+			if newTill <= till {
+				handleError(fmt.Errorf("maintenance already runs until %s", time.Unix(till, 0).Format("2006-01-02 15:04")))
+			}
+
+			// Lengthen the running one-time window, or the last one.
+			timeperiods := cleanTimeperiods(m["timeperiods"])
+			target := -1
+			for i, tp := range timeperiods {
+				if fmt.Sprintf("%v", tp["timeperiod_type"]) != timeperiodOnce {
+					continue
+				}
+				start := parseClock(tp["start_date"])
+				if target < 0 || start <= now.Unix() {
+					target = i
+				}
+			}
+			params := map[string]interface{}{
+				"maintenanceid": m["maintenanceid"],
+				"active_till":   newTill,
+			}
+			if target >= 0 {
+				tp := timeperiods[target]
+				start := parseClock(tp["start_date"])
+				if end := start + parseClock(tp["period"]); end < newTill {
+					tp["period"] = newTill - start
+					params["timeperiods"] = timeperiods
+				}
+			}
+			_, err = client.Call("maintenance.update", params)
+			handleError(err)
+
+			outputResult(cmd, fmt.Sprintf("Maintenance %v now ends at %s.", m["name"], time.Unix(newTill, 0).Format("2006-01-02 15:04")), nil, nil)
+		},
+	}
+
+	cmd.Flags().StringVar(&by, "by", "1h", "Extend by this duration")
+	cmd.Flags().StringVar(&until, "until", "", "Extend until this time instead")
+
+	return cmd
+}
+
+func newMaintenanceEndNowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "end-now [maintenance id or name]",
+		Short: "End a running maintenance period now",
+		Long: `End a running maintenance period by moving its active till to now. The Zabbix server
+picks up the change within a minute.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			m, err := getMaintenance(client, args[0])
+			handleError(err)
+
+			now := time.Now().Unix()
+			if parseClock(m["active_since"]) >= now {
+				handleError(fmt.Errorf("maintenance %v has not started yet; delete it instead", m["name"]))
+			}
+			if parseClock(m["active_till"]) <= now {
+				handleError(fmt.Errorf("maintenance %v has already ended", m["name"]))
+			}
+
+			_, err = client.Call("maintenance.update", map[string]interface{}{
+				"maintenanceid": m["maintenanceid"],
+				"active_till":   now,
+			})
+			handleError(err)
+
+			outputResult(cmd, fmt.Sprintf("Maintenance %v ended.", m["name"]), nil, nil)
+		},
+	}
+}
+
 func newMaintenanceDeleteCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "delete [maintenance name]",
@@ -202,3 +766,208 @@ func newMaintenanceDeleteCmd() *cobra.Command {
 		},
 	}
 }
+
+// getMaintenance finds a maintenance by ID or name, with its time periods and tags.
+func getMaintenance(client *api.ZabbixClient, ref string) (map[string]interface{}, error) {
+	params := map[string]interface{}{
+		"output":            "extend",
+		"selectTimeperiods": "extend",
+		"selectTags":        "extend",
+	}
+	if _, err := strconv.Atoi(ref); err == nil {
+		params["maintenanceids"] = []string{ref}
+		list, err := callGetList(client, "maintenance.get", params)
+		if err != nil {
+			return nil, err
+		}
+		if len(list) > 0 {
+			return list[0], nil
+		}
+		delete(params, "maintenanceids")
+	}
+
+	params["filter"] = map[string]interface{}{"name": ref}
+	list, err := callGetList(client, "maintenance.get", params)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("maintenance period not found: %s", ref)
+	}
+	return list[0], nil
+}
+
+// addServerTZFlag adds --server-tz to commands that evaluate recurring maintenance windows.
+func addServerTZFlag(cmd *cobra.Command) {
+	cmd.Flags().String("server-tz", "", "Time zone of the Zabbix server host, e.g. Europe/Berlin (default: frontend default time zone)")
+}
+
+// maintenanceServerLocation returns the time zone recurring maintenance is evaluated in. The
+// server uses the time zone of its own host, which the API does not expose, so without
+// --server-tz the frontend default time zone (or the local one) is assumed and a warning says so.
+func maintenanceServerLocation(cmd *cobra.Command, client *api.ZabbixClient) (*time.Location, error) {
+	if name, _ := cmd.Flags().GetString("server-tz"); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server time zone: %s", name)
+		}
+		return loc, nil
+	}
+
+	loc, source := time.Local, "the local time zone"
+	if result, err := client.Call("settings.get", map[string]interface{}{"output": []string{"default_timezone"}}); err == nil {
+		var settings map[string]interface{}
+		if json.Unmarshal(result, &settings) == nil {
+			name := fmt.Sprintf("%v", settings["default_timezone"])
+			if name != "" && name != "system" {
+				if l, err := time.LoadLocation(name); err == nil {
+					loc, source = l, l.String()+", the frontend default time zone"
+				}
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Warning: assuming the Zabbix server evaluates maintenance in %s; use --server-tz if its host runs in another zone\n", source)
+	return loc, nil
+}
+
+// parseMaintenanceTags parses --tag flags: name=value (equals), name~value (contains) or name.
+func parseMaintenanceTags(flags []string) ([]map[string]interface{}, error) {
+	var tags []map[string]interface{}
+	for _, f := range flags {
+		operator := 2
+		name, value := f, ""
+		if i := strings.IndexAny(f, "=~"); i >= 0 {
+			name, value = f[:i], f[i+1:]
+			if f[i] == '=' {
+				operator = 0
+			}
+		}
+		if name == "" {
+			return nil, fmt.Errorf("invalid tag: %s (use name=value, name~value or name)", f)
+		}
+		tags = append(tags, map[string]interface{}{"tag": name, "operator": operator, "value": value})
+	}
+	return tags, nil
+}
+
+// cleanTimeperiods copies time periods returned by maintenance.get so they can be sent back.
+func cleanTimeperiods(v interface{}) []map[string]interface{} {
+	var out []map[string]interface{}
+	for _, tp := range exportList(v) {
+		clean := map[string]interface{}{}
+		for k, val := range tp {
+			if k != "timeperiodid" {
+				clean[k] = val
+			}
+		}
+		out = append(out, clean)
+	}
+	return out
+}
+
+// describeTimeperiods renders maintenance time periods, e.g. "weekly on mon,wed at 22:00 for 2h".
+func describeTimeperiods(v interface{}) string {
+	var list []map[string]interface{}
+	switch periods := v.(type) {
+	case []map[string]interface{}:
+		list = periods
+	default:
+		list = exportList(v)
+	}
+
+	var parts []string
+	for _, tp := range list {
+		n := func(name string) int {
+			i, _ := strconv.Atoi(fmt.Sprintf("%v", tp[name]))
+			return i
+		}
+		period := formatDuration(time.Duration(n("period")) * time.Second)
+		at := fmt.Sprintf("%02d:%02d", n("start_time")/3600, n("start_time")%3600/60)
+
+		switch fmt.Sprintf("%v", tp["timeperiod_type"]) {
+		case timeperiodOnce:
+			start := time.Unix(parseClock(tp["start_date"]), 0).Format("2006-01-02 15:04")
+			parts = append(parts, fmt.Sprintf("once at %s for %s", start, period))
+		case timeperiodDaily:
+			every := "every day"
+			if n("every") > 1 {
+				every = fmt.Sprintf("every %d days", n("every"))
+			}
+			parts = append(parts, fmt.Sprintf("%s at %s for %s", every, at, period))
+		case timeperiodWeekly:
+			every := "weekly"
+			if n("every") > 1 {
+				every = fmt.Sprintf("every %d weeks", n("every"))
+			}
+			days := strings.Join(maskNames(n("dayofweek"), weekdayNames), ",")
+			parts = append(parts, fmt.Sprintf("%s on %s at %s for %s", every, days, at, period))
+		case timeperiodMonthly:
+			on := fmt.Sprintf("day %d", n("day"))
+			if n("day") == 0 && n("every") >= 1 && n("every") <= 5 {
+				on = fmt.Sprintf("the %s %s", weekOfMonthNames[n("every")-1], strings.Join(maskNames(n("dayofweek"), weekdayNames), ","))
+			}
+			months := ""
+			if n("month") != 4095 {
+				months = " in " + strings.Join(maskNames(n("month"), monthNames), ",")
+			}
+			parts = append(parts, fmt.Sprintf("monthly on %s%s at %s for %s", on, months, at, period))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// weekdayMask converts weekday names to the Zabbix bitmask (Monday = 1), moving each day by
+// shift days.
+func weekdayMask(days []string, shift int) (int, error) {
+	mask := 0
+	for _, d := range days {
+		i := indexOfName(d, weekdayNames)
+		if i < 0 {
+			return 0, fmt.Errorf("invalid weekday: %s (use mon, tue, wed, thu, fri, sat, sun)", d)
+		}
+		mask |= 1 << ((i + shift + 7) % 7)
+	}
+	return mask, nil
+}
+
+func nameMask(values, names []string, kind string) (int, error) {
+	mask := 0
+	for _, v := range values {
+		i := indexOfName(v, names)
+		if i < 0 {
+			return 0, fmt.Errorf("invalid %s: %s (use %s)", kind, v, strings.Join(names, ", "))
+		}
+		mask |= 1 << i
+	}
+	return mask, nil
+}
+
+func maskNames(mask int, names []string) []string {
+	var out []string
+	for i, name := range names {
+		if mask&(1<<i) != 0 {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// indexOfName matches a name case-insensitively, also by its first three letters ("monday").
+func indexOfName(v string, names []string) int {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if len(v) > 3 {
+		v = v[:3]
+	}
+	for i, name := range names {
+		if v == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// dayNumber returns a day count for t in its own location, for comparing calendar days.
+func dayNumber(t time.Time) int {
+	y, m, d := t.Date()
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
@@ -0,0 +1,525 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"zabbix-dna/internal/api"
+
+	"github.com/spf13/cobra"
+)
+
+// maintenanceOccurrence is one concrete window of a maintenance period.
+type maintenanceOccurrence struct {
+	MaintenanceID string    `json:"maintenanceid"`
+	Name          string    `json:"name"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	NoData        bool      `json:"no_data"`
+}
+
+// maintenanceFinding is one problem found by maintenance check.
+type maintenanceFinding struct {
+	Check          string   `json:"check"`
+	MaintenanceIDs []string `json:"maintenanceids"`
+	Maintenance    string   `json:"maintenance"`
+	Detail         string   `json:"detail"`
+	Hosts          []string `json:"hosts,omitempty"`
+}
+
+// Gantt cells per day, each covering 24h / calendarSlots.
+const calendarSlots = 4
+
+func newMaintenanceCalendarCmd() *cobra.Command {
+	var from string
+	var days int
+	var hostGroupNames []string
+	var tz string
+	var list bool
+
+	cmd := &cobra.Command{
+		Use:   "calendar",
+		Short: "Show maintenance windows on a calendar",
+		Long: `Expand every maintenance period into its concrete windows and show them as a Gantt chart
+per host group, one cell per 6 hours. With --list, or an output format other than table, the
+windows are listed instead.`,
+		Example: `  zabbix-dna maintenance calendar
+  zabbix-dna maintenance calendar --from 2026-11-01 --days 30 --hostgroup Databases
+  zabbix-dna maintenance calendar --list --tz America/Sao_Paulo`,
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			loc := time.Local
+			if tz != "" {
+				loc, err = time.LoadLocation(tz)
+				if err != nil {
+					handleError(fmt.Errorf("invalid time zone: %s", tz))
+				}
+			}
+			start, err := parseTimeSpec(from, time.Now().In(loc))
+			handleError(err)
+			y, m, d := start.Date()
+			start = time.Date(y, m, d, 0, 0, 0, 0, loc)
+			end := start.AddDate(0, 0, days)
+
+			params := map[string]interface{}{}
+			if len(hostGroupNames) > 0 {
+				ids := getHostGroupsIDs(client, hostGroupNames)
+				if len(ids) == 0 {
+					handleError(fmt.Errorf("no host groups found: %s", strings.Join(hostGroupNames, ", ")))
+				}
+				params["groupids"] = ids
+			}
+			maintenances, groupsKey, err := getMaintenancesWithTargets(client, params)
+			handleError(err)
+			serverLoc, err := maintenanceServerLocation(cmd, client)
+			handleError(err)
+
+			// Section per host group; maintenances on hosts only go to a section of their own.
+			sections := map[string][]map[string]interface{}{}
+			occurrences := map[string][]maintenanceOccurrence{}
+			var all []maintenanceOccurrence
+			for _, mt := range maintenances {
+				id := fmt.Sprintf("%v", mt["maintenanceid"])
+				occ := expandMaintenance(mt, start, end, serverLoc)
+				if len(occ) == 0 {
+					continue
+				}
+				occurrences[id] = occ
+				all = append(all, occ...)
+
+				groups := exportList(mt[groupsKey])
+				for _, g := range groups {
+					name := fmt.Sprintf("%v", g["name"])
+					if len(hostGroupNames) == 0 || containsString(hostGroupNames, name) {
+						sections[name] = append(sections[name], mt)
+					}
+				}
+				if len(groups) == 0 {
+					sections["(individual hosts)"] = append(sections["(individual hosts)"], mt)
+				}
+			}
+			sort.Slice(all, func(i, j int) bool { return all[i].Start.Before(all[j].Start) })
+
+			if getOutputFormat(cmd) == "json" {
+				if all == nil {
+					all = []maintenanceOccurrence{}
+				}
+				outputResult(cmd, all, nil, nil)
+				return
+			}
+			if len(all) == 0 {
+				outputResult(cmd, fmt.Sprintf("No maintenance windows between %s and %s.", start.Format("2006-01-02"), end.Format("2006-01-02")), nil, nil)
+				return
+			}
+
+			if list || getOutputFormat(cmd) != "table" {
+				headers := []string{"MaintenanceID", "Name", "Type", "Start", "End", "Duration"}
+				var rows [][]string
+				for _, o := range all {
+					mType := "With data"
+					if o.NoData {
+						mType = "No data"
+					}
+					rows = append(rows, []string{
+						o.MaintenanceID, o.Name, mType,
+						o.Start.In(loc).Format("2006-01-02 15:04"),
+						o.End.In(loc).Format("2006-01-02 15:04"),
+						formatDuration(o.End.Sub(o.Start)),
+					})
+				}
+				outputResult(cmd, all, headers, rows)
+				return
+			}
+
+			outputResult(cmd, strings.TrimSuffix(renderMaintenanceGantt(sections, occurrences, start, days, loc), "\n"), nil, nil)
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "today", "First day of the calendar (today, 2006-01-02, -7d)")
+	cmd.Flags().IntVar(&days, "days", 14, "Number of days to show")
+	cmd.Flags().StringSliceVar(&hostGroupNames, "hostgroup", []string{}, "Only show maintenances of these host groups")
+	cmd.Flags().StringVar(&tz, "tz", "", "Time zone to display (default local)")
+	cmd.Flags().BoolVar(&list, "list", false, "List the windows instead of drawing the calendar")
+	addServerTZFlag(cmd)
+
+	return cmd
+}
+
+func renderMaintenanceGantt(sections map[string][]map[string]interface{}, occurrences map[string][]maintenanceOccurrence, start time.Time, days int, loc *time.Location) string {
+	labelWidth := 20
+	for _, list := range sections {
+		for _, mt := range list {
+			if l := len(fmt.Sprintf("  %v (%v)", mt["name"], mt["maintenanceid"])); l > labelWidth {
+				labelWidth = l
+			}
+		}
+	}
+	if labelWidth > 48 {
+		labelWidth = 48
+	}
+
+	var b strings.Builder
+	var weekdays, dates strings.Builder
+	for i := 0; i < days; i++ {
+		day := start.AddDate(0, 0, i)
+		fmt.Fprintf(&weekdays, "%-*s", calendarSlots+1, day.Format("Mon"))
+		fmt.Fprintf(&dates, "%-*s", calendarSlots+1, day.Format("02"))
+	}
+	last := start.AddDate(0, 0, days-1)
+	fmt.Fprintf(&b, "Maintenance calendar %s to %s (%s)\n\n", start.Format("2006-01-02"), last.Format("2006-01-02"), loc)
+	fmt.Fprintf(&b, "%-*s %s\n", labelWidth, "", headerStyle.Render(weekdays.String()))
+	fmt.Fprintf(&b, "%-*s %s\n", labelWidth, "", headerStyle.Render(dates.String()))
+
+	slot := 24 * time.Hour / calendarSlots
+	for _, section := range sortedKeys(sections) {
+		fmt.Fprintf(&b, "%s\n", headerStyle.Render(section))
+		for _, mt := range sections[section] {
+			label := fmt.Sprintf("  %v (%v)", mt["name"], mt["maintenanceid"])
+			if r := []rune(label); len(r) > labelWidth {
+				label = string(r[:labelWidth-1]) + "…"
+			}
+			occ := occurrences[fmt.Sprintf("%v", mt["maintenanceid"])]
+
+			var cells strings.Builder
+			for i := 0; i < days; i++ {
+				dayStart := start.AddDate(0, 0, i)
+				for s := 0; s < calendarSlots; s++ {
+					from := dayStart.Add(time.Duration(s) * slot)
+					to := from.Add(slot)
+					cell := "·"
+					for _, o := range occ {
+						if o.Start.Before(to) && o.End.After(from) {
+							cell = "█"
+							if o.NoData {
+								cell = "▒"
+							}
+							break
+						}
+					}
+					cells.WriteString(cell)
+				}
+				cells.WriteString(" ")
+			}
+			fmt.Fprintf(&b, "%-*s %s\n", labelWidth, label, cells.String())
+		}
+	}
+	fmt.Fprintf(&b, "\n█ with data  ▒ no data  · none   (%d cells per day)\n", calendarSlots)
+	return b.String()
+}
+
+func newMaintenanceCheckCmd() *cobra.Command {
+	var days int
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Find overlapping, expired and orphaned maintenances",
+		Long: `Check maintenance definitions for:
+
+  overlap      hosts covered by windows of two maintenances at the same time (next --days)
+  expired      maintenances whose active period has ended but are still defined
+  no-targets   maintenances left without hosts or groups, because they were deleted
+  empty-group  host groups in a maintenance that no longer contain hosts`,
+		Example: `  zabbix-dna maintenance check
+  zabbix-dna maintenance check --days 90`,
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			maintenances, groupsKey, err := getMaintenancesWithTargets(client, map[string]interface{}{})
+			handleError(err)
+			serverLoc, err := maintenanceServerLocation(cmd, client)
+			handleError(err)
+			now := time.Now()
+			end := now.AddDate(0, 0, days)
+
+			var findings []maintenanceFinding
+			add := func(check string, mt map[string]interface{}, format string, a ...interface{}) {
+				findings = append(findings, maintenanceFinding{
+					Check:          check,
+					MaintenanceIDs: []string{fmt.Sprintf("%v", mt["maintenanceid"])},
+					Maintenance:    fmt.Sprintf("%v (%v)", mt["name"], mt["maintenanceid"]),
+					Detail:         fmt.Sprintf(format, a...),
+				})
+			}
+
+			groupHosts := map[string][]map[string]interface{}{}
+			hostMaintenances := map[string][]map[string]interface{}{}
+			hostNames := map[string]string{}
+			for _, mt := range maintenances {
+				if till := parseClock(mt["active_till"]); till < now.Unix() {
+					add("expired", mt, "ended %s, still defined", time.Unix(till, 0).Format("2006-01-02 15:04"))
+				}
+
+				hosts := exportList(mt["hosts"])
+				groups := exportList(mt[groupsKey])
+				if len(hosts) == 0 && len(groups) == 0 {
+					add("no-targets", mt, "no hosts or groups left; they were deleted")
+					continue
+				}
+
+				targets := map[string]bool{}
+				for _, h := range hosts {
+					id := fmt.Sprintf("%v", h["hostid"])
+					targets[id] = true
+					hostNames[id] = fmt.Sprintf("%v", h["host"])
+				}
+				for _, g := range groups {
+					gid := fmt.Sprintf("%v", g["groupid"])
+					members, ok := groupHosts[gid]
+					if !ok {
+						members, err = callGetList(client, "host.get", map[string]interface{}{
+							"output":   []string{"hostid", "host"},
+							"groupids": []string{gid},
+						})
+						handleError(err)
+						groupHosts[gid] = members
+					}
+					if len(members) == 0 {
+						add("empty-group", mt, "host group %v has no hosts", g["name"])
+					}
+					for _, h := range members {
+						id := fmt.Sprintf("%v", h["hostid"])
+						targets[id] = true
+						hostNames[id] = fmt.Sprintf("%v", h["host"])
+					}
+				}
+				for id := range targets {
+					hostMaintenances[id] = append(hostMaintenances[id], mt)
+				}
+			}
+
+			findings = append(findings, maintenanceOverlaps(maintenances, hostMaintenances, hostNames, now, end, serverLoc)...)
+
+			if len(findings) == 0 {
+				outputResult(cmd, "No maintenance problems found.", nil, nil)
+				return
+			}
+			headers := []string{"Check", "Maintenance", "Detail"}
+			var rows [][]string
+			for _, f := range findings {
+				rows = append(rows, []string{f.Check, f.Maintenance, f.Detail})
+			}
+			outputResult(cmd, findings, headers, rows)
+		},
+	}
+
+	cmd.Flags().IntVar(&days, "days", 30, "How many days ahead to check for overlapping windows")
+	addServerTZFlag(cmd)
+
+	return cmd
+}
+
+// maintenanceOverlaps reports pairs of maintenances whose windows overlap on the same hosts.
+func maintenanceOverlaps(maintenances []map[string]interface{}, hostMaintenances map[string][]map[string]interface{}, hostNames map[string]string, from, to time.Time, serverLoc *time.Location) []maintenanceFinding {
+	occurrences := map[string][]maintenanceOccurrence{}
+	byID := map[string]map[string]interface{}{}
+	for _, mt := range maintenances {
+		id := fmt.Sprintf("%v", mt["maintenanceid"])
+		occurrences[id] = expandMaintenance(mt, from, to, serverLoc)
+		byID[id] = mt
+	}
+
+	type overlap struct {
+		hosts      []string
+		start, end time.Time
+	}
+	pairs := map[[2]string]*overlap{}
+	for hostID, list := range hostMaintenances {
+		for i := 0; i < len(list); i++ {
+			for j := i + 1; j < len(list); j++ {
+				a := fmt.Sprintf("%v", list[i]["maintenanceid"])
+				b := fmt.Sprintf("%v", list[j]["maintenanceid"])
+				if a > b {
+					a, b = b, a
+				}
+				start, end, ok := firstOverlap(occurrences[a], occurrences[b])
+				if !ok {
+					continue
+				}
+				key := [2]string{a, b}
+				o := pairs[key]
+				if o == nil {
+					o = &overlap{start: start, end: end}
+					pairs[key] = o
+				}
+				o.hosts = append(o.hosts, hostNames[hostID])
+			}
+		}
+	}
+
+	var keys [][2]string
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return pairs[keys[i]].start.Before(pairs[keys[j]].start) })
+
+	var findings []maintenanceFinding
+	for _, k := range keys {
+		o := pairs[k]
+		sort.Strings(o.hosts)
+		hosts := o.hosts
+		more := ""
+		if len(hosts) > 5 {
+			more = fmt.Sprintf(" and %d more", len(hosts)-5)
+			hosts = hosts[:5]
+		}
+		a, b := byID[k[0]], byID[k[1]]
+		detail := fmt.Sprintf("%d host(s) (%s%s), first %s to %s", len(o.hosts), strings.Join(hosts, ", "), more,
+			o.start.Format("2006-01-02 15:04"), o.end.Format("2006-01-02 15:04"))
+		if fmt.Sprintf("%v", a["maintenance_type"]) != fmt.Sprintf("%v", b["maintenance_type"]) {
+			detail += "; one collects data, the other does not"
+		}
+		findings = append(findings, maintenanceFinding{
+			Check:          "overlap",
+			MaintenanceIDs: []string{k[0], k[1]},
+			Maintenance:    fmt.Sprintf("%v (%v) / %v (%v)", a["name"], k[0], b["name"], k[1]),
+			Detail:         detail,
+			Hosts:          o.hosts,
+		})
+	}
+	return findings
+}
+
+func firstOverlap(a, b []maintenanceOccurrence) (time.Time, time.Time, bool) {
+	var start, end time.Time
+	found := false
+	for _, x := range a {
+		for _, y := range b {
+			s, e := x.Start, x.End
+			if y.Start.After(s) {
+				s = y.Start
+			}
+			if y.End.Before(e) {
+				e = y.End
+			}
+			if s.Before(e) && (!found || s.Before(start)) {
+				start, end, found = s, e, true
+			}
+		}
+	}
+	return start, end, found
+}
+
+// getMaintenancesWithTargets returns maintenances with time periods, hosts and host groups, and
+// the key holding the groups, which depends on the API version.
+func getMaintenancesWithTargets(client *api.ZabbixClient, params map[string]interface{}) ([]map[string]interface{}, string, error) {
+	groupsParam, groupsKey := "selectGroups", "groups"
+	if apiVersionAtLeast(getAPIVersion(client), 6, 2) {
+		groupsParam, groupsKey = "selectHostGroups", "hostgroups"
+	}
+	params["output"] = "extend"
+	params["selectTimeperiods"] = "extend"
+	params["selectHosts"] = []string{"hostid", "host"}
+	params[groupsParam] = []string{"groupid", "name"}
+	params["sortfield"] = "name"
+
+	list, err := callGetList(client, "maintenance.get", params)
+	return list, groupsKey, err
+}
+
+// expandMaintenance returns the windows of a maintenance that overlap [from, to), clipped to
+// its active period. Recurring windows are computed in the server time zone.
+func expandMaintenance(m map[string]interface{}, from, to time.Time, serverLoc *time.Location) []maintenanceOccurrence {
+	since := time.Unix(parseClock(m["active_since"]), 0)
+	till := time.Unix(parseClock(m["active_till"]), 0)
+	lo, hi := from, to
+	if since.After(lo) {
+		lo = since
+	}
+	if till.Before(hi) {
+		hi = till
+	}
+	if !lo.Before(hi) {
+		return nil
+	}
+
+	id := fmt.Sprintf("%v", m["maintenanceid"])
+	name := fmt.Sprintf("%v", m["name"])
+	noData := fmt.Sprintf("%v", m["maintenance_type"]) == "1"
+
+	var out []maintenanceOccurrence
+	add := func(start, end time.Time) {
+		if start.Before(since) {
+			start = since
+		}
+		if end.After(till) {
+			end = till
+		}
+		if start.Before(end) && start.Before(to) && end.After(from) {
+			out = append(out, maintenanceOccurrence{MaintenanceID: id, Name: name, Start: start, End: end, NoData: noData})
+		}
+	}
+
+	sinceLocal := since.In(serverLoc)
+	sinceDay := time.Date(sinceLocal.Year(), sinceLocal.Month(), sinceLocal.Day(), 0, 0, 0, 0, serverLoc)
+
+	for _, tp := range exportList(m["timeperiods"]) {
+		n := func(name string) int { return int(parseClock(tp[name])) }
+		period := time.Duration(n("period")) * time.Second
+		tpType := fmt.Sprintf("%v", tp["timeperiod_type"])
+
+		if tpType == timeperiodOnce {
+			start := time.Unix(parseClock(tp["start_date"]), 0)
+			add(start, start.Add(period))
+			continue
+		}
+
+		first := lo.Add(-period).In(serverLoc)
+		day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, serverLoc)
+		if day.Before(sinceDay) {
+			day = sinceDay
+		}
+		for ; day.Before(hi); day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, serverLoc) {
+			if timeperiodMatches(tpType, tp, day, sinceDay) {
+				start := day.Add(time.Duration(n("start_time")) * time.Second)
+				add(start, start.Add(period))
+			}
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// timeperiodMatches reports whether a recurring time period has a window starting on day.
+func timeperiodMatches(tpType string, tp map[string]interface{}, day, sinceDay time.Time) bool {
+	n := func(name string) int { return int(parseClock(tp[name])) }
+	every := n("every")
+	if every < 1 {
+		every = 1
+	}
+	weekday := (int(day.Weekday()) + 6) % 7 // Monday = 0
+
+	switch tpType {
+	case timeperiodDaily:
+		return (dayNumber(day)-dayNumber(sinceDay))%every == 0
+
+	case timeperiodWeekly:
+		if n("dayofweek")&(1<<weekday) == 0 {
+			return false
+		}
+		sinceMonday := dayNumber(sinceDay) - (int(sinceDay.Weekday())+6)%7
+		dayMonday := dayNumber(day) - weekday
+		return ((dayMonday-sinceMonday)/7)%every == 0
+
+	case timeperiodMonthly:
+		if n("month")&(1<<(int(day.Month())-1)) == 0 {
+			return false
+		}
+		if n("day") > 0 {
+			return day.Day() == n("day")
+		}
+		if n("dayofweek")&(1<<weekday) == 0 {
+			return false
+		}
+		if every == 5 {
+			return day.AddDate(0, 0, 7).Month() != day.Month()
+		}
+		return (day.Day()-1)/7+1 == every
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeperiodMatches(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.ParseInLocation("2006-01-02", s, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	since := date("2026-10-05") // a Monday
+
+	tests := []struct {
+		name   string
+		tpType string
+		tp     map[string]interface{}
+		day    string
+		want   bool
+	}{
+		{"daily every second day", timeperiodDaily, map[string]interface{}{"every": "2"}, "2026-10-07", true},
+		{"daily off day", timeperiodDaily, map[string]interface{}{"every": "2"}, "2026-10-08", false},
+		{"weekly first week monday", timeperiodWeekly, map[string]interface{}{"every": "2", "dayofweek": "17"}, "2026-10-05", true},
+		{"weekly first week friday", timeperiodWeekly, map[string]interface{}{"every": "2", "dayofweek": "17"}, "2026-10-09", true},
+		{"weekly other weekday", timeperiodWeekly, map[string]interface{}{"every": "2", "dayofweek": "17"}, "2026-10-06", false},
+		{"weekly skipped week", timeperiodWeekly, map[string]interface{}{"every": "2", "dayofweek": "17"}, "2026-10-12", false},
+		{"weekly third week", timeperiodWeekly, map[string]interface{}{"every": "2", "dayofweek": "17"}, "2026-10-19", true},
+		{"monthly day", timeperiodMonthly, map[string]interface{}{"day": "15", "month": "4095"}, "2026-10-15", true},
+		{"monthly other day", timeperiodMonthly, map[string]interface{}{"day": "15", "month": "4095"}, "2026-10-16", false},
+		{"monthly other month", timeperiodMonthly, map[string]interface{}{"day": "15", "month": "3583"}, "2026-10-15", false},
+		{"monthly first monday", timeperiodMonthly, map[string]interface{}{"every": "1", "dayofweek": "1", "month": "4095"}, "2026-10-05", true},
+		{"monthly second monday", timeperiodMonthly, map[string]interface{}{"every": "1", "dayofweek": "1", "month": "4095"}, "2026-10-12", false},
+		{"monthly last friday", timeperiodMonthly, map[string]interface{}{"every": "5", "dayofweek": "16", "month": "4095"}, "2026-10-30", true},
+		{"monthly fourth friday is not last", timeperiodMonthly, map[string]interface{}{"every": "5", "dayofweek": "16", "month": "4095"}, "2026-10-23", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := timeperiodMatches(tt.tpType, tt.tp, date(tt.day), since); got != tt.want {
+				t.Errorf("timeperiodMatches(%s, %v, %s) = %v, want %v", tt.tpType, tt.tp, tt.day, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandMaintenance(t *testing.T) {
+	saoPaulo := mustLoadLocation(t, "America/Sao_Paulo")
+	utc := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	unix := func(s string) string { return fmt.Sprint(utc(s).Unix()) }
+
+	tests := []struct {
+		name     string
+		m        map[string]interface{}
+		from, to string
+		server   *time.Location
+		want     []string
+	}{
+		{
+			name: "daily in server time",
+			m: map[string]interface{}{
+				"active_since": unix("2026-10-05 03:00"), "active_till": unix("2027-10-05 03:00"),
+				"timeperiods": []interface{}{map[string]interface{}{"timeperiod_type": "2", "every": "1", "start_time": "79200", "period": "10800"}},
+			},
+			from: "2026-10-10 00:00", to: "2026-10-12 00:00", server: saoPaulo,
+			want: []string{"2026-10-10 01:00-2026-10-10 04:00", "2026-10-11 01:00-2026-10-11 04:00"},
+		},
+		{
+			name: "window started before the range",
+			m: map[string]interface{}{
+				"active_since": unix("2026-10-01 00:00"), "active_till": unix("2027-10-01 00:00"),
+				"timeperiods": []interface{}{map[string]interface{}{"timeperiod_type": "2", "every": "1", "start_time": "82800", "period": "7200"}},
+			},
+			from: "2026-10-10 00:00", to: "2026-10-10 12:00", server: time.UTC,
+			want: []string{"2026-10-09 23:00-2026-10-10 01:00"},
+		},
+		{
+			name: "once clipped to the active period",
+			m: map[string]interface{}{
+				"active_since": unix("2026-10-10 10:00"), "active_till": unix("2026-10-10 11:00"),
+				"timeperiods": []interface{}{map[string]interface{}{"timeperiod_type": "0", "start_date": unix("2026-10-10 10:00"), "period": "7200"}},
+			},
+			from: "2026-10-10 00:00", to: "2026-10-11 00:00", server: time.UTC,
+			want: []string{"2026-10-10 10:00-2026-10-10 11:00"},
+		},
+		{
+			name: "outside the active period",
+			m: map[string]interface{}{
+				"active_since": unix("2026-09-01 00:00"), "active_till": unix("2026-09-30 00:00"),
+				"timeperiods": []interface{}{map[string]interface{}{"timeperiod_type": "2", "every": "1", "start_time": "0", "period": "3600"}},
+			},
+			from: "2026-10-10 00:00", to: "2026-10-11 00:00", server: time.UTC,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, o := range expandMaintenance(tt.m, utc(tt.from), utc(tt.to), tt.server) {
+				got = append(got, o.Start.UTC().Format("2006-01-02 15:04")+"-"+o.End.UTC().Format("2006-01-02 15:04"))
+			}
+			if strings.Join(got, ";") != strings.Join(tt.want, ";") {
+				t.Errorf("expandMaintenance = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"os/user"
+	"strings"
+	"syscall"
+	"time"
+
+	"zabbix-dna/internal/api"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+func newMaintenanceRunCmd() *cobra.Command {
+	var opts maintenanceOptions
+	var name string
+	var durationMax string
+	var wait bool
+
+	cmd := &cobra.Command{
+		Use:   "run --host <host> [flags] -- <command> [args...]",
+		Short: "Run a command inside a maintenance window",
+		Long: `Create a maintenance, run a command with its output streamed, and delete the maintenance
+when the command ends, fails or is interrupted. The exit status of the command is returned.
+
+The maintenance is created with --duration-max as its end, so if zabbix-dna itself is killed
+the window still expires on its own. Signals (Ctrl-C, SIGTERM, SIGHUP) are passed to the
+command before the maintenance is removed; Ctrl-C at a terminal already reaches the command
+directly and is not passed a second time.
+
+With --wait the command only starts once the Zabbix server reports the hosts in maintenance,
+which can take up to a minute.`,
+		Example: `  zabbix-dna maintenance run --host web01 --duration-max 1h -- ./deploy.sh v1.4.2
+  zabbix-dna maintenance run --hostgroup "Web servers" --tag service=nginx --wait -- ansible-playbook site.yml`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if cmd.ArgsLenAtDash() != 0 {
+				handleError(fmt.Errorf("put the command after --, e.g. maintenance run --host web01 -- ./deploy.sh"))
+			}
+			if len(opts.hosts) == 0 && len(opts.hostgroups) == 0 {
+				handleError(fmt.Errorf("specify at least one --host or --hostgroup"))
+			}
+			deadline, err := parseDurationSpec(durationMax)
+			handleError(err)
+			if deadline < 5*time.Minute {
+				handleError(fmt.Errorf("--duration-max must be at least 5m"))
+			}
+
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			now := time.Now()
+			if name == "" {
+				name = defaultRunMaintenanceName(args, now)
+			}
+			params := map[string]interface{}{
+				"name":         name,
+				"active_since": now.Unix(),
+				"active_till":  now.Add(deadline).Unix(),
+				"timeperiods": []map[string]interface{}{{
+					"timeperiod_type": 0,
+					"start_date":      now.Unix(),
+					"period":          int64(deadline.Seconds()),
+				}},
+			}
+			if !cmd.Flags().Changed("description") {
+				params["description"] = "Created by zabbix-dna maintenance run: " + strings.Join(args, " ")
+			}
+			handleError(opts.apply(cmd, client, params))
+
+			result, err := client.Call("maintenance.create", params)
+			handleError(err)
+			var resp map[string]interface{}
+			json.Unmarshal(result, &resp)
+			ids, _ := resp["maintenanceids"].([]interface{})
+			if len(ids) == 0 {
+				handleError(fmt.Errorf("maintenance.create returned no ID"))
+			}
+			maintenanceID := fmt.Sprintf("%v", ids[0])
+			fmt.Fprintf(os.Stderr, "zabbix-dna: maintenance %s (%s) active until %s\n", maintenanceID, name, now.Add(deadline).Format("15:04:05"))
+
+			cleanup := func() {
+				if err := deleteRunMaintenance(cmd, client, maintenanceID); err != nil {
+					fmt.Fprintf(os.Stderr, "zabbix-dna: failed to delete maintenance %s, it expires at %s: %v\n", maintenanceID, now.Add(deadline).Format("15:04:05"), err)
+					return
+				}
+				fmt.Fprintf(os.Stderr, "zabbix-dna: maintenance %s deleted\n", maintenanceID)
+			}
+
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+			defer signal.Stop(signals)
+
+			if wait {
+				if err := waitForMaintenance(client, params, signals); err != nil {
+					cleanup()
+					handleError(err)
+				}
+			}
+
+			child := exec.Command(args[0], args[1:]...)
+			child.Stdin = os.Stdin
+			child.Stdout = os.Stdout
+			child.Stderr = os.Stderr
+			if err := child.Start(); err != nil {
+				cleanup()
+				handleError(err)
+			}
+
+			// Ctrl-C at a terminal goes to the whole foreground process group, the command included.
+			interactive := term.IsTerminal(os.Stdin.Fd())
+			go func() {
+				for sig := range signals {
+					if sig == os.Interrupt && interactive {
+						continue
+					}
+					child.Process.Signal(sig)
+				}
+			}()
+			expired := time.AfterFunc(deadline, func() {
+				fmt.Fprintf(os.Stderr, "zabbix-dna: maintenance %s reached --duration-max, alerts are no longer suppressed\n", maintenanceID)
+			})
+
+			err = child.Wait()
+			expired.Stop()
+			cleanup()
+
+			code := 0
+			var exitErr *exec.ExitError
+			switch {
+			case errors.As(err, &exitErr):
+				code = exitErr.ExitCode()
+				if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+					code = 128 + int(status.Signal())
+				}
+			case err != nil:
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				code = 1
+			}
+			os.Exit(code)
+		},
+	}
+
+	opts.addTargetFlags(cmd)
+	cmd.Flags().StringVar(&name, "name", "", "Maintenance name (default describes the command, user and host)")
+	cmd.Flags().StringVar(&durationMax, "duration-max", "1h", "Deadline after which the maintenance expires even if zabbix-dna is killed")
+	cmd.Flags().BoolVar(&wait, "wait", false, "Start the command only once the hosts are in maintenance")
+
+	return cmd
+}
+
+// defaultRunMaintenanceName builds a unique maintenance name; Zabbix limits names to 128 characters.
+func defaultRunMaintenanceName(args []string, now time.Time) string {
+	who := "unknown"
+	if u, err := user.Current(); err == nil {
+		who = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		who += "@" + host
+	}
+	name := fmt.Sprintf("zabbix-dna run %s by %s: %s", now.Format("2006-01-02 15:04:05"), who, strings.Join(args, " "))
+	if r := []rune(name); len(r) > 128 {
+		name = string(r[:127]) + "…"
+	}
+	return name
+}
+
+// deleteRunMaintenance deletes the maintenance, logging in again when the session expired
+// while the command was running.
+func deleteRunMaintenance(cmd *cobra.Command, client *api.ZabbixClient, maintenanceID string) error {
+	_, err := client.Call("maintenance.delete", []string{maintenanceID})
+	if err == nil {
+		return nil
+	}
+	fresh, loginErr := getZabbixClient(cmd)
+	if loginErr != nil {
+		return err
+	}
+	_, err = fresh.Call("maintenance.delete", []string{maintenanceID})
+	return err
+}
+
+// waitForMaintenance polls until every host of the maintenance reports maintenance_status 1.
+func waitForMaintenance(client *api.ZabbixClient, params map[string]interface{}, signals chan os.Signal) error {
+	// host.get with both hostids and groupids returns the intersection, so hosts and groups
+	// are queried separately.
+	var queries []map[string]interface{}
+	if hosts, ok := params["hosts"].([]map[string]string); ok {
+		var ids []string
+		for _, h := range hosts {
+			ids = append(ids, h["hostid"])
+		}
+		queries = append(queries, map[string]interface{}{"hostids": ids})
+	}
+	if groups, ok := params["groups"].([]map[string]string); ok {
+		var ids []string
+		for _, g := range groups {
+			ids = append(ids, g["groupid"])
+		}
+		queries = append(queries, map[string]interface{}{"groupids": ids})
+	}
+
+	fmt.Fprintf(os.Stderr, "zabbix-dna: waiting for the server to start the maintenance...\n")
+	timeout := time.After(3 * time.Minute)
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		status := map[string]string{}
+		for _, query := range queries {
+			query["output"] = []string{"hostid", "host", "maintenance_status"}
+			hosts, err := callGetList(client, "host.get", query)
+			if err != nil {
+				return err
+			}
+			for _, h := range hosts {
+				status[fmt.Sprintf("%v", h["hostid"])] = fmt.Sprintf("%v", h["maintenance_status"])
+			}
+		}
+		pending := 0
+		for _, s := range status {
+			if s != "1" {
+				pending++
+			}
+		}
+		if pending == 0 {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-timeout:
+			return fmt.Errorf("%d host(s) still not in maintenance after 3 minutes", pending)
+		case sig := <-signals:
+			return fmt.Errorf("interrupted by %v", sig)
+		}
+	}
+}
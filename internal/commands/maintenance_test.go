@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+// scheduleCommand parses schedule flags the way create and update do.
+func scheduleCommand(t *testing.T, args []string) (*cobra.Command, *maintenanceSchedule) {
+	t.Helper()
+	s := &maintenanceSchedule{}
+	cmd := &cobra.Command{Use: "test"}
+	s.addFlags(cmd)
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatalf("ParseFlags(%q): %v", args, err)
+	}
+	return cmd, s
+}
+
+func TestMaintenanceScheduleBuild(t *testing.T) {
+	saoPaulo := mustLoadLocation(t, "America/Sao_Paulo")
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+
+	tests := []struct {
+		name   string
+		server *time.Location
+		args   []string
+		want   map[string]interface{}
+		err    bool
+	}{
+		{
+			name:   "weekly in server zone",
+			server: saoPaulo,
+			args:   []string{"--tz", "America/Sao_Paulo", "--schedule", "weekly", "--weekdays", "sat", "--start", "2026-10-17 22:00", "--duration", "3h"},
+			want:   map[string]interface{}{"timeperiod_type": 3, "every": 1, "dayofweek": 32, "start_time": 22 * 3600, "period": int64(3 * 3600)},
+		},
+		{
+			name:   "weekly given in UTC moves back a day",
+			server: saoPaulo,
+			args:   []string{"--tz", "UTC", "--schedule", "weekly", "--weekdays", "sun", "--start", "2026-10-18 01:00", "--duration", "3h"},
+			want:   map[string]interface{}{"timeperiod_type": 3, "every": 1, "dayofweek": 32, "start_time": 22 * 3600, "period": int64(3 * 3600)},
+		},
+		{
+			name:   "monthly day given in UTC moves back a day",
+			server: saoPaulo,
+			args:   []string{"--tz", "UTC", "--schedule", "monthly", "--day", "2", "--start", "2026-11-02 02:00"},
+			want:   map[string]interface{}{"timeperiod_type": 4, "month": 4095, "day": 1, "start_time": 23 * 3600},
+		},
+		{
+			name:   "week of month cannot move",
+			server: saoPaulo,
+			args:   []string{"--tz", "UTC", "--schedule", "monthly", "--week", "first", "--weekdays", "mon", "--start", "2026-11-02 02:00"},
+			err:    true,
+		},
+		{
+			name:   "daily keeps the time of day in server zone",
+			server: berlin,
+			args:   []string{"--tz", "UTC", "--schedule", "daily", "--start", "2026-10-18 22:30"},
+			want:   map[string]interface{}{"timeperiod_type": 2, "every": 1, "start_time": 30 * 60},
+		},
+		{
+			name:   "once",
+			server: berlin,
+			args:   []string{"--tz", "UTC", "--start", "2026-10-18 22:30", "--duration", "2h"},
+			want:   map[string]interface{}{"timeperiod_type": 0, "start_date": time.Date(2026, 10, 18, 22, 30, 0, 0, time.UTC).Unix(), "period": int64(2 * 3600)},
+		},
+		{
+			name:   "too short",
+			server: berlin,
+			args:   []string{"--start", "2026-10-18 22:30", "--duration", "1m"},
+			err:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, s := scheduleCommand(t, tt.args)
+			_, _, periods, err := s.build(cmd, tt.server)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("build succeeded with %v, want error", periods)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("build: %v", err)
+			}
+			checkTimeperiod(t, periods, tt.want)
+		})
+	}
+}
+
+func TestMaintenanceScheduleSeedRoundTrip(t *testing.T) {
+	saoPaulo := mustLoadLocation(t, "America/Sao_Paulo")
+
+	// The existing windows, as maintenance.get returns them, evaluated in Sao Paulo time.
+	weeklySaturday := map[string]interface{}{
+		"active_since": fmt.Sprint(time.Date(2026, 10, 10, 0, 0, 0, 0, saoPaulo).Unix()),
+		"active_till":  fmt.Sprint(time.Date(2027, 10, 10, 0, 0, 0, 0, saoPaulo).Unix()),
+		"timeperiods": []interface{}{map[string]interface{}{
+			"timeperiod_type": "3", "every": "1", "dayofweek": "32", "start_time": "79200", "period": "3600",
+		}},
+	}
+	monthlyFirst := map[string]interface{}{
+		"active_since": fmt.Sprint(time.Date(2026, 10, 1, 0, 0, 0, 0, saoPaulo).Unix()),
+		"active_till":  fmt.Sprint(time.Date(2027, 10, 1, 0, 0, 0, 0, saoPaulo).Unix()),
+		"timeperiods": []interface{}{map[string]interface{}{
+			"timeperiod_type": "4", "every": "1", "day": "1", "month": "4095", "start_time": "82800", "period": "3600",
+		}},
+	}
+	once := map[string]interface{}{
+		"active_since": "1792281600",
+		"active_till":  "1792285200",
+		"timeperiods": []interface{}{map[string]interface{}{
+			"timeperiod_type": "0", "start_date": "1792281600", "period": "3600",
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		existing map[string]interface{}
+		args     []string
+		want     map[string]interface{}
+	}{
+		{
+			name:     "weekly duration only",
+			existing: weeklySaturday,
+			args:     []string{"--duration", "3h"},
+			want:     map[string]interface{}{"timeperiod_type": 3, "dayofweek": 32, "start_time": 79200, "period": int64(3 * 3600)},
+		},
+		{
+			name:     "weekly new start in UTC keeps the seeded weekday",
+			existing: weeklySaturday,
+			args:     []string{"--tz", "UTC", "--start", "2026-10-18 01:00"},
+			want:     map[string]interface{}{"timeperiod_type": 3, "dayofweek": 32, "start_time": 79200, "period": int64(3600)},
+		},
+		{
+			name:     "weekly new weekdays keep the seeded start",
+			existing: weeklySaturday,
+			args:     []string{"--weekdays", "sun"},
+			want:     map[string]interface{}{"timeperiod_type": 3, "dayofweek": 64, "start_time": 79200},
+		},
+		{
+			name:     "weekly new weekdays in UTC are converted",
+			existing: weeklySaturday,
+			args:     []string{"--tz", "UTC", "--start", "2026-10-18 01:00", "--weekdays", "sun"},
+			want:     map[string]interface{}{"timeperiod_type": 3, "dayofweek": 32, "start_time": 79200},
+		},
+		{
+			name:     "monthly new start in UTC keeps the seeded day",
+			existing: monthlyFirst,
+			args:     []string{"--tz", "UTC", "--start", "2026-11-02 02:00"},
+			want:     map[string]interface{}{"timeperiod_type": 4, "day": 1, "month": 4095, "start_time": 82800},
+		},
+		{
+			name:     "once duration only",
+			existing: once,
+			args:     []string{"--duration", "2h"},
+			want:     map[string]interface{}{"timeperiod_type": 0, "start_date": int64(1792281600), "period": int64(2 * 3600)},
+		},
+	}
+
+	// A client in UTC, so local time differs from the server time zone.
+	local := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = local }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, s := scheduleCommand(t, tt.args)
+			if err := s.seed(cmd, tt.existing, saoPaulo); err != nil {
+				t.Fatalf("seed: %v", err)
+			}
+			_, _, periods, err := s.build(cmd, saoPaulo)
+			if err != nil {
+				t.Fatalf("build: %v", err)
+			}
+			checkTimeperiod(t, periods, tt.want)
+		})
+	}
+}
+
+func checkTimeperiod(t *testing.T, periods []map[string]interface{}, want map[string]interface{}) {
+	t.Helper()
+	if len(periods) != 1 {
+		t.Fatalf("got %d time periods, want 1", len(periods))
+	}
+	for k, v := range want {
+		if got := periods[0][k]; fmt.Sprint(got) != fmt.Sprint(v) {
+			t.Errorf("%s = %v, want %v (time period %v)", k, got, v, periods[0])
+		}
+	}
+}
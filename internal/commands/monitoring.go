@@ -3,6 +3,9 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"time"
+
+	"zabbix-dna/internal/tui"
 
 	"github.com/spf13/cobra"
 )
@@ -19,6 +22,7 @@ func newMonitoringCmd() *cobra.Command {
 	cmd.AddCommand(newShowTriggersCmd())
 	cmd.AddCommand(newShowEventsCmd())
 	cmd.AddCommand(newShowGraphsCmd())
+	cmd.AddCommand(newShowHistoryCmd())
 
 	return cmd
 }
@@ -104,7 +108,10 @@ func newShowItemCmd() *cobra.Command {
 }
 
 func newShowLastValuesCmd() *cobra.Command {
-	return &cobra.Command{
+	var watch bool
+	var interval time.Duration
+
+	cmd := &cobra.Command{
 		Use:     "last-values [host name]",
 		Aliases: []string{"show_last_values"},
 		Short:   "Show last values for a host",
@@ -115,33 +122,72 @@ func newShowLastValuesCmd() *cobra.Command {
 
 			params := map[string]interface{}{
 				"host":   args[0],
-				"output": []string{"itemid", "name", "key_", "lastvalue", "units"},
+				"output": []string{"itemid", "name", "key_", "lastvalue", "lastclock", "units"},
 			}
 
-			result, err := client.Call("item.get", params)
-			handleError(err)
+			fetch := func() ([]map[string]interface{}, [][]string, error) {
+				result, err := client.Call("item.get", params)
+				if err != nil {
+					return nil, nil, err
+				}
 
-			var items []map[string]interface{}
-			json.Unmarshal(result, &items)
+				var items []map[string]interface{}
+				json.Unmarshal(result, &items)
+
+				var rows [][]string
+				for _, i := range items {
+					val := fmt.Sprintf("%v", i["lastvalue"])
+					if units, ok := i["units"].(string); ok && units != "" {
+						val += " " + units
+					}
+					rows = append(rows, []string{
+						fmt.Sprintf("%v", i["itemid"]),
+						fmt.Sprintf("%v", i["name"]),
+						fmt.Sprintf("%v", i["key_"]),
+						val,
+					})
+				}
+				return items, rows, nil
+			}
 
 			headers := []string{"ItemID", "Name", "Key", "Last Value"}
-			var rows [][]string
-			for _, i := range items {
-				val := fmt.Sprintf("%v", i["lastvalue"])
-				if units, ok := i["units"].(string); ok && units != "" {
-					val += " " + units
-				}
-				rows = append(rows, []string{
-					fmt.Sprintf("%v", i["itemid"]),
-					fmt.Sprintf("%v", i["name"]),
-					fmt.Sprintf("%v", i["key_"]),
-					val,
-				})
+
+			if watch {
+				handleError(tui.Watch(tui.WatchConfig{
+					Title:    "Last values: " + args[0],
+					Headers:  append(headers, "Last Check"),
+					Interval: interval,
+					Fetch: func() ([]tui.WatchRow, error) {
+						items, rows, err := fetch()
+						if err != nil {
+							return nil, err
+						}
+						var watchRows []tui.WatchRow
+						for idx, row := range rows {
+							watchRows = append(watchRows, tui.WatchRow{
+								Key:      row[0],
+								Cells:    append(row, formatUnixTime(fmt.Sprintf("%v", items[idx]["lastclock"]))),
+								Severity: -1,
+								Host:     args[0],
+							})
+						}
+						return watchRows, nil
+					},
+				}))
+				return
 			}
 
+			items, rows, err := fetch()
+			handleError(err)
+
 			outputResult(cmd, items, headers, rows)
 		},
 	}
+
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Open a live view that refreshes periodically")
+	cmd.Flags().DurationVar(&interval, "interval", 10*time.Second, "Refresh interval for --watch")
+
+	return cmd
 }
 
 func newShowTriggersCmd() *cobra.Command {
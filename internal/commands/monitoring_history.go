@@ -0,0 +1,427 @@
+package commands
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// historyPoint is a single history value or an hourly trend record.
+type historyPoint struct {
+	Clock  int64   `json:"clock"`
+	Min    float64 `json:"min"`
+	Avg    float64 `json:"avg"`
+	Max    float64 `json:"max"`
+	Weight int     `json:"count"`
+	Text   string  `json:"value,omitempty"`
+}
+
+// historyBucket aggregates the points that fall into one time bucket.
+type historyBucket struct {
+	Start int64   `json:"start"`
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Avg   float64 `json:"avg"`
+	Max   float64 `json:"max"`
+	P95   float64 `json:"p95"`
+}
+
+func newShowHistoryCmd() *cobra.Command {
+	var from string
+	var to string
+	var source string
+	var bucket string
+	var export string
+	var outFile string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "history [host name] [item key]",
+		Short: "Show item history or trends for a time range",
+		Long: `Retrieve values with history.get or trend.get for a time range.
+
+Times accept now, relative offsets (-6h, -7d, -1w), Unix timestamps or absolute dates
+("2026-11-01 22:00"). Numeric values can be aggregated per bucket (min/avg/max/p95);
+--source auto uses trends for ranges longer than 7 days.`,
+		Example: `  zabbix-dna monitoring history web01 system.cpu.util --from -6h --bucket 15m
+  zabbix-dna monitoring history web01 'net.if.in[eth0]' --from -30d --export csv -O traffic.csv`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			now := time.Now()
+			timeFrom, err := parseTimeSpec(from, now)
+			handleError(err)
+			timeTill, err := parseTimeSpec(to, now)
+			handleError(err)
+			if !timeFrom.Before(timeTill) {
+				handleError(fmt.Errorf("--from must be before --to"))
+			}
+
+			// Checked before any output file is created, so a bad --export leaves no file behind.
+			switch export {
+			case "", "csv", "ndjson":
+			default:
+				handleError(fmt.Errorf("invalid export format: %s (expected csv or ndjson)", export))
+			}
+
+			var bucketSize time.Duration
+			if bucket != "" && bucket != "0" {
+				bucketSize, err = parseDurationSpec(bucket)
+				handleError(err)
+			}
+
+			hostID := getHostID(client, args[0])
+			if hostID == "" {
+				handleError(fmt.Errorf("host not found: %s", args[0]))
+			}
+			item, err := getItemByKey(client, hostID, args[1])
+			handleError(err)
+
+			valueType := fmt.Sprintf("%v", item["value_type"])
+			numeric := valueType == "0" || valueType == "3"
+			units := fmt.Sprintf("%v", item["units"])
+
+			useTrends := false
+			switch source {
+			case "auto":
+				useTrends = numeric && timeTill.Sub(timeFrom) > 7*24*time.Hour
+			case "history":
+			case "trends":
+				if !numeric {
+					handleError(fmt.Errorf("trends are only stored for numeric items"))
+				}
+				useTrends = true
+			default:
+				handleError(fmt.Errorf("invalid source: %s (expected auto, history or trends)", source))
+			}
+
+			params := map[string]interface{}{
+				"output":    "extend",
+				"itemids":   []string{fmt.Sprintf("%v", item["itemid"])},
+				"time_from": timeFrom.Unix(),
+				"time_till": timeTill.Unix(),
+				"sortfield": "clock",
+				"sortorder": "ASC",
+			}
+			if limit > 0 {
+				params["limit"] = limit
+			}
+			method := "history.get"
+			if useTrends {
+				method = "trend.get"
+				delete(params, "sortfield")
+				delete(params, "sortorder")
+			} else {
+				params["history"] = valueType
+			}
+
+			result, err := client.Call(method, params)
+			handleError(err)
+
+			var records []map[string]interface{}
+			json.Unmarshal(result, &records)
+			points := parseHistoryPoints(records, useTrends, numeric)
+
+			var buckets []historyBucket
+			if numeric && bucketSize > 0 {
+				buckets = aggregateHistory(points, timeFrom, bucketSize)
+			}
+
+			if export != "" {
+				w := io.Writer(os.Stdout)
+				if outFile != "" && outFile != "-" {
+					f, err := os.Create(outFile)
+					handleError(err)
+					defer f.Close()
+					w = f
+				}
+				handleError(exportHistory(w, export, points, buckets, numeric))
+				if outFile != "" && outFile != "-" {
+					fmt.Fprintf(os.Stderr, "Exported %d records to %s\n", exportedCount(points, buckets), outFile)
+				}
+				return
+			}
+
+			if getOutputFormat(cmd) == "table" && numeric && len(points) > 0 {
+				var series []float64
+				if len(buckets) > 0 {
+					for _, b := range buckets {
+						series = append(series, b.Avg)
+					}
+				} else {
+					for _, p := range points {
+						series = append(series, p.Avg)
+					}
+				}
+				fmt.Printf("%s  %s  [%s .. %s]\n", args[1], sparkline(series, 60),
+					timeFrom.Format("2006-01-02 15:04"), timeTill.Format("2006-01-02 15:04"))
+			}
+
+			if len(buckets) > 0 {
+				maxAvg := 0.0
+				for _, b := range buckets {
+					maxAvg = math.Max(maxAvg, b.Avg)
+				}
+				headers := []string{"Bucket", "Count", "Min", "Avg", "Max", "P95", ""}
+				var rows [][]string
+				for _, b := range buckets {
+					rows = append(rows, []string{
+						time.Unix(b.Start, 0).Format("2006-01-02 15:04"),
+						strconv.Itoa(b.Count),
+						formatHistoryValue(b.Min, units),
+						formatHistoryValue(b.Avg, units),
+						formatHistoryValue(b.Max, units),
+						formatHistoryValue(b.P95, units),
+						bar(b.Avg, maxAvg, 20),
+					})
+				}
+				outputResult(cmd, buckets, headers, rows)
+				return
+			}
+
+			var headers []string
+			var rows [][]string
+			switch {
+			case useTrends:
+				headers = []string{"Hour", "Count", "Min", "Avg", "Max"}
+				for _, p := range points {
+					rows = append(rows, []string{
+						time.Unix(p.Clock, 0).Format("2006-01-02 15:04"),
+						strconv.Itoa(p.Weight),
+						formatHistoryValue(p.Min, units),
+						formatHistoryValue(p.Avg, units),
+						formatHistoryValue(p.Max, units),
+					})
+				}
+			case numeric:
+				headers = []string{"Time", "Value"}
+				for _, p := range points {
+					rows = append(rows, []string{time.Unix(p.Clock, 0).Format("2006-01-02 15:04:05"), formatHistoryValue(p.Avg, units)})
+				}
+			default:
+				headers = []string{"Time", "Value"}
+				for _, p := range points {
+					rows = append(rows, []string{time.Unix(p.Clock, 0).Format("2006-01-02 15:04:05"), p.Text})
+				}
+			}
+			outputResult(cmd, points, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "-1h", "Start of the time range")
+	cmd.Flags().StringVar(&to, "to", "now", "End of the time range")
+	cmd.Flags().StringVar(&source, "source", "auto", "Data source (auto, history, trends)")
+	cmd.Flags().StringVarP(&bucket, "bucket", "b", "", "Aggregate numeric values per bucket (e.g. 5m, 1h, 1d)")
+	cmd.Flags().StringVarP(&export, "export", "e", "", "Export raw or aggregated values (csv, ndjson)")
+	cmd.Flags().StringVarP(&outFile, "output-file", "O", "-", "File for --export (default: stdout)")
+	cmd.Flags().IntVarP(&limit, "limit", "l", 0, "Maximum number of records to fetch (0: no limit)")
+
+	return cmd
+}
+
+func parseHistoryPoints(records []map[string]interface{}, trends, numeric bool) []historyPoint {
+	var points []historyPoint
+	for _, r := range records {
+		clock, _ := strconv.ParseInt(fmt.Sprintf("%v", r["clock"]), 10, 64)
+		p := historyPoint{Clock: clock, Weight: 1}
+		switch {
+		case trends:
+			p.Min, _ = strconv.ParseFloat(fmt.Sprintf("%v", r["value_min"]), 64)
+			p.Avg, _ = strconv.ParseFloat(fmt.Sprintf("%v", r["value_avg"]), 64)
+			p.Max, _ = strconv.ParseFloat(fmt.Sprintf("%v", r["value_max"]), 64)
+			p.Weight, _ = strconv.Atoi(fmt.Sprintf("%v", r["num"]))
+		case numeric:
+			v, _ := strconv.ParseFloat(fmt.Sprintf("%v", r["value"]), 64)
+			p.Min, p.Avg, p.Max = v, v, v
+		default:
+			p.Text = fmt.Sprintf("%v", r["value"])
+		}
+		points = append(points, p)
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Clock < points[j].Clock })
+	return points
+}
+
+// aggregateHistory groups points into fixed-size buckets aligned to start.
+// Averages are weighted by the number of values behind each point (trend records).
+func aggregateHistory(points []historyPoint, start time.Time, size time.Duration) []historyBucket {
+	step := int64(size.Seconds())
+	if step <= 0 {
+		return nil
+	}
+
+	var buckets []historyBucket
+	var values []float64
+	var sum float64
+	var weight int
+	current := historyBucket{Start: -1}
+
+	flush := func() {
+		if current.Start < 0 || weight == 0 {
+			return
+		}
+		current.Avg = sum / float64(weight)
+		current.P95 = percentile(values, 95)
+		buckets = append(buckets, current)
+	}
+
+	for _, p := range points {
+		bucketStart := start.Unix() + ((p.Clock-start.Unix())/step)*step
+		if bucketStart != current.Start {
+			flush()
+			current = historyBucket{Start: bucketStart, Min: p.Min, Max: p.Max}
+			values = nil
+			sum = 0
+			weight = 0
+		}
+		w := p.Weight
+		if w <= 0 {
+			w = 1
+		}
+		current.Count += w
+		current.Min = math.Min(current.Min, p.Min)
+		current.Max = math.Max(current.Max, p.Max)
+		sum += p.Avg * float64(w)
+		weight += w
+		values = append(values, p.Avg)
+	}
+	flush()
+	return buckets
+}
+
+// percentile returns the nearest-rank percentile of values.
+func percentile(values []float64, pct float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(pct/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// sparkline renders values as a line of block characters, downsampling to width points.
+func sparkline(values []float64, width int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	if len(values) > width {
+		sampled := make([]float64, width)
+		for i := range sampled {
+			lo := i * len(values) / width
+			hi := (i + 1) * len(values) / width
+			sum := 0.0
+			for _, v := range values[lo:hi] {
+				sum += v
+			}
+			sampled[i] = sum / float64(hi-lo)
+		}
+		values = sampled
+	}
+
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		idx := 0
+		if hi > lo {
+			idx = int((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[idx])
+	}
+	return b.String()
+}
+
+// bar renders value relative to max as a horizontal bar of up to width cells.
+func bar(value, max float64, width int) string {
+	if max <= 0 || value <= 0 {
+		return ""
+	}
+	cells := int(math.Round(value / max * float64(width)))
+	return strings.Repeat("█", cells)
+}
+
+func formatHistoryValue(v float64, units string) string {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if math.Abs(v) >= 1000 || v != math.Trunc(v) {
+		s = strconv.FormatFloat(v, 'f', 4, 64)
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if units != "" && units != "<nil>" {
+		s += " " + units
+	}
+	return s
+}
+
+func exportHistory(w io.Writer, format string, points []historyPoint, buckets []historyBucket, numeric bool) error {
+	switch format {
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		if len(buckets) > 0 {
+			for _, b := range buckets {
+				if err := enc.Encode(b); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for _, p := range points {
+			if err := enc.Encode(p); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "csv":
+		cw := csv.NewWriter(w)
+		f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+		switch {
+		case len(buckets) > 0:
+			cw.Write([]string{"start", "count", "min", "avg", "max", "p95"})
+			for _, b := range buckets {
+				cw.Write([]string{strconv.FormatInt(b.Start, 10), strconv.Itoa(b.Count), f(b.Min), f(b.Avg), f(b.Max), f(b.P95)})
+			}
+		case numeric:
+			cw.Write([]string{"clock", "count", "min", "avg", "max"})
+			for _, p := range points {
+				cw.Write([]string{strconv.FormatInt(p.Clock, 10), strconv.Itoa(p.Weight), f(p.Min), f(p.Avg), f(p.Max)})
+			}
+		default:
+			cw.Write([]string{"clock", "value"})
+			for _, p := range points {
+				cw.Write([]string{strconv.FormatInt(p.Clock, 10), p.Text})
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+
+	default:
+		return fmt.Errorf("invalid export format: %s (expected csv or ndjson)", format)
+	}
+}
+
+func exportedCount(points []historyPoint, buckets []historyBucket) int {
+	if len(buckets) > 0 {
+		return len(buckets)
+	}
+	return len(points)
+}
@@ -3,7 +3,12 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
+
+	"zabbix-dna/internal/api"
+	"zabbix-dna/internal/tui"
 
 	"github.com/spf13/cobra"
 )
@@ -19,6 +24,7 @@ func newProblemCmd() *cobra.Command {
 	cmd.AddCommand(newProblemAcknowledgeTriggerCmd())
 	cmd.AddCommand(newProblemShowEventsCmd())
 	cmd.AddCommand(newProblemShowAlarmsCmd())
+	cmd.AddCommand(newProblemTimelineCmd())
 
 	return cmd
 }
@@ -103,6 +109,8 @@ func newProblemShowAlarmsCmd() *cobra.Command {
 	var priority int
 	var hostGroups []string
 	var unacknowledged bool
+	var watch bool
+	var interval time.Duration
 
 	cmd := &cobra.Command{
 		Use:     "alarms",
@@ -157,22 +165,65 @@ func newProblemShowAlarmsCmd() *cobra.Command {
 				params["withLastEventUnacknowledged"] = true
 			}
 
-			result, err := client.Call("trigger.get", params)
-			handleError(err)
+			fetch := func() ([]map[string]interface{}, error) {
+				result, err := client.Call("trigger.get", params)
+				if err != nil {
+					return nil, err
+				}
+				var triggers []map[string]interface{}
+				json.Unmarshal(result, &triggers)
+				return triggers, nil
+			}
 
-			var triggers []map[string]interface{}
-			json.Unmarshal(result, &triggers)
+			if watch {
+				handleError(tui.Watch(tui.WatchConfig{
+					Title:    "Alarms",
+					Headers:  []string{"TriggerID", "Host", "Description", "Priority", "Last Change"},
+					Interval: interval,
+					Fetch: func() ([]tui.WatchRow, error) {
+						triggers, err := fetch()
+						if err != nil {
+							return nil, err
+						}
+						var rows []tui.WatchRow
+						for _, t := range triggers {
+							hostName := triggerHostName(t)
+							sev, _ := strconv.Atoi(fmt.Sprintf("%v", t["priority"]))
+							rows = append(rows, tui.WatchRow{
+								Key: fmt.Sprintf("%v", t["triggerid"]),
+								Cells: []string{
+									fmt.Sprintf("%v", t["triggerid"]),
+									hostName,
+									fmt.Sprintf("%v", t["description"]),
+									getPriorityName(fmt.Sprintf("%v", t["priority"])),
+									formatUnixTime(fmt.Sprintf("%v", t["lastchange"])),
+								},
+								Severity: sev,
+								Host:     hostName,
+							})
+						}
+						return rows, nil
+					},
+					Acknowledge: func(row tui.WatchRow) error {
+						eventID := getEventForTrigger(client, row.Key)
+						if eventID == "" {
+							return fmt.Errorf("no event found for trigger %s", row.Key)
+						}
+						return acknowledgeEvents(client, []string{eventID})
+					},
+				}))
+				return
+			}
+
+			triggers, err := fetch()
+			handleError(err)
 
 			headers := []string{"TriggerID", "Host", "Description", "Priority", "Last Change"}
 			var rows [][]string
 			for _, t := range triggers {
-				hostName := ""
-				if hosts, ok := t["hosts"].([]interface{}); ok && len(hosts) > 0 {
-					hostName = hosts[0].(map[string]interface{})["name"].(string)
-				}
 				rows = append(rows, []string{
 					fmt.Sprintf("%v", t["triggerid"]),
-					hostName,
+					triggerHostName(t),
 					fmt.Sprintf("%v", t["description"]),
 					getPriorityName(t["priority"].(string)),
 					fmt.Sprintf("%v", t["lastchange"]),
@@ -187,10 +238,21 @@ func newProblemShowAlarmsCmd() *cobra.Command {
 	cmd.Flags().IntVar(&priority, "priority", -1, "Filter by priority")
 	cmd.Flags().StringSliceVar(&hostGroups, "hostgroup", []string{}, "Filter by host group name(s)")
 	cmd.Flags().BoolVar(&unacknowledged, "unack", true, "Show only unacknowledged alarms")
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Open a live view that refreshes periodically")
+	cmd.Flags().DurationVar(&interval, "interval", 10*time.Second, "Refresh interval for --watch")
 
 	return cmd
 }
 
+func triggerHostName(t map[string]interface{}) string {
+	if hosts, ok := t["hosts"].([]interface{}); ok && len(hosts) > 0 {
+		if h, ok := hosts[0].(map[string]interface{}); ok {
+			return fmt.Sprintf("%v", h["name"])
+		}
+	}
+	return ""
+}
+
 func newProblemAcknowledgeTriggerCmd() *cobra.Command {
 	var message string
 	var close bool
@@ -229,9 +291,9 @@ func newProblemAcknowledgeTriggerCmd() *cobra.Command {
 				message = "[Zabbix-DNA] Acknowledged via CLI"
 			}
 
-			action := 2 // Acknowledge
+			action := eventActionAcknowledge | eventActionMessage
 			if close {
-				action |= 1 // Close
+				action |= eventActionClose
 			}
 
 			params := map[string]interface{}{
@@ -251,19 +313,49 @@ func newProblemAcknowledgeTriggerCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&message, "message", "m", "", "Acknowledgement message")
-	cmd.Flags().BoolVarP(&close, "close", "c", false, "Close the event(s)")
+	cmd.Flags().BoolVar(&close, "close", false, "Close the event(s)")
 
 	return cmd
 }
 
+// Event update action flags accepted by event.acknowledge
+const (
+	eventActionClose         = 1
+	eventActionAcknowledge   = 2
+	eventActionMessage       = 4
+	eventActionSeverity      = 8
+	eventActionUnacknowledge = 16
+	eventActionSuppress      = 32
+	eventActionUnsuppress    = 64
+	eventActionCause         = 128
+	eventActionSymptom       = 256
+)
+
 func newProblemAcknowledgeCmd() *cobra.Command {
 	var message string
 	var close bool
+	var ack bool
+	var unack bool
+	var setSeverity string
+	var suppressUntil string
+	var unsuppress bool
+	var cause bool
+	var symptomOf string
+	var filter problemFilter
+	var yes bool
 
 	cmd := &cobra.Command{
 		Use:   "acknowledge [eventid]",
-		Short: "Acknowledge one or more events",
-		Args:  cobra.MinimumNArgs(1),
+		Short: "Acknowledge or update one or more events",
+		Long: `Acknowledge or update events by ID or by problem filter.
+
+Without action flags the events are acknowledged. When events are selected with filters
+(--host, --tag, ...) the affected problems are listed and confirmation is requested
+before applying, unless --yes is given.`,
+		Example: `  zabbix-dna problem acknowledge 1234 -m "Investigating"
+  zabbix-dna problem acknowledge 1234 --set-severity high --suppress-until "+2h"
+  zabbix-dna problem acknowledge --host web01 --tag service=nginx --unacknowledged -m "Known issue"
+  zabbix-dna problem acknowledge 1235 1236 --symptom-of 1234`,
 		Run: func(cmd *cobra.Command, args []string) {
 			client, err := getZabbixClient(cmd)
 			handleError(err)
@@ -279,77 +371,236 @@ func newProblemAcknowledgeCmd() *cobra.Command {
 				}
 			}
 
-			if message == "" {
-				message = "[Zabbix-DNA] Acknowledged via CLI"
+			bulk := filter.isSet(cmd)
+			if len(eventIDs) == 0 && !bulk {
+				handleError(fmt.Errorf("specify event IDs or at least one filter (--host, --hostgroup, --tag, ...)"))
 			}
 
-			action := 2 // Acknowledge
+			params := map[string]interface{}{}
+			action := 0
 			if close {
-				action |= 1 // Close
+				action |= eventActionClose
+			}
+			if unack {
+				action |= eventActionUnacknowledge
+			}
+			if setSeverity != "" {
+				sev, err := parseSeverity(setSeverity)
+				handleError(err)
+				action |= eventActionSeverity
+				params["severity"] = sev
+			}
+			if suppressUntil != "" {
+				action |= eventActionSuppress
+				if suppressUntil == "0" || suppressUntil == "indefinitely" {
+					params["suppress_until"] = 0
+				} else {
+					until, err := parseTimeSpec(suppressUntil, time.Now())
+					handleError(err)
+					params["suppress_until"] = until.Unix()
+				}
+			}
+			if unsuppress {
+				action |= eventActionUnsuppress
+			}
+			if cause {
+				action |= eventActionCause
+			}
+			if symptomOf != "" {
+				action |= eventActionSymptom
+				params["cause_eventid"] = symptomOf
+			}
+			// Plain acknowledge unless only other updates were requested
+			if ack || action&^eventActionClose == 0 {
+				action |= eventActionAcknowledge
+			}
+			if action&eventActionAcknowledge != 0 && message == "" {
+				message = "[Zabbix-DNA] Acknowledged via CLI"
+			}
+			if message != "" {
+				action |= eventActionMessage
+				params["message"] = message
 			}
 
-			params := map[string]interface{}{
-				"eventids": eventIDs,
-				"message":  message,
-				"action":   action,
+			switch {
+			case action&eventActionAcknowledge != 0 && action&eventActionUnacknowledge != 0:
+				handleError(fmt.Errorf("--ack and --unacknowledge are mutually exclusive"))
+			case action&eventActionSuppress != 0 && action&eventActionUnsuppress != 0:
+				handleError(fmt.Errorf("--suppress-until and --unsuppress are mutually exclusive"))
+			case action&eventActionCause != 0 && action&eventActionSymptom != 0:
+				handleError(fmt.Errorf("--cause and --symptom-of are mutually exclusive"))
 			}
 
-			result, err := client.Call("event.acknowledge", params)
-			handleError(err)
+			version := getAPIVersion(client)
+			if action&(eventActionCause|eventActionSymptom) != 0 {
+				handleError(requireEventActionVersion(version, 7, 0, "cause and symptom updates"))
+			}
+			if action&(eventActionSuppress|eventActionUnsuppress) != 0 {
+				handleError(requireEventActionVersion(version, 6, 4, "suppress and unsuppress"))
+			}
+			if action&eventActionUnacknowledge != 0 {
+				handleError(requireEventActionVersion(version, 6, 0, "unacknowledge"))
+			}
 
-			var ackResult map[string]interface{}
-			json.Unmarshal(result, &ackResult)
+			if bulk {
+				query := map[string]interface{}{
+					"output":    []string{"eventid", "name", "severity", "clock", "acknowledged"},
+					"sortfield": "eventid",
+					"sortorder": "DESC",
+				}
+				if len(eventIDs) > 0 {
+					query["eventids"] = eventIDs
+				}
+				handleError(filter.apply(cmd, client, query))
 
-			outputResult(cmd, "Event(s) acknowledged successfully.", nil, nil)
+				problems, err := getProblems(client, query)
+				handleError(err)
+				if len(problems) == 0 {
+					handleError(fmt.Errorf("no problems match the given filters"))
+				}
+
+				eventIDs = nil
+				var rows [][]string
+				for _, p := range problems {
+					eventIDs = append(eventIDs, fmt.Sprintf("%v", p["eventid"]))
+					rows = append(rows, []string{
+						fmt.Sprintf("%v", p["eventid"]),
+						fmt.Sprintf("%v", p["host"]),
+						fmt.Sprintf("%v", p["name"]),
+						getPriorityName(fmt.Sprintf("%v", p["severity"])),
+						formatUnixTime(fmt.Sprintf("%v", p["clock"])),
+					})
+				}
+
+				if !yes {
+					outputResult(cmd, problems, []string{"EventID", "Host", "Problem", "Severity", "Started"}, rows)
+					if !confirmAction(fmt.Sprintf("Apply %s to %d event(s)?", strings.Join(eventActionNames(action), ", "), len(eventIDs))) {
+						outputResult(cmd, "Aborted.", nil, nil)
+						return
+					}
+				}
+			}
+
+			params["eventids"] = eventIDs
+			params["action"] = action
+
+			_, err = client.Call("event.acknowledge", params)
+			handleError(err)
+
+			outputResult(cmd, fmt.Sprintf("Updated %d event(s): %s.", len(eventIDs), strings.Join(eventActionNames(action), ", ")), nil, nil)
 		},
 	}
 
 	cmd.Flags().StringVarP(&message, "message", "m", "", "Acknowledgement message")
-	cmd.Flags().BoolVarP(&close, "close", "c", false, "Close the event(s)")
+	cmd.Flags().BoolVar(&close, "close", false, "Close the event(s)")
+	cmd.Flags().BoolVar(&ack, "ack", false, "Acknowledge together with other updates")
+	cmd.Flags().BoolVar(&unack, "unacknowledge", false, "Remove the acknowledgement from the event(s)")
+	cmd.Flags().StringVar(&setSeverity, "set-severity", "", "Change severity (0-5 or name, e.g. high)")
+	cmd.Flags().StringVar(&suppressUntil, "suppress-until", "", "Suppress until a time (e.g. +2h, 2024-05-01 08:00) or 0 for indefinitely")
+	cmd.Flags().BoolVar(&unsuppress, "unsuppress", false, "Remove manual suppression")
+	cmd.Flags().BoolVar(&cause, "cause", false, "Convert symptom event(s) to cause")
+	cmd.Flags().StringVar(&symptomOf, "symptom-of", "", "Convert event(s) to symptoms of this cause event ID")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Do not ask for confirmation")
+	filter.addFlags(cmd)
 
 	return cmd
 }
 
+func eventActionNames(action int) []string {
+	var names []string
+	for _, a := range []struct {
+		flag int
+		name string
+	}{
+		{eventActionClose, "close"},
+		{eventActionAcknowledge, "acknowledge"},
+		{eventActionMessage, "message"},
+		{eventActionSeverity, "change severity"},
+		{eventActionUnacknowledge, "unacknowledge"},
+		{eventActionSuppress, "suppress"},
+		{eventActionUnsuppress, "unsuppress"},
+		{eventActionCause, "change to cause"},
+		{eventActionSymptom, "change to symptom"},
+	} {
+		if action&a.flag != 0 {
+			names = append(names, a.name)
+		}
+	}
+	return names
+}
+
 func newProblemListCmd() *cobra.Command {
 	var limit int
-	var severity int
+	var filter problemFilter
+	var recent bool
+	var watch bool
+	var interval time.Duration
 
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List active Zabbix problems",
+		Example: `  zabbix-dna problem list --hostgroup "Linux servers" --min-severity 3 --unacknowledged
+  zabbix-dna problem list --host web01 --since -24h --tag service=nginx
+  zabbix-dna problem list --since "2024-05-01" --until "2024-05-02" --recent`,
 		Run: func(cmd *cobra.Command, args []string) {
 			client, err := getZabbixClient(cmd)
 			handleError(err)
 
 			params := map[string]interface{}{
-				"output":    []string{"eventid", "name", "severity", "clock", "objectid"},
-				"limit":     limit,
-				"sortfield": "eventid",
-				"sortorder": "DESC",
+				"output":             []string{"eventid", "name", "severity", "clock", "r_clock", "objectid", "acknowledged", "suppressed"},
+				"selectAcknowledges": []string{"clock", "message", "action"},
+				"limit":              limit,
+				"sortfield":          "eventid",
+				"sortorder":          "DESC",
+			}
+			handleError(filter.apply(cmd, client, params))
+			if recent {
+				params["recent"] = true
 			}
 
-			if severity >= 0 {
-				params["severities"] = []int{severity}
+			fetch := func() ([]map[string]interface{}, error) {
+				return getProblems(client, params)
 			}
 
-			result, err := client.Call("problem.get", params)
-			handleError(err)
+			headers := []string{"EventID", "Host", "Problem", "Severity", "Started", "Duration", "Age", "Ack", "Last Ack Message"}
+
+			if watch {
+				handleError(tui.Watch(tui.WatchConfig{
+					Title:    "Problems",
+					Headers:  headers,
+					Interval: interval,
+					Fetch: func() ([]tui.WatchRow, error) {
+						problems, err := fetch()
+						if err != nil {
+							return nil, err
+						}
+						now := time.Now()
+						var rows []tui.WatchRow
+						for _, p := range problems {
+							sev, _ := strconv.Atoi(fmt.Sprintf("%v", p["severity"]))
+							rows = append(rows, tui.WatchRow{
+								Key:      fmt.Sprintf("%v", p["eventid"]),
+								Cells:    problemRow(p, now),
+								Severity: sev,
+								Host:     fmt.Sprintf("%v", p["host"]),
+							})
+						}
+						return rows, nil
+					},
+					Acknowledge: func(row tui.WatchRow) error {
+						return acknowledgeEvents(client, []string{row.Key})
+					},
+				}))
+				return
+			}
 
-			var problems []map[string]interface{}
-			json.Unmarshal(result, &problems)
+			problems, err := fetch()
+			handleError(err)
 
-			headers := []string{"EventID", "Problem", "Severity", "Time"}
+			now := time.Now()
 			var rows [][]string
 			for _, p := range problems {
-				sev := getPriorityName(p["severity"].(string))
-				clockStr := p["clock"].(string)
-
-				rows = append(rows, []string{
-					fmt.Sprintf("%v", p["eventid"]),
-					fmt.Sprintf("%v", p["name"]),
-					sev,
-					clockStr,
-				})
+				rows = append(rows, problemRow(p, now))
 			}
 
 			outputResult(cmd, problems, headers, rows)
@@ -357,7 +608,244 @@ func newProblemListCmd() *cobra.Command {
 	}
 
 	cmd.Flags().IntVarP(&limit, "limit", "l", 100, "Limit the number of problems")
-	cmd.Flags().IntVarP(&severity, "severity", "s", -1, "Filter by severity (0-5)")
+	filter.addFlags(cmd)
+	cmd.Flags().BoolVar(&recent, "recent", false, "Include recently resolved problems")
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Open a live view that refreshes periodically")
+	cmd.Flags().DurationVar(&interval, "interval", 10*time.Second, "Refresh interval for --watch")
 
 	return cmd
 }
+
+// problemFilter holds the problem.get selection flags shared by list and acknowledge.
+type problemFilter struct {
+	severity       int
+	minSeverity    int
+	hosts          []string
+	hostGroups     []string
+	tags           []string
+	since          string
+	until          string
+	acknowledged   bool
+	unacknowledged bool
+	suppressed     bool
+}
+
+var problemFilterFlags = []string{"severity", "min-severity", "host", "hostgroup", "tag", "since", "until", "acknowledged", "unacknowledged", "suppressed"}
+
+func (f *problemFilter) addFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVarP(&f.severity, "severity", "s", -1, "Filter by exact severity (0-5)")
+	cmd.Flags().IntVar(&f.minSeverity, "min-severity", 0, "Filter by this severity or higher (0-5)")
+	cmd.Flags().StringSliceVar(&f.hosts, "host", []string{}, "Filter by host name(s)")
+	cmd.Flags().StringSliceVar(&f.hostGroups, "hostgroup", []string{}, "Filter by host group name(s)")
+	cmd.Flags().StringArrayVar(&f.tags, "tag", []string{}, "Filter by tag (name or name=value, repeatable)")
+	cmd.Flags().StringVar(&f.since, "since", "", "Only problems started after this time (e.g. -24h, today, 2024-05-01 08:00)")
+	cmd.Flags().StringVar(&f.until, "until", "", "Only problems started before this time")
+	cmd.Flags().BoolVar(&f.acknowledged, "acknowledged", false, "Only acknowledged problems")
+	cmd.Flags().BoolVar(&f.unacknowledged, "unacknowledged", false, "Only unacknowledged problems")
+	cmd.Flags().BoolVar(&f.suppressed, "suppressed", false, "Only suppressed problems (--suppressed=false excludes them)")
+}
+
+// isSet reports whether any filter flag was given on the command line.
+func (f *problemFilter) isSet(cmd *cobra.Command) bool {
+	for _, name := range problemFilterFlags {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply adds the selected filters to problem.get params.
+func (f *problemFilter) apply(cmd *cobra.Command, client *api.ZabbixClient, params map[string]interface{}) error {
+	if f.acknowledged && f.unacknowledged {
+		return fmt.Errorf("--acknowledged and --unacknowledged are mutually exclusive")
+	}
+
+	if f.severity >= 0 {
+		params["severities"] = []int{f.severity}
+	} else if f.minSeverity > 0 {
+		var severities []int
+		for s := f.minSeverity; s <= 5; s++ {
+			severities = append(severities, s)
+		}
+		params["severities"] = severities
+	}
+	if len(f.hosts) > 0 {
+		ids := getHostsIDs(client, f.hosts)
+		if len(ids) == 0 {
+			return fmt.Errorf("no hosts found: %s", strings.Join(f.hosts, ", "))
+		}
+		params["hostids"] = ids
+	}
+	if len(f.hostGroups) > 0 {
+		ids := getHostGroupsIDs(client, f.hostGroups)
+		if len(ids) == 0 {
+			return fmt.Errorf("no host groups found: %s", strings.Join(f.hostGroups, ", "))
+		}
+		params["groupids"] = ids
+	}
+	if len(f.tags) > 0 {
+		tagFilters, err := problemTagFilters(f.tags)
+		if err != nil {
+			return err
+		}
+		params["evaltype"] = 0
+		params["tags"] = tagFilters
+	}
+	now := time.Now()
+	if f.since != "" {
+		from, err := parseTimeSpec(f.since, now)
+		if err != nil {
+			return err
+		}
+		params["time_from"] = from.Unix()
+	}
+	if f.until != "" {
+		till, err := parseTimeSpec(f.until, now)
+		if err != nil {
+			return err
+		}
+		params["time_till"] = till.Unix()
+	}
+	if f.acknowledged {
+		params["acknowledged"] = true
+	}
+	if f.unacknowledged {
+		params["acknowledged"] = false
+	}
+	if cmd.Flags().Changed("suppressed") {
+		params["suppressed"] = f.suppressed
+	}
+	return nil
+}
+
+// problemTagFilters converts name=value flags into problem.get/event.get tag filters.
+func problemTagFilters(tags []string) ([]map[string]string, error) {
+	parsed, err := parseTagFlags(tags)
+	if err != nil {
+		return nil, err
+	}
+	var filters []map[string]string
+	for _, t := range parsed {
+		operator := "0" // Contains; an empty value matches any value of the tag
+		if t["value"] != "" {
+			operator = "1" // Equals
+		}
+		filters = append(filters, map[string]string{"tag": t["tag"], "value": t["value"], "operator": operator})
+	}
+	return filters, nil
+}
+
+// getProblems runs problem.get and adds the name of each problem's host under "host".
+func getProblems(client *api.ZabbixClient, params map[string]interface{}) ([]map[string]interface{}, error) {
+	result, err := client.Call("problem.get", params)
+	if err != nil {
+		return nil, err
+	}
+	var problems []map[string]interface{}
+	json.Unmarshal(result, &problems)
+
+	var eventIDs []string
+	for _, p := range problems {
+		eventIDs = append(eventIDs, fmt.Sprintf("%v", p["eventid"]))
+	}
+	eventHosts := getEventHosts(client, eventIDs)
+	for _, p := range problems {
+		p["host"] = eventHosts[fmt.Sprintf("%v", p["eventid"])]
+	}
+	return problems, nil
+}
+
+// problemRow renders a problem.get entry, with its resolved host, as a list row.
+func problemRow(p map[string]interface{}, now time.Time) []string {
+	started, _ := strconv.ParseInt(fmt.Sprintf("%v", p["clock"]), 10, 64)
+	resolved, _ := strconv.ParseInt(fmt.Sprintf("%v", p["r_clock"]), 10, 64)
+
+	end := now
+	if resolved > 0 {
+		end = time.Unix(resolved, 0)
+	}
+	duration := end.Sub(time.Unix(started, 0))
+	age := now.Sub(time.Unix(started, 0))
+
+	ack := "No"
+	if fmt.Sprintf("%v", p["acknowledged"]) == "1" {
+		ack = "Yes"
+	}
+
+	lastMessage := ""
+	if acks, ok := p["acknowledges"].([]interface{}); ok {
+		// Acknowledges are returned newest first
+		for _, a := range acks {
+			if m, ok := a.(map[string]interface{}); ok && fmt.Sprintf("%v", m["message"]) != "" {
+				lastMessage = fmt.Sprintf("%v", m["message"])
+				break
+			}
+		}
+	}
+
+	host := ""
+	if h, ok := p["host"].(string); ok {
+		host = h
+	}
+
+	return []string{
+		fmt.Sprintf("%v", p["eventid"]),
+		host,
+		fmt.Sprintf("%v", p["name"]),
+		getPriorityName(fmt.Sprintf("%v", p["severity"])),
+		formatUnixTime(fmt.Sprintf("%v", p["clock"])),
+		formatDuration(duration),
+		formatDuration(age),
+		ack,
+		lastMessage,
+	}
+}
+
+// getEventHosts maps event IDs to the visible name of their first host; problem.get cannot select hosts.
+func getEventHosts(client *api.ZabbixClient, eventIDs []string) map[string]string {
+	hosts := make(map[string]string)
+	if len(eventIDs) == 0 {
+		return hosts
+	}
+	result, err := client.Call("event.get", map[string]interface{}{
+		"output":      []string{"eventid"},
+		"eventids":    eventIDs,
+		"selectHosts": []string{"hostid", "name"},
+	})
+	if err != nil {
+		return hosts
+	}
+	var events []map[string]interface{}
+	json.Unmarshal(result, &events)
+	for _, e := range events {
+		if list, ok := e["hosts"].([]interface{}); ok && len(list) > 0 {
+			if h, ok := list[0].(map[string]interface{}); ok {
+				hosts[fmt.Sprintf("%v", e["eventid"])] = fmt.Sprintf("%v", h["name"])
+			}
+		}
+	}
+	return hosts
+}
+
+// acknowledgeEvents acknowledges events from the live views with the default message.
+func acknowledgeEvents(client *api.ZabbixClient, eventIDs []string) error {
+	_, err := client.Call("event.acknowledge", map[string]interface{}{
+		"eventids": eventIDs,
+		"message":  "[Zabbix-DNA] Acknowledged via CLI",
+		"action":   eventActionAcknowledge | eventActionMessage,
+	})
+	return err
+}
+
+// requireEventActionVersion reports an error when an event update feature is not supported by
+// the server version, or when the version could not be determined.
+func requireEventActionVersion(version string, major, minor int, feature string) error {
+	if version == "" {
+		return fmt.Errorf("%s: requires Zabbix %d.%d or later, and the server version could not be determined", feature, major, minor)
+	}
+	if !apiVersionAtLeast(version, major, minor) {
+		return fmt.Errorf("%s: requires Zabbix %d.%d or later (server is %s)", feature, major, minor, version)
+	}
+	return nil
+}
@@ -0,0 +1,445 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"zabbix-dna/internal/api"
+
+	"github.com/spf13/cobra"
+)
+
+type timelineEntry struct {
+	Clock   int64  `json:"clock"`
+	Time    string `json:"time"`
+	EventID string `json:"eventid"`
+	Type    string `json:"type"`
+	Actor   string `json:"actor,omitempty"`
+	Detail  string `json:"detail"`
+	Status  string `json:"status,omitempty"`
+}
+
+func newProblemTimelineCmd() *cobra.Command {
+	var since string
+	var until string
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "timeline [eventid|host]",
+		Short: "Build a chronological incident timeline for a problem or host",
+		Long: `Gather everything that happened around a problem into one chronological timeline:
+the problem and recovery events, acknowledgements and their users, alert deliveries,
+remote commands, manual script executions and overlapping maintenance windows.
+
+Given an event ID the timeline covers that problem until its recovery. Given a host
+name it covers every problem of the host between --since and --until.`,
+		Example: `  zabbix-dna problem timeline 4512
+  zabbix-dna problem timeline web01 --since -48h --format markdown > incident.md`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if format != "table" && format != "markdown" && format != "json" {
+				handleError(fmt.Errorf("invalid format: %s (expected table, markdown or json)", format))
+			}
+
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			eventParams := map[string]interface{}{
+				"output":              "extend",
+				"select_acknowledges": "extend",
+				"selectHosts":         []string{"hostid", "host", "name"},
+				"sortfield":           []string{"clock", "eventid"},
+				"sortorder":           "ASC",
+			}
+
+			now := time.Now()
+			var from, till time.Time
+			if _, err := strconv.ParseUint(args[0], 10, 64); err == nil {
+				eventParams["eventids"] = []string{args[0]}
+			} else {
+				hostID := getHostID(client, args[0])
+				if hostID == "" {
+					handleError(fmt.Errorf("host not found: %s", args[0]))
+				}
+				from, err = parseTimeSpec(since, now)
+				handleError(err)
+				till, err = parseTimeSpec(until, now)
+				handleError(err)
+
+				eventParams["hostids"] = []string{hostID}
+				eventParams["source"] = 0 // Triggers
+				eventParams["object"] = 0
+				eventParams["value"] = 1 // Problem events
+				eventParams["time_from"] = from.Unix()
+				eventParams["time_till"] = till.Unix()
+			}
+
+			events, err := callGetList(client, "event.get", eventParams)
+			handleError(err)
+			if len(events) == 0 {
+				handleError(fmt.Errorf("no problem events found for %s", args[0]))
+			}
+
+			entries, err := buildTimeline(cmd, client, events, from, till, now)
+			handleError(err)
+
+			if format == "markdown" {
+				fmt.Print(renderTimelineMarkdown(args[0], entries))
+				return
+			}
+			headers := []string{"Time", "EventID", "Type", "Actor", "Detail", "Status"}
+			var rows [][]string
+			for _, e := range entries {
+				rows = append(rows, []string{e.Time, e.EventID, e.Type, e.Actor, e.Detail, e.Status})
+			}
+			if format == "json" {
+				outputResultAs("json", entries, headers, rows)
+				return
+			}
+			outputResult(cmd, entries, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "-24h", "Start of the range when a host is given")
+	cmd.Flags().StringVar(&until, "until", "now", "End of the range when a host is given")
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, markdown, json)")
+	addServerTZFlag(cmd)
+
+	return cmd
+}
+
+// buildTimeline collects the entries related to the given problem events. A zero from/till
+// is derived from the events themselves.
+func buildTimeline(cmd *cobra.Command, client *api.ZabbixClient, events []map[string]interface{}, from, till, now time.Time) ([]timelineEntry, error) {
+	var entries []timelineEntry
+	var eventIDs []string
+	var recoveryIDs []string
+	hostIDs := make(map[string]bool)
+	first, last := int64(0), int64(0)
+
+	for _, e := range events {
+		eventID := fmt.Sprintf("%v", e["eventid"])
+		clock := parseClock(e["clock"])
+		eventIDs = append(eventIDs, eventID)
+		if first == 0 || clock < first {
+			first = clock
+		}
+		if clock > last {
+			last = clock
+		}
+
+		if hosts, ok := e["hosts"].([]interface{}); ok {
+			for _, h := range hosts {
+				if hm, ok := h.(map[string]interface{}); ok {
+					hostIDs[fmt.Sprintf("%v", hm["hostid"])] = true
+				}
+			}
+		}
+
+		entries = append(entries, timelineEntry{
+			Clock:   clock,
+			EventID: eventID,
+			Type:    "problem",
+			Actor:   eventHostNames(e),
+			Detail:  fmt.Sprintf("%v", e["name"]),
+			Status:  getPriorityName(fmt.Sprintf("%v", e["severity"])),
+		})
+
+		if rID := fmt.Sprintf("%v", e["r_eventid"]); rID != "" && rID != "0" && rID != "<nil>" {
+			recoveryIDs = append(recoveryIDs, rID)
+		} else {
+			// Still open, so the timeline runs until now
+			last = now.Unix()
+		}
+
+		if acks, ok := e["acknowledges"].([]interface{}); ok {
+			for _, a := range acks {
+				ack, ok := a.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				entries = append(entries, timelineEntry{
+					Clock:   parseClock(ack["clock"]),
+					EventID: eventID,
+					Type:    "update",
+					Actor:   timelineUserName(ack),
+					Detail:  describeAcknowledge(ack),
+				})
+			}
+		}
+	}
+
+	if len(recoveryIDs) > 0 {
+		recoveries, err := callGetList(client, "event.get", map[string]interface{}{
+			"output":   []string{"eventid", "clock", "name", "userid"},
+			"eventids": recoveryIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range recoveries {
+			clock := parseClock(r["clock"])
+			if clock > last {
+				last = clock
+			}
+			entries = append(entries, timelineEntry{
+				Clock:   clock,
+				EventID: fmt.Sprintf("%v", r["eventid"]),
+				Type:    "recovery",
+				Detail:  fmt.Sprintf("Resolved: %v", r["name"]),
+			})
+		}
+	}
+
+	if from.IsZero() {
+		from = time.Unix(first, 0)
+	}
+	if till.IsZero() {
+		till = time.Unix(last, 0)
+	}
+
+	alerts, err := callGetList(client, "alert.get", map[string]interface{}{
+		"output":           "extend",
+		"eventids":         append(append([]string{}, eventIDs...), recoveryIDs...),
+		"selectMediatypes": []string{"name"},
+		"selectUsers":      []string{"username"},
+		"sortfield":        "clock",
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range alerts {
+		entry := timelineEntry{
+			Clock:   parseClock(a["clock"]),
+			EventID: fmt.Sprintf("%v", a["eventid"]),
+			Status:  getAlertStatusName(fmt.Sprintf("%v", a["status"])),
+		}
+		if fmt.Sprintf("%v", a["alerttype"]) == "1" {
+			entry.Type = "command"
+			entry.Detail = fmt.Sprintf("%v", a["message"])
+		} else {
+			entry.Type = "alert"
+			mediaType := ""
+			if mts, ok := a["mediatypes"].([]interface{}); ok && len(mts) > 0 {
+				mediaType = fmt.Sprintf("%v", mts[0].(map[string]interface{})["name"])
+			}
+			entry.Detail = fmt.Sprintf("%s to %v: %v", mediaType, a["sendto"], a["subject"])
+			if users, ok := a["users"].([]interface{}); ok && len(users) > 0 {
+				entry.Actor = timelineUserName(users[0].(map[string]interface{}))
+			}
+		}
+		if errMsg := fmt.Sprintf("%v", a["error"]); errMsg != "" && errMsg != "<nil>" {
+			entry.Status += ": " + errMsg
+		}
+		entries = append(entries, entry)
+	}
+
+	var hosts []string
+	for id := range hostIDs {
+		hosts = append(hosts, id)
+	}
+	sort.Strings(hosts)
+
+	if len(hosts) > 0 {
+		maintenances, err := getHostMaintenances(client, hosts)
+		if err != nil {
+			return nil, err
+		}
+		// Recurring windows need the server time zone; only look it up when there are any.
+		recurring := false
+		for _, m := range maintenances {
+			for _, tp := range exportList(m["timeperiods"]) {
+				recurring = recurring || fmt.Sprintf("%v", tp["timeperiod_type"]) != timeperiodOnce
+			}
+		}
+		serverLoc := time.Local
+		if recurring {
+			if serverLoc, err = maintenanceServerLocation(cmd, client); err != nil {
+				return nil, err
+			}
+		}
+		for _, m := range maintenances {
+			kind := "with data collection"
+			if fmt.Sprintf("%v", m["maintenance_type"]) == "1" {
+				kind = "no data collection"
+			}
+			for _, occ := range expandMaintenance(m, from, till, serverLoc) {
+				entries = append(entries,
+					timelineEntry{Clock: occ.Start.Unix(), Type: "maintenance", Detail: fmt.Sprintf("Maintenance %q starts (%s)", m["name"], kind)},
+					timelineEntry{Clock: occ.End.Unix(), Type: "maintenance", Detail: fmt.Sprintf("Maintenance %q ends", m["name"])},
+				)
+			}
+		}
+
+		// Manual script executions are only recorded in the audit log (Zabbix 5.4+)
+		audit, err := callGetList(client, "auditlog.get", map[string]interface{}{
+			"output":    "extend",
+			"filter":    map[string]interface{}{"action": 7, "resourcetype": 25},
+			"time_from": from.Unix(),
+			"time_till": till.Unix(),
+		})
+		if err == nil {
+			for _, a := range audit {
+				if !auditScriptOnHosts(a, hostIDs) {
+					continue
+				}
+				entries = append(entries, timelineEntry{
+					Clock:  parseClock(a["clock"]),
+					Type:   "script",
+					Actor:  fmt.Sprintf("%v", a["username"]),
+					Detail: fmt.Sprintf("Executed script %v", a["resourcename"]),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Clock < entries[j].Clock })
+	for i := range entries {
+		entries[i].Time = time.Unix(entries[i].Clock, 0).Format("2006-01-02 15:04:05")
+	}
+	return entries, nil
+}
+
+func describeAcknowledge(ack map[string]interface{}) string {
+	action, _ := strconv.Atoi(fmt.Sprintf("%v", ack["action"]))
+	var parts []string
+	for _, name := range eventActionNames(action) {
+		if name == "message" {
+			continue
+		}
+		if name == "change severity" {
+			name = fmt.Sprintf("change severity %s -> %s",
+				getPriorityName(fmt.Sprintf("%v", ack["old_severity"])),
+				getPriorityName(fmt.Sprintf("%v", ack["new_severity"])))
+		}
+		parts = append(parts, name)
+	}
+	detail := strings.Join(parts, ", ")
+	if msg := fmt.Sprintf("%v", ack["message"]); msg != "" && msg != "<nil>" {
+		if detail != "" {
+			detail += ": "
+		}
+		detail += msg
+	}
+	return detail
+}
+
+func getAlertStatusName(status string) string {
+	switch status {
+	case "0":
+		return "Not sent"
+	case "1":
+		return "Sent"
+	case "2":
+		return "Failed"
+	case "3":
+		return "New"
+	default:
+		return "Unknown"
+	}
+}
+
+func timelineUserName(u map[string]interface{}) string {
+	for _, key := range []string{"username", "alias"} {
+		if v, ok := u[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func eventHostNames(e map[string]interface{}) string {
+	var names []string
+	if hosts, ok := e["hosts"].([]interface{}); ok {
+		for _, h := range hosts {
+			if hm, ok := h.(map[string]interface{}); ok {
+				names = append(names, fmt.Sprintf("%v", hm["host"]))
+			}
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// auditScriptOnHosts reports whether a script execution audit record targeted one of the hosts.
+func auditScriptOnHosts(record map[string]interface{}, hostIDs map[string]bool) bool {
+	var details map[string][]interface{}
+	if err := json.Unmarshal([]byte(fmt.Sprintf("%v", record["details"])), &details); err != nil {
+		return false
+	}
+	values := details["script.hostid"]
+	if len(values) == 0 {
+		return false
+	}
+	return hostIDs[fmt.Sprintf("%v", values[len(values)-1])]
+}
+
+func parseClock(v interface{}) int64 {
+	clock, _ := strconv.ParseInt(fmt.Sprintf("%v", v), 10, 64)
+	return clock
+}
+
+// getHostMaintenances returns the maintenances, with their time periods, covering the hosts directly or through one of
+// their host groups.
+func getHostMaintenances(client *api.ZabbixClient, hostIDs []string) ([]map[string]interface{}, error) {
+	groupsParam, groupsKey := "selectGroups", "groups"
+	if apiVersionAtLeast(getAPIVersion(client), 6, 2) {
+		groupsParam, groupsKey = "selectHostGroups", "hostgroups"
+	}
+	hosts, err := callGetList(client, "host.get", map[string]interface{}{
+		"output":    []string{"hostid"},
+		"hostids":   hostIDs,
+		groupsParam: []string{"groupid"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[string]bool)
+	for _, h := range hosts {
+		for _, g := range exportList(h[groupsKey]) {
+			groups[fmt.Sprintf("%v", g["groupid"])] = true
+		}
+	}
+
+	output := []string{"maintenanceid", "name", "active_since", "active_till", "maintenance_type"}
+	queries := []map[string]interface{}{{"output": output, "selectTimeperiods": "extend", "hostids": hostIDs}}
+	if len(groups) > 0 {
+		queries = append(queries, map[string]interface{}{"output": output, "selectTimeperiods": "extend", "groupids": sortedKeys(groups)})
+	}
+
+	var maintenances []map[string]interface{}
+	seen := make(map[string]bool)
+	for _, q := range queries {
+		list, err := callGetList(client, "maintenance.get", q)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range list {
+			id := fmt.Sprintf("%v", m["maintenanceid"])
+			if !seen[id] {
+				seen[id] = true
+				maintenances = append(maintenances, m)
+			}
+		}
+	}
+	return maintenances, nil
+}
+
+func renderTimelineMarkdown(subject string, entries []timelineEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Incident timeline: %s\n\n", subject)
+	if len(entries) > 0 {
+		fmt.Fprintf(&b, "From %s to %s.\n\n", entries[0].Time, entries[len(entries)-1].Time)
+	}
+	b.WriteString("| Time | Event | Type | Actor | Detail | Status |\n")
+	b.WriteString("|------|-------|------|-------|--------|--------|\n")
+	escape := strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>")
+	for _, e := range entries {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+			e.Time, e.EventID, e.Type, escape.Replace(e.Actor), escape.Replace(e.Detail), escape.Replace(e.Status))
+	}
+	return b.String()
+}
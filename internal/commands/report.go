@@ -0,0 +1,927 @@
+package commands
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"zabbix-dna/internal/api"
+
+	"github.com/spf13/cobra"
+)
+
+// problemInterval is one trigger problem with the time it was resolved. End is zero while
+// the problem is still open.
+type problemInterval struct {
+	EventID   string
+	TriggerID string
+	Name      string
+	Severity  int
+	HostID    string
+	Host      string
+	Start     int64
+	End       int64
+	ClosedBy  string
+}
+
+type slaRow struct {
+	Name        string  `json:"name"`
+	SLO         string  `json:"slo,omitempty"`
+	Uptime      float64 `json:"uptime_percent"`
+	DowntimeMin float64 `json:"downtime_minutes"`
+	Incidents   int     `json:"incidents"`
+	MTTRMin     float64 `json:"mttr_minutes"`
+	MTBFMin     float64 `json:"mtbf_minutes"`
+}
+
+func newReportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Availability and operational reports",
+	}
+
+	cmd.AddCommand(newReportSLACmd())
+	cmd.AddCommand(newReportNoiseCmd())
+
+	return cmd
+}
+
+func newReportSLACmd() *cobra.Command {
+	var from string
+	var to string
+	var month string
+	var slaNames []string
+	var hostGroups []string
+	var tags []string
+	var minSeverity int
+	var source string
+	var format string
+	var outFile string
+
+	cmd := &cobra.Command{
+		Use:   "sla",
+		Short: "Report availability per SLA service or per host",
+		Long: `Report availability over a period.
+
+With --sla the report uses the SLA objects of Zabbix 6.0+ (sla.get / sla.getsli) and
+shows one row per service. Otherwise availability is computed from trigger events:
+every problem with --min-severity or higher on a host counts as downtime, overlapping
+problems are merged, and one row per host is produced with uptime, downtime, number of
+incidents, MTTR and MTBF.`,
+		Example: `  zabbix-dna report sla --hostgroup "Customer A" --month 2024-05 -f html -O may.html
+  zabbix-dna report sla --sla "Web shop" --from -30d -f csv
+  zabbix-dna report sla --hostgroup Databases --tag service=mysql --min-severity 3`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !containsString([]string{"table", "csv", "json", "html"}, format) {
+				handleError(fmt.Errorf("invalid format: %s (expected table, csv, json or html)", format))
+			}
+
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			now := time.Now()
+			start, end, err := reportPeriod(from, to, month, now)
+			handleError(err)
+			if end.After(now) {
+				// Open problems must not count downtime that has not happened yet
+				end = now
+			}
+
+			if source == "auto" {
+				source = "events"
+				if len(slaNames) > 0 {
+					source = "sla"
+				}
+			}
+
+			var rows []slaRow
+			var title string
+			switch source {
+			case "sla":
+				if len(slaNames) == 0 {
+					handleError(fmt.Errorf("--sla is required with --source sla"))
+				}
+				if version := getAPIVersion(client); !apiVersionAtLeast(version, 6, 0) {
+					handleError(fmt.Errorf("SLA objects require Zabbix 6.0 or later (server is %s); use --hostgroup for event-based availability", version))
+				}
+				rows, err = slaReportFromSLI(client, slaNames, start, end)
+				handleError(err)
+				title = "SLA: " + strings.Join(slaNames, ", ")
+			case "events":
+				if len(hostGroups) == 0 {
+					handleError(fmt.Errorf("--hostgroup is required for event-based availability"))
+				}
+				rows, err = slaReportFromEvents(client, hostGroups, tags, minSeverity, start, end)
+				handleError(err)
+				title = "Availability: " + strings.Join(hostGroups, ", ")
+			default:
+				handleError(fmt.Errorf("invalid source: %s (expected auto, sla or events)", source))
+			}
+
+			if format == "table" {
+				headers := []string{"Name", "Uptime %", "Downtime", "Incidents", "MTTR", "MTBF"}
+				if source == "sla" {
+					headers = []string{"Service", "SLO %", "SLI %", "Downtime"}
+				}
+				var table [][]string
+				for _, r := range rows {
+					if source == "sla" {
+						table = append(table, []string{r.Name, r.SLO, fmt.Sprintf("%.4f", r.Uptime), formatMinutes(r.DowntimeMin)})
+						continue
+					}
+					table = append(table, []string{
+						r.Name,
+						fmt.Sprintf("%.4f", r.Uptime),
+						formatMinutes(r.DowntimeMin),
+						strconv.Itoa(r.Incidents),
+						formatMinutes(r.MTTRMin),
+						formatMinutes(r.MTBFMin),
+					})
+				}
+				outputResult(cmd, rows, headers, table)
+				return
+			}
+
+			w := io.Writer(os.Stdout)
+			if outFile != "" && outFile != "-" {
+				f, err := os.Create(outFile)
+				handleError(err)
+				defer f.Close()
+				w = f
+			}
+			handleError(writeSLAReport(w, format, title, start, end, rows))
+			if outFile != "" && outFile != "-" {
+				fmt.Fprintf(os.Stderr, "Report written to %s\n", outFile)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "-30d", "Start of the report period")
+	cmd.Flags().StringVar(&to, "to", "now", "End of the report period")
+	cmd.Flags().StringVar(&month, "month", "", "Report a calendar month (YYYY-MM, or \"last\" for the previous month)")
+	cmd.Flags().StringSliceVar(&slaNames, "sla", []string{}, "SLA name(s) to report with sla.getsli")
+	cmd.Flags().StringSliceVar(&hostGroups, "hostgroup", []string{}, "Host group(s) for event-based availability")
+	cmd.Flags().StringArrayVar(&tags, "tag", []string{}, "Only count problems with this tag (name or name=value)")
+	cmd.Flags().IntVar(&minSeverity, "min-severity", 4, "Lowest problem severity counted as downtime (0-5)")
+	cmd.Flags().StringVar(&source, "source", "auto", "Data source (auto, sla, events)")
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, csv, json, html)")
+	cmd.Flags().StringVarP(&outFile, "output-file", "O", "-", "File for csv, json or html output (default: stdout)")
+
+	return cmd
+}
+
+type noiseStat struct {
+	Name         string  `json:"name"`
+	Host         string  `json:"host,omitempty"`
+	Template     string  `json:"template,omitempty"`
+	Events       int     `json:"events"`
+	Resolved     int     `json:"resolved"`
+	AutoResolved int     `json:"auto_resolved"`
+	FlapRate     float64 `json:"flap_rate_per_hour"`
+	MeanDuration float64 `json:"mean_duration_seconds"`
+	AutoPercent  float64 `json:"auto_resolved_percent"`
+	Suggestion   string  `json:"suggestion,omitempty"`
+
+	totalDuration int64
+}
+
+func newReportNoiseCmd() *cobra.Command {
+	var period string
+	var top int
+	var by string
+	var sortBy string
+	var hostGroups []string
+	var hosts []string
+	var minSeverity int
+	var autoWindow time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "noise",
+		Short: "Rank noisy and flapping triggers",
+		Long: `Rank triggers, hosts or templates by how much alert noise they produce.
+
+Problem events of the period are read from event.get in pages and aggregated. For each
+group the report shows the number of problem events, the flap rate (problem to OK
+transitions per hour), the mean problem duration and the share of problems that resolved
+on their own within --auto-resolve. Groups that flap or mostly self-resolve are flagged
+as candidates for hysteresis or severity tuning.`,
+		Example: `  zabbix-dna report noise --period 30d
+  zabbix-dna report noise --period 7d --by template --sort flap --top 10
+  zabbix-dna report noise --hostgroup "Linux servers" --auto-resolve 2m`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !containsString([]string{"trigger", "host", "template"}, by) {
+				handleError(fmt.Errorf("invalid --by: %s (expected trigger, host or template)", by))
+			}
+			if !containsString([]string{"count", "flap", "duration", "auto"}, sortBy) {
+				handleError(fmt.Errorf("invalid --sort: %s (expected count, flap, duration or auto)", sortBy))
+			}
+
+			length, err := parseDurationSpec(period)
+			handleError(err)
+
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			filter := map[string]interface{}{}
+			if minSeverity > 0 {
+				var severities []int
+				for s := minSeverity; s <= 5; s++ {
+					severities = append(severities, s)
+				}
+				filter["severities"] = severities
+			}
+			if len(hostGroups) > 0 {
+				ids := getHostGroupsIDs(client, hostGroups)
+				if len(ids) == 0 {
+					handleError(fmt.Errorf("no host groups found: %s", strings.Join(hostGroups, ", ")))
+				}
+				filter["groupids"] = ids
+			}
+			if len(hosts) > 0 {
+				ids := getHostsIDs(client, hosts)
+				if len(ids) == 0 {
+					handleError(fmt.Errorf("no hosts found: %s", strings.Join(hosts, ", ")))
+				}
+				filter["hostids"] = ids
+			}
+
+			end := time.Now()
+			start := end.Add(-length)
+			problems, err := getProblemIntervals(client, filter, start, end)
+			handleError(err)
+
+			templates, err := triggerTemplateNames(client, problems)
+			handleError(err)
+
+			stats := aggregateNoise(problems, by, templates, autoWindow, length)
+			sortNoise(stats, sortBy)
+			if top > 0 && len(stats) > top {
+				stats = stats[:top]
+			}
+
+			headers := []string{"Trigger", "Host", "Template", "Events", "Flaps/h", "Mean Duration", "Auto-resolved", "Suggestion"}
+			switch by {
+			case "host":
+				headers[0] = "Host"
+			case "template":
+				headers[0] = "Template"
+			}
+			var rows [][]string
+			for _, st := range stats {
+				row := []string{
+					st.Name,
+					st.Host,
+					st.Template,
+					strconv.Itoa(st.Events),
+					fmt.Sprintf("%.2f", st.FlapRate),
+					formatDuration(time.Duration(st.MeanDuration) * time.Second),
+					fmt.Sprintf("%.0f%%", st.AutoPercent),
+					st.Suggestion,
+				}
+				if st.Resolved == 0 {
+					row[5] = "-"
+				}
+				if by != "trigger" {
+					// Host and template only describe single triggers
+					row = append(row[:1], row[3:]...)
+				}
+				rows = append(rows, row)
+			}
+			if by != "trigger" {
+				headers = append(headers[:1], headers[3:]...)
+			}
+
+			outputResult(cmd, stats, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringVar(&period, "period", "30d", "Period to analyze, counted back from now (e.g. 7d, 30d)")
+	cmd.Flags().IntVarP(&top, "top", "n", 20, "Show only the N noisiest entries (0 for all)")
+	cmd.Flags().StringVar(&by, "by", "trigger", "Aggregate by trigger, host or template")
+	cmd.Flags().StringVar(&sortBy, "sort", "count", "Rank by count, flap, duration or auto")
+	cmd.Flags().StringSliceVar(&hostGroups, "hostgroup", []string{}, "Filter by host group name(s)")
+	cmd.Flags().StringSliceVar(&hosts, "host", []string{}, "Filter by host name(s)")
+	cmd.Flags().IntVar(&minSeverity, "min-severity", 0, "Lowest severity to include (0-5)")
+	cmd.Flags().DurationVar(&autoWindow, "auto-resolve", 5*time.Minute, "Problems resolved on their own within this time count as auto-resolved")
+
+	return cmd
+}
+
+// triggerTemplateNames maps trigger IDs to the name of the template their trigger was inherited from.
+func triggerTemplateNames(client *api.ZabbixClient, problems []problemInterval) (map[string]string, error) {
+	names := make(map[string]string)
+	seen := make(map[string]bool)
+	var triggerIDs []string
+	for _, p := range problems {
+		if !seen[p.TriggerID] {
+			seen[p.TriggerID] = true
+			triggerIDs = append(triggerIDs, p.TriggerID)
+		}
+	}
+	if len(triggerIDs) == 0 {
+		return names, nil
+	}
+
+	triggers, err := callGetList(client, "trigger.get", map[string]interface{}{
+		"output":     []string{"triggerid", "templateid"},
+		"triggerids": triggerIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	parents := make(map[string]string)
+	var parentIDs []string
+	for _, t := range triggers {
+		if parent := fmt.Sprintf("%v", t["templateid"]); parent != "0" && parent != "" {
+			parents[fmt.Sprintf("%v", t["triggerid"])] = parent
+			parentIDs = append(parentIDs, parent)
+		}
+	}
+	if len(parentIDs) == 0 {
+		return names, nil
+	}
+
+	templateTriggers, err := callGetList(client, "trigger.get", map[string]interface{}{
+		"output":      []string{"triggerid"},
+		"triggerids":  parentIDs,
+		"selectHosts": []string{"host"},
+		"templated":   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	parentNames := make(map[string]string)
+	for _, t := range templateTriggers {
+		if hosts, ok := t["hosts"].([]interface{}); ok && len(hosts) > 0 {
+			if h, ok := hosts[0].(map[string]interface{}); ok {
+				parentNames[fmt.Sprintf("%v", t["triggerid"])] = fmt.Sprintf("%v", h["host"])
+			}
+		}
+	}
+	for triggerID, parent := range parents {
+		names[triggerID] = parentNames[parent]
+	}
+	return names, nil
+}
+
+func aggregateNoise(problems []problemInterval, by string, templates map[string]string, autoWindow, period time.Duration) []*noiseStat {
+	groups := make(map[string]*noiseStat)
+	var order []string
+	for _, p := range problems {
+		template := templates[p.TriggerID]
+		key, name := p.TriggerID, p.Name
+		switch by {
+		case "host":
+			key, name = p.HostID, p.Host
+		case "template":
+			key, name = template, template
+			if key == "" {
+				key, name = "", "(no template)"
+			}
+		}
+
+		st, ok := groups[key]
+		if !ok {
+			st = &noiseStat{Name: name, Host: p.Host, Template: template}
+			groups[key] = st
+			order = append(order, key)
+		}
+		st.Events++
+		if p.End > 0 {
+			st.Resolved++
+			st.totalDuration += p.End - p.Start
+			if p.ClosedBy == "" && time.Duration(p.End-p.Start)*time.Second <= autoWindow {
+				st.AutoResolved++
+			}
+		}
+	}
+
+	hours := period.Hours()
+	var stats []*noiseStat
+	for _, key := range order {
+		st := groups[key]
+		st.FlapRate = float64(st.Resolved) / hours
+		if st.Resolved > 0 {
+			st.MeanDuration = float64(st.totalDuration) / float64(st.Resolved)
+		}
+		st.AutoPercent = float64(st.AutoResolved) / float64(st.Events) * 100
+		st.Suggestion = noiseSuggestion(st)
+		stats = append(stats, st)
+	}
+	return stats
+}
+
+// noiseSuggestion flags entries that look like tuning candidates.
+func noiseSuggestion(st *noiseStat) string {
+	switch {
+	case st.Events < 5:
+		return ""
+	case st.AutoPercent >= 50 && st.FlapRate >= 0.5:
+		return "flapping: add hysteresis (recovery expression) or evaluate over a window"
+	case st.AutoPercent >= 50:
+		return "mostly self-resolving: lengthen the evaluation window or lower severity"
+	case st.FlapRate >= 1:
+		return "frequent: review threshold"
+	default:
+		return ""
+	}
+}
+
+func sortNoise(stats []*noiseStat, by string) {
+	sort.SliceStable(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		switch by {
+		case "flap":
+			return a.FlapRate > b.FlapRate
+		case "duration":
+			return a.MeanDuration > b.MeanDuration
+		case "auto":
+			if a.AutoPercent != b.AutoPercent {
+				return a.AutoPercent > b.AutoPercent
+			}
+		}
+		return a.Events > b.Events
+	})
+}
+
+// reportPeriod resolves --from/--to, or --month when given, into a time range.
+func reportPeriod(from, to, month string, now time.Time) (time.Time, time.Time, error) {
+	if month != "" {
+		var start time.Time
+		if month == "last" {
+			first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+			start = first.AddDate(0, -1, 0)
+		} else {
+			t, err := time.ParseInLocation("2006-01", month, now.Location())
+			if err != nil {
+				return time.Time{}, time.Time{}, fmt.Errorf("invalid month: %s (expected YYYY-MM)", month)
+			}
+			start = t
+		}
+		return start, start.AddDate(0, 1, 0), nil
+	}
+
+	start, err := parseTimeSpec(from, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := parseTimeSpec(to, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("--to must be after --from")
+	}
+	return start, end, nil
+}
+
+func slaReportFromSLI(client *api.ZabbixClient, names []string, start, end time.Time) ([]slaRow, error) {
+	slas, err := callGetList(client, "sla.get", map[string]interface{}{
+		"output": []string{"slaid", "name", "slo"},
+		"filter": map[string]interface{}{"name": names},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(slas) == 0 {
+		return nil, fmt.Errorf("no SLAs found: %s", strings.Join(names, ", "))
+	}
+
+	var rows []slaRow
+	for _, sla := range slas {
+		result, err := client.Call("sla.getsli", map[string]interface{}{
+			"slaid":       sla["slaid"],
+			"period_from": start.Unix(),
+			"period_to":   end.Unix(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		var sli struct {
+			ServiceIDs []json.Number `json:"serviceids"`
+			SLI        [][]struct {
+				Uptime   int64 `json:"uptime"`
+				Downtime int64 `json:"downtime"`
+			} `json:"sli"`
+		}
+		if err := json.Unmarshal(result, &sli); err != nil {
+			return nil, fmt.Errorf("failed to parse sla.getsli result: %w", err)
+		}
+
+		var serviceIDs []string
+		for _, id := range sli.ServiceIDs {
+			serviceIDs = append(serviceIDs, id.String())
+		}
+		serviceNames := make(map[string]string)
+		if len(serviceIDs) > 0 {
+			services, err := callGetList(client, "service.get", map[string]interface{}{
+				"output":     []string{"serviceid", "name"},
+				"serviceids": serviceIDs,
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range services {
+				serviceNames[fmt.Sprintf("%v", s["serviceid"])] = fmt.Sprintf("%v", s["name"])
+			}
+		}
+
+		// sli is indexed by [period][service]; sum every period of the range
+		for i, id := range serviceIDs {
+			var uptime, downtime int64
+			for _, period := range sli.SLI {
+				if i < len(period) {
+					uptime += period[i].Uptime
+					downtime += period[i].Downtime
+				}
+			}
+			row := slaRow{
+				Name:        fmt.Sprintf("%v / %s", sla["name"], serviceNames[id]),
+				SLO:         fmt.Sprintf("%v", sla["slo"]),
+				Uptime:      100,
+				DowntimeMin: float64(downtime) / 60,
+			}
+			if total := uptime + downtime; total > 0 {
+				row.Uptime = float64(uptime) / float64(total) * 100
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func slaReportFromEvents(client *api.ZabbixClient, hostGroups, tags []string, minSeverity int, start, end time.Time) ([]slaRow, error) {
+	groupIDs := getHostGroupsIDs(client, hostGroups)
+	if len(groupIDs) == 0 {
+		return nil, fmt.Errorf("no host groups found: %s", strings.Join(hostGroups, ", "))
+	}
+	hosts, err := callGetList(client, "host.get", map[string]interface{}{
+		"output":   []string{"hostid", "host"},
+		"groupids": groupIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no hosts in host groups: %s", strings.Join(hostGroups, ", "))
+	}
+
+	var hostIDs []string
+	for _, h := range hosts {
+		hostIDs = append(hostIDs, fmt.Sprintf("%v", h["hostid"]))
+	}
+
+	params := map[string]interface{}{"hostids": hostIDs}
+	var severities []int
+	for s := minSeverity; s <= 5; s++ {
+		severities = append(severities, s)
+	}
+	params["severities"] = severities
+	if len(tags) > 0 {
+		tagFilters, err := problemTagFilters(tags)
+		if err != nil {
+			return nil, err
+		}
+		params["tags"] = tagFilters
+	}
+
+	// Problems that started up to one period before the range still count while open in it
+	lookback := start.Add(-end.Sub(start))
+	intervals, err := getProblemIntervals(client, params, lookback, end)
+	if err != nil {
+		return nil, err
+	}
+	// Older problems still open at the start of the range are not in that event range
+	older, err := getOlderProblemIntervals(client, params, lookback, start, end)
+	if err != nil {
+		return nil, err
+	}
+	intervals = append(intervals, older...)
+
+	byHost := make(map[string][]problemInterval)
+	for _, p := range intervals {
+		byHost[p.HostID] = append(byHost[p.HostID], p)
+	}
+
+	total := end.Sub(start).Seconds()
+	var rows []slaRow
+	for _, h := range hosts {
+		hostID := fmt.Sprintf("%v", h["hostid"])
+		outages := mergeOutages(byHost[hostID], start.Unix(), end.Unix())
+
+		var downtime float64
+		for _, o := range outages {
+			downtime += float64(o[1] - o[0])
+		}
+		row := slaRow{
+			Name:        fmt.Sprintf("%v", h["host"]),
+			Uptime:      (total - downtime) / total * 100,
+			DowntimeMin: downtime / 60,
+			Incidents:   len(outages),
+		}
+		if row.Incidents > 0 {
+			row.MTTRMin = downtime / float64(row.Incidents) / 60
+			row.MTBFMin = (total - downtime) / float64(row.Incidents) / 60
+		}
+		rows = append(rows, row)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Uptime < rows[j].Uptime })
+	return rows, nil
+}
+
+// eventPageSize is the number of events requested per event.get call when walking long periods.
+const eventPageSize = 5000
+
+// getProblemIntervals returns the trigger problems that started in [from, till], walking
+// event.get in pages of eventPageSize ordered by event ID.
+func getProblemIntervals(client *api.ZabbixClient, filter map[string]interface{}, from, till time.Time) ([]problemInterval, error) {
+	params := map[string]interface{}{
+		"output":      []string{"eventid", "objectid", "name", "severity", "clock", "r_eventid"},
+		"selectHosts": []string{"hostid", "host"},
+		"source":      0,
+		"object":      0,
+		"value":       1,
+		"time_from":   from.Unix(),
+		"time_till":   till.Unix(),
+		"sortfield":   "eventid",
+		"sortorder":   "ASC",
+		"limit":       eventPageSize,
+	}
+	for k, v := range filter {
+		params[k] = v
+	}
+
+	var intervals []problemInterval
+	for {
+		events, err := callGetList(client, "event.get", params)
+		if err != nil {
+			return nil, err
+		}
+
+		var recoveryIDs []string
+		for _, e := range events {
+			if rID := fmt.Sprintf("%v", e["r_eventid"]); rID != "0" && rID != "" {
+				recoveryIDs = append(recoveryIDs, rID)
+			}
+		}
+		recoveries := make(map[string]map[string]interface{})
+		if len(recoveryIDs) > 0 {
+			list, err := callGetList(client, "event.get", map[string]interface{}{
+				"output":   []string{"eventid", "clock", "userid"},
+				"eventids": recoveryIDs,
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range list {
+				recoveries[fmt.Sprintf("%v", r["eventid"])] = r
+			}
+		}
+
+		for _, e := range events {
+			p := problemInterval{
+				EventID:   fmt.Sprintf("%v", e["eventid"]),
+				TriggerID: fmt.Sprintf("%v", e["objectid"]),
+				Name:      fmt.Sprintf("%v", e["name"]),
+				Start:     parseClock(e["clock"]),
+			}
+			p.Severity, _ = strconv.Atoi(fmt.Sprintf("%v", e["severity"]))
+			if r, ok := recoveries[fmt.Sprintf("%v", e["r_eventid"])]; ok {
+				p.End = parseClock(r["clock"])
+				if userID := fmt.Sprintf("%v", r["userid"]); userID != "0" && userID != "<nil>" {
+					p.ClosedBy = userID
+				}
+			}
+			if hosts, ok := e["hosts"].([]interface{}); ok && len(hosts) > 0 {
+				if h, ok := hosts[0].(map[string]interface{}); ok {
+					p.HostID = fmt.Sprintf("%v", h["hostid"])
+					p.Host = fmt.Sprintf("%v", h["host"])
+				}
+			}
+			intervals = append(intervals, p)
+		}
+
+		if len(events) < eventPageSize {
+			break
+		}
+		lastID, err := strconv.ParseInt(fmt.Sprintf("%v", events[len(events)-1]["eventid"]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected event ID: %v", events[len(events)-1]["eventid"])
+		}
+		params["eventid_from"] = strconv.FormatInt(lastID+1, 10)
+	}
+	return intervals, nil
+}
+
+// getOlderProblemIntervals returns the trigger problems that started before lookback and were
+// still open at start: those whose recovery event falls in [start, end], found through the
+// recovery events of the range, and those not resolved at all, which problem.get keeps.
+func getOlderProblemIntervals(client *api.ZabbixClient, filter map[string]interface{}, lookback, start, end time.Time) ([]problemInterval, error) {
+	// Recovery events have no severity, so only the host filter applies to them.
+	recoveryParams := map[string]interface{}{
+		"output":    []string{"eventid", "objectid"},
+		"source":    0,
+		"object":    0,
+		"value":     0,
+		"time_from": start.Unix(),
+		"time_till": end.Unix(),
+	}
+	if hostIDs, ok := filter["hostids"]; ok {
+		recoveryParams["hostids"] = hostIDs
+	}
+	recoveries, err := callGetList(client, "event.get", recoveryParams)
+	if err != nil {
+		return nil, err
+	}
+	triggers := make(map[string]bool)
+	for _, r := range recoveries {
+		triggers[fmt.Sprintf("%v", r["objectid"])] = true
+	}
+
+	var candidates []problemInterval
+	if len(triggers) > 0 {
+		params := map[string]interface{}{"objectids": sortedKeys(triggers)}
+		for k, v := range filter {
+			params[k] = v
+		}
+		resolved, err := getProblemIntervals(client, params, time.Unix(0, 0), lookback.Add(-time.Second))
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, resolved...)
+	}
+
+	params := map[string]interface{}{
+		"output":    []string{"eventid"},
+		"source":    0,
+		"object":    0,
+		"time_till": lookback.Unix() - 1,
+	}
+	for k, v := range filter {
+		params[k] = v
+	}
+	problems, err := callGetList(client, "problem.get", params)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		var eventIDs []string
+		for _, p := range problems {
+			eventIDs = append(eventIDs, fmt.Sprintf("%v", p["eventid"]))
+		}
+		unresolved, err := getProblemIntervals(client, map[string]interface{}{"eventids": eventIDs}, time.Unix(0, 0), lookback.Add(-time.Second))
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, unresolved...)
+	}
+
+	var intervals []problemInterval
+	seen := make(map[string]bool)
+	for _, p := range candidates {
+		if seen[p.EventID] || (p.End != 0 && p.End < start.Unix()) {
+			continue
+		}
+		seen[p.EventID] = true
+		intervals = append(intervals, p)
+	}
+	return intervals, nil
+}
+
+// mergeOutages clips problem intervals to [start, end) and merges overlapping ones, so
+// concurrent problems on the same host count as a single outage.
+func mergeOutages(problems []problemInterval, start, end int64) [][2]int64 {
+	var spans [][2]int64
+	for _, p := range problems {
+		s, e := p.Start, p.End
+		if e == 0 || e > end {
+			e = end
+		}
+		if s < start {
+			s = start
+		}
+		if e > s {
+			spans = append(spans, [2]int64{s, e})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+	var merged [][2]int64
+	for _, s := range spans {
+		if n := len(merged); n > 0 && s[0] <= merged[n-1][1] {
+			if s[1] > merged[n-1][1] {
+				merged[n-1][1] = s[1]
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+func formatMinutes(m float64) string {
+	if m == 0 {
+		return "-"
+	}
+	return formatDuration(time.Duration(m * float64(time.Minute)))
+}
+
+var slaReportTemplate = template.Must(template.New("sla").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #1a1a1a; }
+h1 { color: #d64e4e; font-size: 1.5em; }
+p.period { color: #666; }
+table { border-collapse: collapse; width: 100%; }
+th { background: #d64e4e; color: #fff; text-align: left; padding: 6px 10px; }
+td { border-bottom: 1px solid #ddd; padding: 6px 10px; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+tr.breach td { background: #fdecec; }
+footer { margin-top: 2em; color: #999; font-size: 0.8em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="period">{{.From}} &ndash; {{.To}}</p>
+<table>
+<tr><th>Name</th>{{if .HasSLO}}<th>SLO %</th>{{end}}<th>Uptime %</th><th>Downtime</th><th>Incidents</th><th>MTTR</th><th>MTBF</th></tr>
+{{range .Rows}}<tr{{if .Breach}} class="breach"{{end}}><td>{{.Name}}</td>{{if $.HasSLO}}<td class="num">{{.SLO}}</td>{{end}}<td class="num">{{.Uptime}}</td><td class="num">{{.Downtime}}</td><td class="num">{{.Incidents}}</td><td class="num">{{.MTTR}}</td><td class="num">{{.MTBF}}</td></tr>
+{{end}}</table>
+<footer>Generated by zabbix-dna on {{.Generated}}</footer>
+</body>
+</html>
+`))
+
+func writeSLAReport(w io.Writer, format, title string, start, end time.Time, rows []slaRow) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{
+			"title": title,
+			"from":  start.Format(time.RFC3339),
+			"to":    end.Format(time.RFC3339),
+			"rows":  rows,
+		})
+
+	case "csv":
+		cw := csv.NewWriter(w)
+		f := func(v float64) string { return strconv.FormatFloat(v, 'f', 4, 64) }
+		cw.Write([]string{"name", "slo", "uptime_percent", "downtime_minutes", "incidents", "mttr_minutes", "mtbf_minutes"})
+		for _, r := range rows {
+			cw.Write([]string{r.Name, r.SLO, f(r.Uptime), f(r.DowntimeMin), strconv.Itoa(r.Incidents), f(r.MTTRMin), f(r.MTBFMin)})
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case "html":
+		type htmlRow struct {
+			Name, SLO, Uptime, Downtime, MTTR, MTBF string
+			Incidents                               int
+			Breach                                  bool
+		}
+		data := struct {
+			Title, From, To, Generated string
+			HasSLO                     bool
+			Rows                       []htmlRow
+		}{
+			Title:     title,
+			From:      start.Format("2006-01-02 15:04"),
+			To:        end.Format("2006-01-02 15:04"),
+			Generated: time.Now().Format("2006-01-02 15:04:05"),
+		}
+		for _, r := range rows {
+			row := htmlRow{
+				Name:      r.Name,
+				SLO:       r.SLO,
+				Uptime:    fmt.Sprintf("%.4f", r.Uptime),
+				Downtime:  formatMinutes(r.DowntimeMin),
+				Incidents: r.Incidents,
+				MTTR:      formatMinutes(r.MTTRMin),
+				MTBF:      formatMinutes(r.MTBFMin),
+			}
+			if r.SLO != "" {
+				data.HasSLO = true
+				if slo, err := strconv.ParseFloat(r.SLO, 64); err == nil && r.Uptime < slo {
+					row.Breach = true
+				}
+			}
+			data.Rows = append(data.Rows, row)
+		}
+		return slaReportTemplate.Execute(w, data)
+
+	default:
+		return fmt.Errorf("invalid format: %s", format)
+	}
+}
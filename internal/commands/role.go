@@ -0,0 +1,290 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// roleTypes maps CLI names of user types to their Zabbix codes.
+var roleTypes = map[string]string{
+	"user":        "1",
+	"admin":       "2",
+	"super-admin": "3",
+}
+
+func newRoleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "role",
+		Short: "Manage Zabbix user roles",
+	}
+
+	cmd.AddCommand(newRoleListCmd())
+	cmd.AddCommand(newRoleShowCmd())
+	cmd.AddCommand(newRoleCreateCmd())
+
+	return cmd
+}
+
+func newRoleListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List Zabbix user roles",
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			params := map[string]interface{}{
+				"output":      []string{"roleid", "name", "type", "readonly"},
+				"selectUsers": []string{"userid"},
+			}
+
+			result, err := client.Call("role.get", params)
+			handleError(err)
+
+			var roles []map[string]interface{}
+			json.Unmarshal(result, &roles)
+
+			headers := []string{"RoleID", "Name", "Type", "Users", "Read-only"}
+			var rows [][]string
+			for _, r := range roles {
+				readonly := "No"
+				if fmt.Sprintf("%v", r["readonly"]) == "1" {
+					readonly = "Yes"
+				}
+				rows = append(rows, []string{
+					fmt.Sprintf("%v", r["roleid"]),
+					fmt.Sprintf("%v", r["name"]),
+					getRoleTypeName(fmt.Sprintf("%v", r["type"])),
+					fmt.Sprintf("%d", len(exportList(r["users"]))),
+					readonly,
+				})
+			}
+
+			outputResult(cmd, roles, headers, rows)
+		},
+	}
+
+	return cmd
+}
+
+func newRoleShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [role]",
+		Short: "Show the UI, API and action rules of a role",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			roleID, err := getRoleID(client, args[0])
+			handleError(err)
+
+			roles, err := callGetList(client, "role.get", map[string]interface{}{
+				"output":      "extend",
+				"roleids":     []string{roleID},
+				"selectRules": "extend",
+				"selectUsers": []string{"username"},
+			})
+			handleError(err)
+			if len(roles) == 0 {
+				handleError(fmt.Errorf("role not found: %s", args[0]))
+			}
+			r := roles[0]
+			rules, _ := r["rules"].(map[string]interface{})
+
+			headers := []string{"Property", "Value"}
+			var rows [][]string
+			rows = append(rows, []string{"RoleID", fmt.Sprintf("%v", r["roleid"])})
+			rows = append(rows, []string{"Name", fmt.Sprintf("%v", r["name"])})
+			rows = append(rows, []string{"Type", getRoleTypeName(fmt.Sprintf("%v", r["type"]))})
+
+			rows = append(rows, roleRuleRows("UI", rules, "ui")...)
+			rows = append(rows, roleRuleRows("Actions", rules, "actions")...)
+
+			apiAccess := "Disabled"
+			if exportString(rules, "api.access") == "1" {
+				apiAccess = "Enabled"
+			}
+			rows = append(rows, []string{"API access", apiAccess})
+			if raw, ok := rules["api"].([]interface{}); ok && len(raw) > 0 {
+				var methods []string
+				for _, m := range raw {
+					methods = append(methods, fmt.Sprintf("%v", m))
+				}
+				label := "API denied methods"
+				if exportString(rules, "api.mode") == "1" {
+					label = "API allowed methods"
+				}
+				rows = append(rows, []string{label, strings.Join(methods, ", ")})
+			}
+
+			var users []string
+			for _, u := range exportList(r["users"]) {
+				users = append(users, fmt.Sprintf("%v", u["username"]))
+			}
+			rows = append(rows, []string{"Users", strings.Join(users, ", ")})
+
+			outputResult(cmd, r, headers, rows)
+		},
+	}
+}
+
+func newRoleCreateCmd() *cobra.Command {
+	var roleType string
+	var uiDefault string
+	var uiAllow []string
+	var uiDeny []string
+	var apiAccess bool
+	var apiMode string
+	var apiMethods []string
+	var actionDefault string
+	var actionAllow []string
+	var actionDeny []string
+
+	cmd := &cobra.Command{
+		Use:   "create [name]",
+		Short: "Create a user role with UI, API and action rules",
+		Long: `Create a user role. UI elements (e.g. monitoring.problems, configuration.hosts) and actions
+(e.g. edit_dashboards, acknowledge_problems) are allowed or denied on top of their default
+access. API methods (e.g. host.get, "*.create") form an allow list or a deny list depending on
+--api-mode.`,
+		Example: `  zabbix-dna role create "NOC operator" --type user --ui-default deny --ui-allow monitoring.problems,monitoring.dashboard
+  zabbix-dna role create "CI deployer" --type admin --api-mode allow --api-method host.get,maintenance.create,maintenance.delete`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			typeCode, ok := roleTypes[roleType]
+			if !ok {
+				handleError(fmt.Errorf("invalid role type: %s (use user, admin or super-admin)", roleType))
+			}
+
+			rules := map[string]interface{}{}
+			access, err := parseAccess("ui-default", uiDefault)
+			handleError(err)
+			rules["ui.default_access"] = access
+			if ui := roleRuleList(uiAllow, uiDeny); len(ui) > 0 {
+				rules["ui"] = ui
+			}
+
+			access, err = parseAccess("action-default", actionDefault)
+			handleError(err)
+			rules["actions.default_access"] = access
+			if actions := roleRuleList(actionAllow, actionDeny); len(actions) > 0 {
+				rules["actions"] = actions
+			}
+
+			rules["api.access"] = "0"
+			if apiAccess {
+				rules["api.access"] = "1"
+				switch apiMode {
+				case "deny":
+					rules["api.mode"] = "0"
+				case "allow":
+					rules["api.mode"] = "1"
+				default:
+					handleError(fmt.Errorf("invalid --api-mode: %s (use allow or deny)", apiMode))
+				}
+				if len(apiMethods) > 0 {
+					rules["api"] = apiMethods
+				}
+			}
+
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			result, err := client.Call("role.create", map[string]interface{}{
+				"name":  args[0],
+				"type":  typeCode,
+				"rules": rules,
+			})
+			handleError(err)
+
+			var resp map[string]interface{}
+			json.Unmarshal(result, &resp)
+			id := ""
+			if ids, ok := resp["roleids"].([]interface{}); ok && len(ids) > 0 {
+				id = fmt.Sprintf("%v", ids[0])
+			}
+
+			headers := []string{"Name", "Action", "Status", "ID"}
+			rows := [][]string{{args[0], "Create", "Success", id}}
+			outputResult(cmd, resp, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringVar(&roleType, "type", "user", "User type: user, admin or super-admin")
+	cmd.Flags().StringVar(&uiDefault, "ui-default", "allow", "Default access to UI elements: allow or deny")
+	cmd.Flags().StringSliceVar(&uiAllow, "ui-allow", []string{}, "UI elements to allow")
+	cmd.Flags().StringSliceVar(&uiDeny, "ui-deny", []string{}, "UI elements to deny")
+	cmd.Flags().BoolVar(&apiAccess, "api-access", true, "Allow access to the API")
+	cmd.Flags().StringVar(&apiMode, "api-mode", "deny", "Whether --api-method lists allowed or denied methods: allow or deny")
+	cmd.Flags().StringSliceVar(&apiMethods, "api-method", []string{}, "API methods for the allow or deny list")
+	cmd.Flags().StringVar(&actionDefault, "action-default", "allow", "Default access to actions: allow or deny")
+	cmd.Flags().StringSliceVar(&actionAllow, "action-allow", []string{}, "Actions to allow")
+	cmd.Flags().StringSliceVar(&actionDeny, "action-deny", []string{}, "Actions to deny")
+
+	return cmd
+}
+
+func getRoleTypeName(t string) string {
+	switch t {
+	case "1":
+		return "User"
+	case "2":
+		return "Admin"
+	case "3":
+		return "Super admin"
+	default:
+		return "Unknown"
+	}
+}
+
+func parseAccess(flag, value string) (string, error) {
+	switch value {
+	case "allow":
+		return "1", nil
+	case "deny":
+		return "0", nil
+	}
+	return "", fmt.Errorf("invalid --%s: %s (use allow or deny)", flag, value)
+}
+
+// roleRuleList builds role rule objects for UI elements or actions.
+func roleRuleList(allow, deny []string) []map[string]interface{} {
+	var rules []map[string]interface{}
+	for _, name := range allow {
+		rules = append(rules, map[string]interface{}{"name": name, "status": "1"})
+	}
+	for _, name := range deny {
+		rules = append(rules, map[string]interface{}{"name": name, "status": "0"})
+	}
+	return rules
+}
+
+// roleRuleRows summarises the UI or action rules of a role: default access and the exceptions.
+func roleRuleRows(label string, rules map[string]interface{}, key string) [][]string {
+	access := "allow"
+	if exportString(rules, key+".default_access") == "0" {
+		access = "deny"
+	}
+	rows := [][]string{{label + " default", access}}
+
+	var allowed, denied []string
+	for _, r := range exportList(rules[key]) {
+		if fmt.Sprintf("%v", r["status"]) == "1" {
+			allowed = append(allowed, fmt.Sprintf("%v", r["name"]))
+		} else {
+			denied = append(denied, fmt.Sprintf("%v", r["name"]))
+		}
+	}
+	// Zabbix returns every element; only the ones differing from the default are interesting.
+	if access == "allow" && len(denied) > 0 {
+		rows = append(rows, []string{label + " denied", strings.Join(denied, ", ")})
+	}
+	if access == "deny" && len(allowed) > 0 {
+		rows = append(rows, []string{label + " allowed", strings.Join(allowed, ", ")})
+	}
+	return rows
+}
@@ -16,6 +16,7 @@ func newTemplateCmd() *cobra.Command {
 	cmd.AddCommand(newTemplateListCmd())
 	cmd.AddCommand(newTemplateShowCmd())
 	cmd.AddCommand(newTemplateDeleteCmd())
+	cmd.AddCommand(newTemplateLintCmd())
 
 	return cmd
 }
@@ -0,0 +1,500 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"zabbix-dna/internal/config"
+	"zabbix-dna/internal/expression"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// templateLintRule describes a template lint rule and its default severity.
+type templateLintRule struct {
+	ID          string
+	Severity    string
+	Description string
+}
+
+var templateLintRules = []templateLintRule{
+	{"item-description", "warning", "Item or item prototype has no description"},
+	{"item-tags", "warning", "Item or item prototype has no tags"},
+	{"trigger-host-name", "warning", "Trigger name does not contain {HOST.NAME}"},
+	{"trigger-opdata", "info", "Trigger has no operational data"},
+	{"hardcoded-threshold", "warning", "Trigger threshold is a literal instead of a user macro"},
+	{"history-retention", "warning", "History or trends retention above policy"},
+	{"lld-lifetime", "warning", "Discovery rule relies on the default lost resource lifetime"},
+	{"duplicate-key", "error", "Item key defined more than once in a template"},
+	{"syntax", "error", "Trigger expression does not parse"},
+	{"unknown-function", "error", "Trigger expression uses a function unknown to Zabbix 6.0+"},
+	{"flapping-threshold", "warning", "Single-value threshold without a recovery expression"},
+}
+
+// lintSeverityLevel ranks finding severities for --fail-on.
+var lintSeverityLevel = map[string]int{"info": 1, "warning": 2, "error": 3}
+
+// exportRetentionDefaults are the values the server assumes when an export omits history or trends.
+var exportRetentionDefaults = map[string]string{"history": "90d", "trends": "365d"}
+
+// templateLinter holds the effective rule configuration.
+type templateLinter struct {
+	severity   map[string]string
+	maxHistory time.Duration
+	maxTrends  time.Duration
+	findings   []lintFinding
+	file       string
+}
+
+func newTemplateLintCmd() *cobra.Command {
+	var format string
+	var outputFile string
+	var disable []string
+	var maxHistory string
+	var maxTrends string
+	var failOn string
+
+	cmd := &cobra.Command{
+		Use:   "lint <file|template name>...",
+		Short: "Check templates against quality rules",
+		Long: `Check templates for common quality problems.
+
+Templates are read from export files (yaml or json) or, when the argument is not a file,
+exported from the server with configuration.export.
+
+Rules:
+  item-description     item or item prototype without description
+  item-tags            item or item prototype without tags
+  trigger-host-name    trigger name without {HOST.NAME}
+  trigger-opdata       trigger without operational data
+  hardcoded-threshold  literal threshold that should be a user macro
+  history-retention    history/trends above policy (--max-history, --max-trends)
+  lld-lifetime         discovery rule without an explicit lost resource lifetime
+  duplicate-key        item key defined more than once
+  syntax, unknown-function, flapping-threshold
+                       trigger expression checks (see "trigger lint")
+
+Rules are configured in the [lint] section of the config file:
+
+  [lint]
+  disabled_rules = ["trigger-opdata"]
+  max_history = "31d"
+  max_trends = "365d"
+  [lint.severity]
+  item-tags = "error"
+
+With --format sarif or json the report is suitable for CI; the exit status is 1 when a
+finding at or above --fail-on is reported.`,
+		Example: `  zabbix-dna template lint templates/app_nginx.yaml
+  zabbix-dna template lint "Linux by Zabbix agent" --disable item-description
+  zabbix-dna template lint templates/*.yaml -f sarif -O lint.sarif`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfgPath, _ := cmd.Flags().GetString("config")
+			lintCfg := config.LintConfig{MaxHistory: "90d", MaxTrends: "365d"}
+			if cfg, err := config.LoadConfig(cfgPath); err == nil {
+				lintCfg = cfg.Lint
+			}
+			if cmd.Flags().Changed("max-history") {
+				lintCfg.MaxHistory = maxHistory
+			}
+			if cmd.Flags().Changed("max-trends") {
+				lintCfg.MaxTrends = maxTrends
+			}
+			lintCfg.DisabledRules = append(lintCfg.DisabledRules, disable...)
+
+			linter, err := newTemplateLinter(lintCfg)
+			handleError(err)
+
+			switch format {
+			case "table", "json", "sarif":
+			default:
+				handleError(fmt.Errorf("invalid format: %s (use table, json or sarif)", format))
+			}
+			failLevel := lintSeverityLevel[failOn]
+			if failOn == "never" {
+				failLevel = lintSeverityLevel["error"] + 1
+			}
+			if failLevel == 0 {
+				handleError(fmt.Errorf("invalid --fail-on: %s (use error, warning, info or never)", failOn))
+			}
+
+			for _, arg := range args {
+				doc, file, err := loadTemplateExport(cmd, arg)
+				handleError(err)
+				linter.file = file
+				linter.lintExport(doc)
+			}
+
+			failed := false
+			for _, f := range linter.findings {
+				if lintSeverityLevel[f.Severity] >= failLevel {
+					failed = true
+				}
+			}
+
+			if format == "table" {
+				if len(linter.findings) == 0 {
+					outputResult(cmd, "No issues found.", nil, nil)
+					return
+				}
+				headers := []string{"Severity", "Rule", "Object", "Message"}
+				var rows [][]string
+				for _, f := range linter.findings {
+					rows = append(rows, []string{f.Severity, f.Rule, f.Object, f.Message})
+				}
+				outputResult(cmd, linter.findings, headers, rows)
+			} else {
+				var w io.Writer = os.Stdout
+				if outputFile != "" {
+					file, err := os.Create(outputFile)
+					handleError(err)
+					defer file.Close()
+					w = file
+				}
+				if format == "sarif" {
+					handleError(writeSARIF(w, linter.findings))
+				} else {
+					enc := json.NewEncoder(w)
+					enc.SetIndent("", "  ")
+					findings := linter.findings
+					if findings == nil {
+						findings = []lintFinding{}
+					}
+					handleError(enc.Encode(findings))
+				}
+				if outputFile != "" {
+					fmt.Fprintf(os.Stderr, "%d finding(s) written to %s\n", len(linter.findings), outputFile)
+				}
+			}
+
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json, sarif)")
+	cmd.Flags().StringVarP(&outputFile, "output-file", "O", "", "Write json/sarif output to a file")
+	cmd.Flags().StringArrayVar(&disable, "disable", []string{}, "Disable a rule (repeatable)")
+	cmd.Flags().StringVar(&maxHistory, "max-history", "90d", "Maximum history retention")
+	cmd.Flags().StringVar(&maxTrends, "max-trends", "365d", "Maximum trends retention")
+	cmd.Flags().StringVar(&failOn, "fail-on", "error", "Exit with status 1 on findings of this severity or above (error, warning, info, never)")
+
+	return cmd
+}
+
+func newTemplateLinter(cfg config.LintConfig) (*templateLinter, error) {
+	l := &templateLinter{severity: map[string]string{}}
+	for _, r := range templateLintRules {
+		l.severity[r.ID] = r.Severity
+	}
+	for rule, severity := range cfg.Severity {
+		if _, ok := l.severity[rule]; !ok {
+			return nil, fmt.Errorf("unknown lint rule in config: %s", rule)
+		}
+		switch severity {
+		case "error", "warning", "info", "off":
+		default:
+			return nil, fmt.Errorf("invalid severity for %s: %s (use error, warning, info or off)", rule, severity)
+		}
+		l.severity[rule] = severity
+	}
+	for _, rule := range cfg.DisabledRules {
+		if _, ok := l.severity[rule]; !ok {
+			return nil, fmt.Errorf("unknown lint rule: %s", rule)
+		}
+		l.severity[rule] = "off"
+	}
+
+	var err error
+	if l.maxHistory, err = parseRetention(cfg.MaxHistory); err != nil {
+		return nil, fmt.Errorf("invalid max history: %w", err)
+	}
+	if l.maxTrends, err = parseRetention(cfg.MaxTrends); err != nil {
+		return nil, fmt.Errorf("invalid max trends: %w", err)
+	}
+	return l, nil
+}
+
+// loadTemplateExport reads an export file, or exports the named template from the server.
+// It returns the parsed document and the file name, empty for server exports.
+func loadTemplateExport(cmd *cobra.Command, arg string) (map[string]interface{}, string, error) {
+	var source, file string
+	if info, err := os.Stat(arg); err == nil && !info.IsDir() {
+		format, data, err := readImportSource(arg, "")
+		if err != nil {
+			return nil, "", err
+		}
+		if format == "xml" {
+			return nil, "", fmt.Errorf("%s: xml exports are not supported, export the template as yaml or json", arg)
+		}
+		source, file = data, arg
+	} else {
+		client, err := getZabbixClient(cmd)
+		if err != nil {
+			return nil, "", err
+		}
+		templateID := getTemplateID(client, arg)
+		if templateID == "" {
+			return nil, "", fmt.Errorf("template not found (and no such file): %s", arg)
+		}
+		result, err := client.Call("configuration.export", map[string]interface{}{
+			"options": map[string]interface{}{"templates": []string{templateID}},
+			"format":  "yaml",
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		if err := json.Unmarshal(result, &source); err != nil {
+			return nil, "", fmt.Errorf("unexpected configuration.export response: %w", err)
+		}
+	}
+
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal([]byte(source), &doc); err != nil {
+		return nil, "", fmt.Errorf("failed to parse %s: %w", arg, err)
+	}
+	if export, ok := doc["zabbix_export"].(map[string]interface{}); ok {
+		doc = export
+	}
+	if _, ok := doc["templates"]; !ok {
+		return nil, "", fmt.Errorf("%s: no templates found in export", arg)
+	}
+	return doc, file, nil
+}
+
+func (l *templateLinter) report(rule, object, format string, args ...interface{}) {
+	severity := l.severity[rule]
+	if severity == "off" {
+		return
+	}
+	l.findings = append(l.findings, lintFinding{
+		Severity: severity,
+		Rule:     rule,
+		Object:   object,
+		Message:  fmt.Sprintf(format, args...),
+		File:     l.file,
+	})
+}
+
+func (l *templateLinter) lintExport(doc map[string]interface{}) {
+	for _, t := range exportList(doc["templates"]) {
+		name := fmt.Sprintf("%v", t["template"])
+		keys := map[string]int{}
+
+		for _, item := range exportList(t["items"]) {
+			l.lintItem(name, "item", item, keys)
+			for _, trigger := range exportList(item["triggers"]) {
+				l.lintTrigger(name, "trigger", trigger)
+			}
+		}
+
+		for _, rule := range exportList(t["discovery_rules"]) {
+			key := fmt.Sprintf("%v", rule["key"])
+			keys[key]++
+			object := fmt.Sprintf("%s: discovery rule %s", name, key)
+			if _, ok := rule["lifetime"]; !ok {
+				l.report("lld-lifetime", object, "no lost resource lifetime set, the server default keeps or deletes resources silently")
+			}
+			for _, item := range exportList(rule["item_prototypes"]) {
+				l.lintItem(name, "item prototype", item, keys)
+				for _, trigger := range exportList(item["trigger_prototypes"]) {
+					l.lintTrigger(name, "trigger prototype", trigger)
+				}
+			}
+			for _, trigger := range exportList(rule["trigger_prototypes"]) {
+				l.lintTrigger(name, "trigger prototype", trigger)
+			}
+		}
+
+		for _, key := range sortedKeys(keys) {
+			if keys[key] > 1 {
+				l.report("duplicate-key", name+": "+key, "key %s is defined %d times", key, keys[key])
+			}
+		}
+	}
+
+	// Triggers spanning several items are exported at the top level.
+	for _, trigger := range exportList(doc["triggers"]) {
+		l.lintTrigger("", "trigger", trigger)
+	}
+}
+
+func (l *templateLinter) lintItem(template, kind string, item map[string]interface{}, keys map[string]int) {
+	key := fmt.Sprintf("%v", item["key"])
+	keys[key]++
+	object := fmt.Sprintf("%s: %s %s", template, kind, key)
+
+	if strings.TrimSpace(exportString(item, "description")) == "" {
+		l.report("item-description", object, "%s has no description", kind)
+	}
+	if len(exportList(item["tags"])) == 0 {
+		l.report("item-tags", object, "%s has no tags", kind)
+	}
+
+	for _, field := range []struct {
+		name  string
+		limit time.Duration
+	}{{"history", l.maxHistory}, {"trends", l.maxTrends}} {
+		value, shown := exportString(item, field.name), ""
+		if value == "" {
+			// Character, log and text items keep no trends.
+			if field.name == "trends" && containsString([]string{"CHAR", "LOG", "TEXT"}, exportString(item, "value_type")) {
+				continue
+			}
+			value, shown = exportRetentionDefaults[field.name], " (default)"
+		}
+		if strings.Contains(value, "{") {
+			continue
+		}
+		d, err := parseRetention(value)
+		if err != nil {
+			continue
+		}
+		if d > field.limit {
+			l.report("history-retention", object, "%s retention %s%s exceeds policy of %s", field.name, value, shown, formatDuration(field.limit))
+		}
+	}
+}
+
+func (l *templateLinter) lintTrigger(template, kind string, trigger map[string]interface{}) {
+	name := exportString(trigger, "name")
+	object := fmt.Sprintf("%s %s", kind, name)
+	if template != "" {
+		object = template + ": " + object
+	}
+
+	if !strings.Contains(name, "{HOST.NAME") && !strings.Contains(name, "{HOST.HOST") {
+		l.report("trigger-host-name", object, "name does not contain {HOST.NAME}; notifications will not say which host is affected")
+	}
+	if exportString(trigger, "opdata") == "" {
+		l.report("trigger-opdata", object, "no operational data; add the current value, e.g. opdata: \"Current: {ITEM.LASTVALUE1}\"")
+	}
+
+	expr := exportString(trigger, "expression")
+	recoveryMode := "0"
+	switch exportString(trigger, "recovery_mode") {
+	case "RECOVERY_EXPRESSION", "1":
+		recoveryMode = "1"
+	case "NONE", "2":
+		recoveryMode = "2"
+	}
+	for _, f := range lintTriggerExpression(object, expr, exportString(trigger, "recovery_expression"), recoveryMode, newItemLookup(nil)) {
+		l.report(f.Rule, object, "%s", f.Message)
+	}
+
+	if tree, err := expression.Parse(expr); err == nil {
+		for _, literal := range hardcodedThresholds(tree) {
+			l.report("hardcoded-threshold", object, "threshold %s is hardcoded; use a user macro such as {$THRESHOLD}", literal)
+		}
+	}
+}
+
+// hardcodedThresholds returns numeric literals compared against functions, ignoring 0 and 1
+// which usually test states rather than thresholds.
+func hardcodedThresholds(tree expression.Node) []string {
+	var literals []string
+	expression.Walk(tree, func(n expression.Node) bool {
+		b, ok := n.(*expression.BinaryExpr)
+		if !ok {
+			return true
+		}
+		switch b.Op {
+		case "<", "<=", ">", ">=", "=", "<>":
+		default:
+			return true
+		}
+		for _, pair := range [][2]expression.Node{{b.Left, b.Right}, {b.Right, b.Left}} {
+			if _, ok := pair[0].(*expression.FunctionCall); !ok {
+				continue
+			}
+			if num, ok := pair[1].(*expression.NumberLit); ok && num.Value != "0" && num.Value != "1" {
+				literals = append(literals, num.Value)
+			}
+		}
+		return true
+	})
+	return literals
+}
+
+// parseRetention parses Zabbix retention periods: seconds or a number with s/m/h/d/w suffix.
+func parseRetention(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+	return parseDurationSpec(s)
+}
+
+func exportList(v interface{}) []map[string]interface{} {
+	list, _ := v.([]interface{})
+	var out []map[string]interface{}
+	for _, e := range list {
+		if m, ok := e.(map[string]interface{}); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func exportString(m map[string]interface{}, key string) string {
+	if v, ok := m[key]; ok && v != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return ""
+}
+
+// writeSARIF writes findings as a SARIF 2.1.0 log for code scanning tools.
+func writeSARIF(w io.Writer, findings []lintFinding) error {
+	levels := map[string]string{"error": "error", "warning": "warning", "info": "note"}
+
+	var rules []map[string]interface{}
+	for _, r := range templateLintRules {
+		rules = append(rules, map[string]interface{}{
+			"id":                   r.ID,
+			"shortDescription":     map[string]string{"text": r.Description},
+			"defaultConfiguration": map[string]string{"level": levels[r.Severity]},
+		})
+	}
+
+	results := []map[string]interface{}{}
+	for _, f := range findings {
+		location := map[string]interface{}{
+			"logicalLocations": []map[string]string{{"fullyQualifiedName": f.Object}},
+		}
+		if f.File != "" {
+			location["physicalLocation"] = map[string]interface{}{
+				"artifactLocation": map[string]string{"uri": f.File},
+			}
+		}
+		results = append(results, map[string]interface{}{
+			"ruleId":    f.Rule,
+			"level":     levels[f.Severity],
+			"message":   map[string]string{"text": f.Object + ": " + f.Message},
+			"locations": []map[string]interface{}{location},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]interface{}{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []map[string]interface{}{{
+			"tool": map[string]interface{}{
+				"driver": map[string]interface{}{
+					"name":  "zabbix-dna",
+					"rules": rules,
+				},
+			},
+			"results": results,
+		}},
+	})
+}
@@ -3,6 +3,10 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+
+	"zabbix-dna/internal/api"
 
 	"github.com/spf13/cobra"
 )
@@ -14,7 +18,13 @@ func newTriggerCmd() *cobra.Command {
 	}
 
 	cmd.AddCommand(newTriggerListCmd())
+	cmd.AddCommand(newTriggerShowCmd())
 	cmd.AddCommand(newTriggerCreateCmd())
+	cmd.AddCommand(newTriggerUpdateCmd())
+	cmd.AddCommand(newTriggerDeleteCmd())
+	cmd.AddCommand(newTriggerStatusCmd("enable", "0"))
+	cmd.AddCommand(newTriggerStatusCmd("disable", "1"))
+	cmd.AddCommand(newTriggerLintCmd())
 
 	return cmd
 }
@@ -54,19 +64,11 @@ func newTriggerListCmd() *cobra.Command {
 			headers := []string{"TriggerID", "Description", "Priority", "Status"}
 			var rows [][]string
 			for _, t := range triggers {
-				priority := getPriorityName(t["priority"].(string))
-				status := "OK"
-				if t["value"].(string) == "1" {
-					status = "PROBLEM"
-				}
-				if t["status"].(string) == "1" {
-					status = "DISABLED"
-				}
 				rows = append(rows, []string{
 					fmt.Sprintf("%v", t["triggerid"]),
 					fmt.Sprintf("%v", t["description"]),
-					priority,
-					status,
+					getPriorityName(t["priority"].(string)),
+					getTriggerStateName(t),
 				})
 			}
 
@@ -81,22 +83,119 @@ func newTriggerListCmd() *cobra.Command {
 	return cmd
 }
 
+// triggerOptions holds the trigger properties shared by create and update.
+type triggerOptions struct {
+	priority           int
+	recoveryExpression string
+	manualClose        bool
+	tags               []string
+	dependsOn          []string
+	url                string
+	comments           string
+}
+
+func (o *triggerOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVarP(&o.priority, "priority", "p", 0, "Trigger priority (0-5)")
+	cmd.Flags().StringVar(&o.recoveryExpression, "recovery-expression", "", "Recovery expression (empty to recover on the problem expression)")
+	cmd.Flags().BoolVar(&o.manualClose, "manual-close", false, "Allow problems to be closed manually")
+	cmd.Flags().StringArrayVar(&o.tags, "tag", []string{}, "Trigger tag as name=value (repeatable)")
+	cmd.Flags().StringArrayVar(&o.dependsOn, "depends-on", []string{}, "Trigger this one depends on, as ID or host:description (repeatable)")
+	cmd.Flags().StringVar(&o.url, "url", "", "URL shown with the problem")
+	cmd.Flags().StringVar(&o.comments, "comments", "", "Trigger description / operational data for the on-call")
+}
+
+// apply copies the options that were set on the command line into params.
+func (o *triggerOptions) apply(cmd *cobra.Command, client *api.ZabbixClient, host string, params map[string]interface{}) error {
+	flags := cmd.Flags()
+	if flags.Changed("priority") {
+		if o.priority < 0 || o.priority > 5 {
+			return fmt.Errorf("invalid priority: %d (expected 0-5)", o.priority)
+		}
+		params["priority"] = o.priority
+	}
+	if flags.Changed("recovery-expression") {
+		if o.recoveryExpression == "" {
+			params["recovery_mode"] = 0
+			params["recovery_expression"] = ""
+		} else {
+			params["recovery_mode"] = 1
+			params["recovery_expression"] = expandHostPlaceholder(o.recoveryExpression, host)
+		}
+	}
+	if flags.Changed("manual-close") {
+		params["manual_close"] = 0
+		if o.manualClose {
+			params["manual_close"] = 1
+		}
+	}
+	if flags.Changed("tag") {
+		tags, err := parseTagFlags(o.tags)
+		if err != nil {
+			return err
+		}
+		params["tags"] = tags
+	}
+	if flags.Changed("depends-on") {
+		deps := []map[string]string{}
+		for _, ref := range o.dependsOn {
+			trigger, err := resolveTriggerRef(client, ref)
+			if err != nil {
+				return err
+			}
+			deps = append(deps, map[string]string{"triggerid": fmt.Sprintf("%v", trigger["triggerid"])})
+		}
+		params["dependencies"] = deps
+	}
+	if flags.Changed("url") {
+		params["url"] = o.url
+	}
+	if flags.Changed("comments") {
+		params["comments"] = o.comments
+	}
+	return nil
+}
+
 func newTriggerCreateCmd() *cobra.Command {
 	var expression string
-	var priority int
+	var host string
+	var validate bool
+	var opts triggerOptions
 
 	cmd := &cobra.Command{
 		Use:   "create [trigger description]",
 		Short: "Create a new Zabbix trigger",
-		Args:  cobra.ExactArgs(1),
+		Long: `Create a new Zabbix trigger.
+
+With --host, the {HOST} placeholder in the expression and recovery expression is replaced
+by the host name, so the same expression can be reused across hosts.
+
+With --validate the expressions are parsed locally, after {HOST} is replaced, before the
+trigger is created, so syntax errors and unknown functions are reported with their position.`,
+		Example: `  zabbix-dna trigger create "High CPU on {HOST.NAME}" --host web01 \
+    -e 'avg(/{HOST}/system.cpu.util,5m)>90' --recovery-expression 'avg(/{HOST}/system.cpu.util,5m)<70' \
+    -p 4 --tag scope=performance --depends-on "web01:Zabbix agent is not available" --manual-close`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			client, err := getZabbixClient(cmd)
 			handleError(err)
 
+			if host != "" && getHostID(client, host) == "" {
+				handleError(fmt.Errorf("host not found: %s", host))
+			}
+
 			params := map[string]interface{}{
 				"description": args[0],
-				"expression":  expression,
-				"priority":    priority,
+				"expression":  expandHostPlaceholder(expression, host),
+				"priority":    opts.priority,
+			}
+			handleError(opts.apply(cmd, client, host, params))
+
+			// Validate what is sent, with {HOST} already replaced.
+			if validate {
+				handleError(validateTriggerExpression(fmt.Sprintf("%v", params["expression"])))
+				if recovery, _ := params["recovery_expression"].(string); recovery != "" {
+					handleError(validateTriggerExpression(recovery))
+				}
 			}
 
 			result, err := client.Call("trigger.create", params)
@@ -113,8 +212,456 @@ func newTriggerCreateCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&expression, "expression", "e", "", "Trigger expression")
-	cmd.Flags().IntVarP(&priority, "priority", "p", 0, "Trigger priority (0-5)")
+	cmd.Flags().StringVar(&host, "host", "", "Host name substituted for {HOST} in expressions")
+	cmd.Flags().BoolVar(&validate, "validate", false, "Parse the expressions locally before creating the trigger")
+	opts.addFlags(cmd)
 	cmd.MarkFlagRequired("expression")
 
 	return cmd
 }
+
+func newTriggerUpdateCmd() *cobra.Command {
+	var description string
+	var expression string
+	var status string
+	var opts triggerOptions
+
+	cmd := &cobra.Command{
+		Use:   "update [triggerid | host description]",
+		Short: "Update a Zabbix trigger",
+		Long:  "Update a Zabbix trigger. Only the flags given are changed; --tag and --depends-on replace the existing lists.",
+		Example: `  zabbix-dna trigger update 13550 -p 5 --url https://wiki/runbooks/cpu
+  zabbix-dna trigger update web01 "High CPU" --depends-on 13491 --tag team=web`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			trigger, err := resolveTriggerArgs(client, args)
+			handleError(err)
+			// {HOST} expands to the technical host name, as in create
+			host := ""
+			if hosts, ok := trigger["hosts"].([]interface{}); ok && len(hosts) > 0 {
+				if h, ok := hosts[0].(map[string]interface{}); ok {
+					host = fmt.Sprintf("%v", h["host"])
+				}
+			}
+
+			params := map[string]interface{}{
+				"triggerid": trigger["triggerid"],
+			}
+			if description != "" {
+				params["description"] = description
+			}
+			if expression != "" {
+				params["expression"] = expandHostPlaceholder(expression, host)
+			}
+			switch status {
+			case "":
+			case "enable":
+				params["status"] = "0"
+			case "disable":
+				params["status"] = "1"
+			default:
+				handleError(fmt.Errorf("invalid status: %s (expected enable or disable)", status))
+			}
+			handleError(opts.apply(cmd, client, host, params))
+
+			result, err := client.Call("trigger.update", params)
+			handleError(err)
+
+			var resp map[string]interface{}
+			json.Unmarshal(result, &resp)
+
+			headers := []string{"Trigger", "Action", "Status", "ID"}
+			rows := [][]string{{fmt.Sprintf("%v", trigger["description"]), "Update", "Success", fmt.Sprintf("%v", trigger["triggerid"])}}
+			outputResult(cmd, resp, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringVarP(&description, "description", "d", "", "New trigger name")
+	cmd.Flags().StringVarP(&expression, "expression", "e", "", "New trigger expression")
+	cmd.Flags().StringVar(&status, "status", "", "Set trigger status (enable/disable)")
+	opts.addFlags(cmd)
+
+	return cmd
+}
+
+func newTriggerDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete [triggerid... | host description]",
+		Short: "Delete Zabbix triggers",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			var triggerIDs []string
+			if _, err := strconv.ParseUint(args[0], 10, 64); err == nil {
+				triggerIDs = args
+			} else {
+				trigger, err := resolveTriggerArgs(client, args)
+				handleError(err)
+				triggerIDs = []string{fmt.Sprintf("%v", trigger["triggerid"])}
+			}
+
+			result, err := client.Call("trigger.delete", triggerIDs)
+			handleError(err)
+
+			var resp map[string]interface{}
+			json.Unmarshal(result, &resp)
+
+			headers := []string{"TriggerID", "Action", "Status"}
+			var rows [][]string
+			for _, id := range triggerIDs {
+				rows = append(rows, []string{id, "Delete", "Success"})
+			}
+			outputResult(cmd, resp, headers, rows)
+		},
+	}
+}
+
+// newTriggerStatusCmd builds the enable and disable commands, which select triggers by ID or filter.
+func newTriggerStatusCmd(use, status string) *cobra.Command {
+	var hostNames []string
+	var hostGroupNames []string
+	var templateNames []string
+	var search string
+	var tags []string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   use + " [triggerid...]",
+		Short: fmt.Sprintf("%s triggers by ID or filter", strings.ToUpper(use[:1])+use[1:]),
+		Long: fmt.Sprintf(`%s triggers given by ID, or every trigger matching the filters.
+
+When filters are used the matching triggers are listed and confirmation is requested
+unless --yes is given.`, strings.ToUpper(use[:1])+use[1:]),
+		Example: fmt.Sprintf(`  zabbix-dna trigger %[1]s 13550 13551
+  zabbix-dna trigger %[1]s --hostgroup "Linux servers" --search "swap" --yes`, use),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			filtered := len(hostNames) > 0 || len(hostGroupNames) > 0 || len(templateNames) > 0 || search != "" || len(tags) > 0
+			if len(args) == 0 && !filtered {
+				handleError(fmt.Errorf("specify trigger IDs or at least one filter (--host, --hostgroup, --template, --search, --tag)"))
+			}
+
+			params := map[string]interface{}{
+				"output":      []string{"triggerid", "description", "priority", "status", "value"},
+				"selectHosts": []string{"host", "name"},
+				"sortfield":   "description",
+			}
+			if len(args) > 0 {
+				params["triggerids"] = args
+			}
+			if len(hostNames) > 0 {
+				ids := getHostsIDs(client, hostNames)
+				if len(ids) == 0 {
+					handleError(fmt.Errorf("no hosts found: %s", strings.Join(hostNames, ", ")))
+				}
+				params["hostids"] = ids
+			}
+			if len(hostGroupNames) > 0 {
+				ids := getHostGroupsIDs(client, hostGroupNames)
+				if len(ids) == 0 {
+					handleError(fmt.Errorf("no host groups found: %s", strings.Join(hostGroupNames, ", ")))
+				}
+				params["groupids"] = ids
+			}
+			if len(templateNames) > 0 {
+				var ids []string
+				for _, name := range templateNames {
+					id := getTemplateID(client, name)
+					if id == "" {
+						handleError(fmt.Errorf("template not found: %s", name))
+					}
+					ids = append(ids, id)
+				}
+				params["templateids"] = ids
+			}
+			if search != "" {
+				params["search"] = map[string]interface{}{"description": search}
+			}
+			if len(tags) > 0 {
+				tagFilters, err := problemTagFilters(tags)
+				handleError(err)
+				params["tags"] = tagFilters
+			}
+
+			triggers, err := callGetList(client, "trigger.get", params)
+			handleError(err)
+
+			headers := []string{"TriggerID", "Host", "Description", "Priority", "Status"}
+			var rows [][]string
+			var updates []map[string]interface{}
+			for _, t := range triggers {
+				if fmt.Sprintf("%v", t["status"]) == status {
+					continue
+				}
+				updates = append(updates, map[string]interface{}{"triggerid": t["triggerid"], "status": status})
+				rows = append(rows, []string{
+					fmt.Sprintf("%v", t["triggerid"]),
+					triggerHostName(t),
+					fmt.Sprintf("%v", t["description"]),
+					getPriorityName(fmt.Sprintf("%v", t["priority"])),
+					getTriggerStateName(t),
+				})
+			}
+
+			if len(updates) == 0 {
+				outputResult(cmd, fmt.Sprintf("No triggers to %s.", use), nil, nil)
+				return
+			}
+
+			if filtered && !yes {
+				outputResult(cmd, updates, headers, rows)
+				if !confirmAction(fmt.Sprintf("%s %d trigger(s)?", strings.ToUpper(use[:1])+use[1:], len(updates))) {
+					outputResult(cmd, "Aborted.", nil, nil)
+					return
+				}
+			}
+
+			_, err = client.Call("trigger.update", updates)
+			handleError(err)
+
+			outputResult(cmd, fmt.Sprintf("%d trigger(s) %sd.", len(updates), use), nil, nil)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&hostNames, "host", []string{}, "Filter by host name(s)")
+	cmd.Flags().StringSliceVar(&hostGroupNames, "hostgroup", []string{}, "Filter by host group name(s)")
+	cmd.Flags().StringSliceVar(&templateNames, "template", []string{}, "Filter by template name(s)")
+	cmd.Flags().StringVar(&search, "search", "", "Filter by text in the trigger name")
+	cmd.Flags().StringArrayVar(&tags, "tag", []string{}, "Filter by trigger tag (name or name=value)")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Do not ask for confirmation")
+
+	return cmd
+}
+
+func newTriggerShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [triggerid | host description]",
+		Short: "Show a trigger with its expanded expression, functions, dependencies and state",
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			ref, err := resolveTriggerArgs(client, args)
+			handleError(err)
+
+			triggers, err := callGetList(client, "trigger.get", map[string]interface{}{
+				"output":             "extend",
+				"triggerids":         []string{fmt.Sprintf("%v", ref["triggerid"])},
+				"expandExpression":   true,
+				"expandDescription":  true,
+				"selectHosts":        []string{"hostid", "host", "name"},
+				"selectFunctions":    "extend",
+				"selectItems":        []string{"itemid", "key_", "name", "lastvalue", "units"},
+				"selectTags":         "extend",
+				"selectDependencies": []string{"triggerid"},
+			})
+			handleError(err)
+			if len(triggers) == 0 {
+				handleError(fmt.Errorf("trigger not found: %v", ref["triggerid"]))
+			}
+			t := triggers[0]
+
+			items := make(map[string]map[string]interface{})
+			if list, ok := t["items"].([]interface{}); ok {
+				for _, i := range list {
+					if item, ok := i.(map[string]interface{}); ok {
+						items[fmt.Sprintf("%v", item["itemid"])] = item
+					}
+				}
+			}
+
+			headers := []string{"Property", "Value"}
+			rows := [][]string{
+				{"TriggerID", fmt.Sprintf("%v", t["triggerid"])},
+				{"Name", fmt.Sprintf("%v", t["description"])},
+				{"Host", triggerHostName(t)},
+				{"Priority", getPriorityName(fmt.Sprintf("%v", t["priority"]))},
+				{"State", getTriggerStateName(t)},
+				{"Last Change", formatUnixTime(fmt.Sprintf("%v", t["lastchange"]))},
+				{"Expression", fmt.Sprintf("%v", t["expression"])},
+			}
+			if fmt.Sprintf("%v", t["recovery_mode"]) == "1" {
+				rows = append(rows, []string{"Recovery Expression", fmt.Sprintf("%v", t["recovery_expression"])})
+			}
+			if errMsg := fmt.Sprintf("%v", t["error"]); errMsg != "" && errMsg != "<nil>" {
+				rows = append(rows, []string{"Error", errMsg})
+			}
+			manualClose := "No"
+			if fmt.Sprintf("%v", t["manual_close"]) == "1" {
+				manualClose = "Yes"
+			}
+			rows = append(rows, []string{"Manual Close", manualClose})
+
+			if functions, ok := t["functions"].([]interface{}); ok {
+				for i, f := range functions {
+					fn, ok := f.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					rows = append(rows, []string{fmt.Sprintf("Function %d", i+1), describeTriggerFunction(fn, items)})
+				}
+			}
+			if tags, ok := t["tags"].([]interface{}); ok && len(tags) > 0 {
+				var parts []string
+				for _, tg := range tags {
+					if m, ok := tg.(map[string]interface{}); ok {
+						parts = append(parts, fmt.Sprintf("%v=%v", m["tag"], m["value"]))
+					}
+				}
+				rows = append(rows, []string{"Tags", strings.Join(parts, ", ")})
+			}
+			// One row per dependency, since the table renderer reflows multi-line cells
+			for i, line := range triggerDependencyTree(client, t, 0, map[string]bool{}) {
+				label := ""
+				if i == 0 {
+					label = "Depends On"
+				}
+				rows = append(rows, []string{label, line})
+			}
+			if url := fmt.Sprintf("%v", t["url"]); url != "" {
+				rows = append(rows, []string{"URL", url})
+			}
+			if comments := fmt.Sprintf("%v", t["comments"]); comments != "" {
+				rows = append(rows, []string{"Comments", comments})
+			}
+
+			outputResult(cmd, t, headers, rows)
+		},
+	}
+}
+
+func getTriggerStateName(t map[string]interface{}) string {
+	if fmt.Sprintf("%v", t["status"]) == "1" {
+		return "DISABLED"
+	}
+	if fmt.Sprintf("%v", t["state"]) == "1" {
+		return "UNKNOWN"
+	}
+	if fmt.Sprintf("%v", t["value"]) == "1" {
+		return "PROBLEM"
+	}
+	return "OK"
+}
+
+// describeTriggerFunction renders a trigger function with its item key and last value.
+func describeTriggerFunction(fn map[string]interface{}, items map[string]map[string]interface{}) string {
+	item := items[fmt.Sprintf("%v", fn["itemid"])]
+	key := fmt.Sprintf("%v", fn["itemid"])
+	if item != nil {
+		key = fmt.Sprintf("%v", item["key_"])
+	}
+	// Zabbix 5.4+ stores the item position as "$" in the parameter list
+	params := strings.TrimPrefix(strings.TrimPrefix(fmt.Sprintf("%v", fn["parameter"]), "$"), ",")
+	desc := fmt.Sprintf("%v(%s", fn["function"], key)
+	if params != "" {
+		desc += "," + params
+	}
+	desc += ")"
+	if item != nil {
+		desc += fmt.Sprintf(" = %v", item["lastvalue"])
+		if units := fmt.Sprintf("%v", item["units"]); units != "" {
+			desc += " " + units
+		}
+	}
+	return desc
+}
+
+// triggerDependencyTree returns the indented dependency lines of a trigger, following
+// dependencies recursively and stopping at cycles.
+func triggerDependencyTree(client *api.ZabbixClient, t map[string]interface{}, depth int, seen map[string]bool) []string {
+	deps, ok := t["dependencies"].([]interface{})
+	if !ok || len(deps) == 0 || depth > 10 {
+		return nil
+	}
+	seen[fmt.Sprintf("%v", t["triggerid"])] = true
+
+	var ids []string
+	for _, d := range deps {
+		if m, ok := d.(map[string]interface{}); ok {
+			ids = append(ids, fmt.Sprintf("%v", m["triggerid"]))
+		}
+	}
+	parents, err := callGetList(client, "trigger.get", map[string]interface{}{
+		"output":             []string{"triggerid", "description", "status", "state", "value"},
+		"triggerids":         ids,
+		"expandDescription":  true,
+		"selectHosts":        []string{"host", "name"},
+		"selectDependencies": []string{"triggerid"},
+	})
+	if err != nil {
+		return nil
+	}
+
+	var lines []string
+	indent := strings.Repeat("  ", depth)
+	for _, p := range parents {
+		id := fmt.Sprintf("%v", p["triggerid"])
+		lines = append(lines, fmt.Sprintf("%s└─ [%s] %s: %v (%s)", indent, id, triggerHostName(p), p["description"], getTriggerStateName(p)))
+		if seen[id] {
+			lines = append(lines, indent+"   (cycle)")
+			continue
+		}
+		lines = append(lines, triggerDependencyTree(client, p, depth+1, seen)...)
+	}
+	return lines
+}
+
+// resolveTriggerArgs finds a trigger from either [triggerid] or [host description] arguments.
+func resolveTriggerArgs(client *api.ZabbixClient, args []string) (map[string]interface{}, error) {
+	if len(args) == 2 {
+		return resolveTriggerRef(client, args[0]+":"+args[1])
+	}
+	return resolveTriggerRef(client, args[0])
+}
+
+// resolveTriggerRef finds a trigger by ID or by "host:description".
+func resolveTriggerRef(client *api.ZabbixClient, ref string) (map[string]interface{}, error) {
+	params := map[string]interface{}{
+		"output":      []string{"triggerid", "description", "expression"},
+		"selectHosts": []string{"host", "name"},
+	}
+	if _, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		params["triggerids"] = []string{ref}
+	} else {
+		parts := strings.SplitN(ref, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid trigger reference: %s (expected ID or host:description)", ref)
+		}
+		hostID := getHostID(client, parts[0])
+		if hostID == "" {
+			hostID = getTemplateID(client, parts[0])
+		}
+		if hostID == "" {
+			return nil, fmt.Errorf("host not found: %s", parts[0])
+		}
+		params["hostids"] = []string{hostID}
+		params["filter"] = map[string]interface{}{"description": parts[1]}
+	}
+
+	triggers, err := callGetList(client, "trigger.get", params)
+	if err != nil {
+		return nil, err
+	}
+	if len(triggers) == 0 {
+		return nil, fmt.Errorf("trigger not found: %s", ref)
+	}
+	if len(triggers) > 1 {
+		return nil, fmt.Errorf("trigger reference is ambiguous: %s matches %d triggers", ref, len(triggers))
+	}
+	return triggers[0], nil
+}
+
+func expandHostPlaceholder(expression, host string) string {
+	if host == "" {
+		return expression
+	}
+	return strings.ReplaceAll(expression, "{HOST}", host)
+}
@@ -0,0 +1,335 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"zabbix-dna/internal/api"
+	"zabbix-dna/internal/expression"
+
+	"github.com/spf13/cobra"
+)
+
+// lintFinding is a single problem reported by a linter.
+type lintFinding struct {
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Object   string `json:"object"`
+	Message  string `json:"message"`
+	File     string `json:"file,omitempty"`
+}
+
+// passiveItemTypes are item types polled by the server or proxy, where nodata() also fires
+// on collection failures that are better covered by interface availability.
+var passiveItemTypes = map[string]string{
+	"0":  "Zabbix agent (passive)",
+	"3":  "simple check",
+	"20": "SNMP agent",
+}
+
+// flappingFunctions compare single recent values and flap around a threshold without hysteresis.
+var flappingFunctions = map[string]bool{"last": true, "first": true, "change": true}
+
+func newTriggerLintCmd() *cobra.Command {
+	var expr string
+	var recoveryExpr string
+	var hostNames []string
+	var hostGroupNames []string
+	var templateNames []string
+	var offline bool
+
+	cmd := &cobra.Command{
+		Use:   "lint [triggerid...]",
+		Short: "Check trigger expressions for common mistakes",
+		Long: `Check trigger expressions for syntax errors and common mistakes.
+
+Rules:
+  syntax              expression does not parse
+  unknown-function    function not supported by Zabbix 6.0+
+  missing-item        item query refers to an item that does not exist (needs the API)
+  key-quoting         item key differs from an existing key only in quoting or spacing,
+                      which the server does not match (needs the API)
+  flapping-threshold  single-value threshold without a recovery expression
+  nodata-passive      nodata() on a passive (polled) item (needs the API)
+
+With --expression a single expression is checked; add --offline to skip the API.
+Exits with status 1 when errors are found.`,
+		Example: `  zabbix-dna trigger lint --hostgroup "Linux servers"
+  zabbix-dna trigger lint --template "Linux by Zabbix agent"
+  zabbix-dna trigger lint -e 'last(/web01/system.cpu.util)>90' --offline`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if expr != "" && len(args) > 0 {
+				handleError(fmt.Errorf("--expression cannot be combined with trigger IDs"))
+			}
+
+			var client *api.ZabbixClient
+			if !offline {
+				var err error
+				client, err = getZabbixClient(cmd)
+				handleError(err)
+			}
+			items := newItemLookup(client)
+
+			var findings []lintFinding
+			if expr != "" {
+				recoveryMode := "0"
+				if recoveryExpr != "" {
+					recoveryMode = "1"
+				}
+				findings = lintTriggerExpression("expression", expr, recoveryExpr, recoveryMode, items)
+			} else {
+				if offline {
+					handleError(fmt.Errorf("--offline requires --expression"))
+				}
+				if len(args) == 0 && len(hostNames) == 0 && len(hostGroupNames) == 0 && len(templateNames) == 0 {
+					handleError(fmt.Errorf("specify trigger IDs, --expression or a filter (--host, --hostgroup, --template)"))
+				}
+
+				params := map[string]interface{}{
+					"output":           []string{"triggerid", "description", "expression", "recovery_mode", "recovery_expression"},
+					"selectHosts":      []string{"host", "name"},
+					"expandExpression": true,
+					"sortfield":        "description",
+				}
+				if len(args) > 0 {
+					params["triggerids"] = args
+				}
+				if len(hostNames) > 0 {
+					ids := getHostsIDs(client, hostNames)
+					if len(ids) == 0 {
+						handleError(fmt.Errorf("no hosts found: %s", strings.Join(hostNames, ", ")))
+					}
+					params["hostids"] = ids
+				}
+				if len(hostGroupNames) > 0 {
+					ids := getHostGroupsIDs(client, hostGroupNames)
+					if len(ids) == 0 {
+						handleError(fmt.Errorf("no host groups found: %s", strings.Join(hostGroupNames, ", ")))
+					}
+					params["groupids"] = ids
+				}
+				if len(templateNames) > 0 {
+					var ids []string
+					for _, name := range templateNames {
+						id := getTemplateID(client, name)
+						if id == "" {
+							handleError(fmt.Errorf("template not found: %s", name))
+						}
+						ids = append(ids, id)
+					}
+					params["templateids"] = ids
+				}
+
+				triggers, err := callGetList(client, "trigger.get", params)
+				handleError(err)
+
+				for _, t := range triggers {
+					object := fmt.Sprintf("%v: %v", triggerHostName(t), t["description"])
+					findings = append(findings, lintTriggerExpression(object,
+						fmt.Sprintf("%v", t["expression"]),
+						fmt.Sprintf("%v", t["recovery_expression"]),
+						fmt.Sprintf("%v", t["recovery_mode"]),
+						items)...)
+				}
+			}
+
+			if len(findings) == 0 {
+				outputResult(cmd, "No issues found.", nil, nil)
+				return
+			}
+
+			headers := []string{"Severity", "Rule", "Trigger", "Message"}
+			var rows [][]string
+			failed := false
+			for _, f := range findings {
+				rows = append(rows, []string{f.Severity, f.Rule, f.Object, f.Message})
+				if f.Severity == "error" {
+					failed = true
+				}
+			}
+			outputResult(cmd, findings, headers, rows)
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&expr, "expression", "e", "", "Check this expression instead of existing triggers")
+	cmd.Flags().StringVar(&recoveryExpr, "recovery-expression", "", "Recovery expression for --expression")
+	cmd.Flags().StringSliceVar(&hostNames, "host", []string{}, "Check triggers of these hosts")
+	cmd.Flags().StringSliceVar(&hostGroupNames, "hostgroup", []string{}, "Check triggers of these host groups")
+	cmd.Flags().StringSliceVar(&templateNames, "template", []string{}, "Check triggers of these templates")
+	cmd.Flags().BoolVar(&offline, "offline", false, "Skip checks that need the API (with --expression)")
+
+	return cmd
+}
+
+// validateTriggerExpression parses an expression and rejects unknown functions.
+func validateTriggerExpression(expr string) error {
+	tree, err := expression.Parse(expr)
+	if err != nil {
+		return fmt.Errorf("invalid expression: %s", expression.FormatError(expr, err))
+	}
+	for _, f := range expression.Functions(tree) {
+		if !expression.IsKnownFunction(f.Name) {
+			return fmt.Errorf("invalid expression: unknown function %s() at position %d", f.Name, f.Pos+1)
+		}
+	}
+	return nil
+}
+
+// lintTriggerExpression runs every rule on a trigger expression. Item checks are skipped
+// when items has no API client.
+func lintTriggerExpression(object, expr, recoveryExpr, recoveryMode string, items *itemLookup) []lintFinding {
+	var findings []lintFinding
+	seen := map[string]bool{}
+	add := func(severity, rule, format string, args ...interface{}) {
+		message := fmt.Sprintf(format, args...)
+		if seen[rule+message] {
+			return
+		}
+		seen[rule+message] = true
+		findings = append(findings, lintFinding{Severity: severity, Rule: rule, Object: object, Message: message})
+	}
+
+	tree, err := expression.Parse(expr)
+	if err != nil {
+		add("error", "syntax", "%v", err)
+		return findings
+	}
+	trees := []expression.Node{tree}
+	if recoveryMode == "1" && recoveryExpr != "" {
+		recoveryTree, err := expression.Parse(recoveryExpr)
+		if err != nil {
+			add("error", "syntax", "recovery expression: %v", err)
+		} else {
+			trees = append(trees, recoveryTree)
+		}
+	}
+
+	for _, t := range trees {
+		for _, f := range expression.Functions(t) {
+			if !expression.IsKnownFunction(f.Name) {
+				add("error", "unknown-function", "unknown function %s()", f.Name)
+				continue
+			}
+			if !expression.IsHistoryFunction(f.Name) || len(f.Args) == 0 {
+				continue
+			}
+			query, ok := f.Args[0].(*expression.ItemQuery)
+			if !ok {
+				continue
+			}
+			item, similar, checked := items.find(query.Host, query.Key)
+			if !checked {
+				continue
+			}
+			if item == nil && similar != "" {
+				add("error", "key-quoting", "item %s not found on %s; %s differs only in quoting or spacing", query.Key, query.Host, similar)
+				continue
+			}
+			if item == nil {
+				add("error", "missing-item", "item %s not found on %s", query.Key, query.Host)
+				continue
+			}
+			if f.Name == "nodata" {
+				if typeName, ok := passiveItemTypes[fmt.Sprintf("%v", item["type"])]; ok {
+					add("warning", "nodata-passive", "nodata() on %s item %s also fires when polling fails; use an active item or interface availability", typeName, query.Key)
+				}
+			}
+		}
+	}
+
+	if recoveryMode == "0" {
+		if name := flappingComparison(tree); name != "" {
+			add("warning", "flapping-threshold", "%s() compared to a constant without a recovery expression will flap around the threshold; add --recovery-expression", name)
+		}
+	}
+
+	return findings
+}
+
+// flappingComparison returns the function name of the first threshold comparison on a
+// single recent value, or "" when there is none.
+func flappingComparison(tree expression.Node) string {
+	found := ""
+	expression.Walk(tree, func(n expression.Node) bool {
+		b, ok := n.(*expression.BinaryExpr)
+		if !ok || found != "" {
+			return found == ""
+		}
+		switch b.Op {
+		case "<", "<=", ">", ">=":
+		default:
+			return true
+		}
+		for _, pair := range [][2]expression.Node{{b.Left, b.Right}, {b.Right, b.Left}} {
+			f, ok := pair[0].(*expression.FunctionCall)
+			if ok && flappingFunctions[f.Name] && isConstant(pair[1]) {
+				found = f.Name
+			}
+		}
+		return true
+	})
+	return found
+}
+
+func isConstant(n expression.Node) bool {
+	switch v := n.(type) {
+	case *expression.NumberLit, *expression.MacroRef:
+		return true
+	case *expression.UnaryExpr:
+		return v.Op == "-" && isConstant(v.X)
+	}
+	return false
+}
+
+// itemLookup caches item.get results per host for lint rules. The server matches item keys
+// literally; the normalized keys only serve to explain a near miss.
+type itemLookup struct {
+	client     *api.ZabbixClient
+	hosts      map[string]map[string]map[string]interface{}
+	normalized map[string]map[string]string // host -> normalized key -> actual key
+}
+
+func newItemLookup(client *api.ZabbixClient) *itemLookup {
+	return &itemLookup{
+		client:     client,
+		hosts:      map[string]map[string]map[string]interface{}{},
+		normalized: map[string]map[string]string{},
+	}
+}
+
+// find returns the item with exactly the given key on a host or template. When there is none,
+// similar is the key of an item that differs only in quoting or spacing. checked is false when
+// the lookup was not possible (offline, macros or wildcards in the query).
+func (l *itemLookup) find(host, key string) (item map[string]interface{}, similar string, checked bool) {
+	if l.client == nil || host == "" || strings.ContainsAny(host, "{*") || strings.ContainsAny(key, "{*") {
+		return nil, "", false
+	}
+	items, ok := l.hosts[host]
+	if !ok {
+		list, err := callGetList(l.client, "item.get", map[string]interface{}{
+			"output":   []string{"itemid", "key_", "type"},
+			"host":     host,
+			"webitems": true,
+		})
+		if err != nil {
+			return nil, "", false
+		}
+		items = map[string]map[string]interface{}{}
+		normalized := map[string]string{}
+		for _, it := range list {
+			k := fmt.Sprintf("%v", it["key_"])
+			items[k] = it
+			normalized[expression.NormalizeKey(k)] = k
+		}
+		l.hosts[host] = items
+		l.normalized[host] = normalized
+	}
+	if item, ok := items[key]; ok {
+		return item, "", true
+	}
+	return nil, l.normalized[host][expression.NormalizeKey(key)], true
+}
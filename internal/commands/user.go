@@ -3,7 +3,15 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
 
+	"zabbix-dna/internal/api"
+	"zabbix-dna/internal/config"
+
+	"github.com/charmbracelet/x/term"
 	"github.com/spf13/cobra"
 )
 
@@ -20,6 +28,8 @@ func newUserCmd() *cobra.Command {
 	cmd.AddCommand(newUserDeleteCmd())  // remove_user -> user delete
 	cmd.AddCommand(newUserEnableCmd())  // enable_user -> user enable
 	cmd.AddCommand(newUserDisableCmd()) // disable_user -> user disable
+	cmd.AddCommand(newUserMediaCmd())
+	cmd.AddCommand(newUserSetPasswordCmd())
 
 	return cmd
 }
@@ -37,8 +47,10 @@ func newUserListCmd() *cobra.Command {
 			handleError(err)
 
 			params := map[string]interface{}{
-				"output": []string{"userid", "username", "name", "surname", "roleid"},
-				"limit":  limit,
+				"output":        []string{"userid", "username", "name", "surname", "roleid"},
+				"selectRole":    []string{"name"},
+				"selectUsrgrps": []string{"usrgrpid", "name", "users_status"},
+				"limit":         limit,
 			}
 			if search != "" {
 				params["search"] = map[string]interface{}{
@@ -52,7 +64,7 @@ func newUserListCmd() *cobra.Command {
 			var users []map[string]interface{}
 			json.Unmarshal(result, &users)
 
-			headers := []string{"UserID", "Username", "First Name", "Last Name", "Role ID"}
+			headers := []string{"UserID", "Username", "First Name", "Last Name", "Role", "Status"}
 			var rows [][]string
 			for _, u := range users {
 				rows = append(rows, []string{
@@ -60,7 +72,8 @@ func newUserListCmd() *cobra.Command {
 					fmt.Sprintf("%v", u["username"]),
 					fmt.Sprintf("%v", u["name"]),
 					fmt.Sprintf("%v", u["surname"]),
-					fmt.Sprintf("%v", u["roleid"]),
+					getUserRoleName(u),
+					getUserStatus(u),
 				})
 			}
 
@@ -90,6 +103,7 @@ func newUserShowCmd() *cobra.Command {
 				},
 				"selectUsrgrps": "extend",
 				"selectMedias":  "extend",
+				"selectRole":    []string{"name"},
 			}
 
 			result, err := client.Call("user.get", params)
@@ -110,7 +124,8 @@ func newUserShowCmd() *cobra.Command {
 			rows = append(rows, []string{"UserID", fmt.Sprintf("%v", u["userid"])})
 			rows = append(rows, []string{"Username", fmt.Sprintf("%v", u["username"])})
 			rows = append(rows, []string{"Name", fmt.Sprintf("%v %v", u["name"], u["surname"])})
-			rows = append(rows, []string{"Role ID", fmt.Sprintf("%v", u["roleid"])})
+			rows = append(rows, []string{"Role", getUserRoleName(u)})
+			rows = append(rows, []string{"Status", getUserStatus(u)})
 
 			if groups, ok := u["usrgrps"].([]interface{}); ok {
 				for i, g := range groups {
@@ -123,6 +138,17 @@ func newUserShowCmd() *cobra.Command {
 				}
 			}
 
+			if medias := exportList(u["medias"]); len(medias) > 0 {
+				mediaTypes := getMediaTypeNames(client)
+				for i, m := range medias {
+					label := "Media"
+					if i > 0 {
+						label = ""
+					}
+					rows = append(rows, []string{label, describeUserMedia(m, mediaTypes)})
+				}
+			}
+
 			outputResult(cmd, u, headers, rows)
 		},
 	}
@@ -130,25 +156,64 @@ func newUserShowCmd() *cobra.Command {
 
 func newUserCreateCmd() *cobra.Command {
 	var password string
+	var passwordStdin bool
+	var role string
+	var groups []string
 	var roleID string
 	var groupID string
+	var name string
+	var surname string
 
 	cmd := &cobra.Command{
 		Use:     "create [username]",
 		Aliases: []string{"create_user"},
 		Short:   "Create a new Zabbix user",
-		Args:    cobra.ExactArgs(1),
+		Long: `Create a user with a role and user groups given by name (or ID).
+
+Without --password the password is prompted for on the terminal, or read from standard input
+with --password-stdin. It is checked against the server password policy before the user is
+created.`,
+		Example: `  zabbix-dna user create jdoe --role "Admin role" --group "Zabbix administrators" --group Ops
+  echo "$PASS" | zabbix-dna user create ci-bot --role "User role" --group API --password-stdin`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			client, err := getZabbixClient(cmd)
 			handleError(err)
 
+			if role == "" {
+				role = roleID
+			}
+			resolvedRoleID, err := getRoleID(client, role)
+			handleError(err)
+
+			var groupIDs []string
+			if groupID != "" {
+				groupIDs = append(groupIDs, groupID)
+			}
+			ids, err := getUserGroupIDs(client, groups)
+			handleError(err)
+			groupIDs = append(groupIDs, ids...)
+			if len(groupIDs) == 0 {
+				handleError(fmt.Errorf("specify at least one --group"))
+			}
+
+			if !cmd.Flags().Changed("password") {
+				password, err = readNewPassword(passwordStdin)
+				handleError(err)
+			}
+			handleError(checkPasswordPolicy(client, password, args[0], name, surname))
+
 			params := map[string]interface{}{
 				"username": args[0],
 				"passwd":   password,
-				"roleid":   roleID,
-				"usrgrps": []map[string]string{
-					{"usrgrpid": groupID},
-				},
+				"roleid":   resolvedRoleID,
+				"usrgrps":  userGroupRefs(groupIDs),
+			}
+			if name != "" {
+				params["name"] = name
+			}
+			if surname != "" {
+				params["surname"] = surname
 			}
 
 			result, err := client.Call("user.create", params)
@@ -164,11 +229,16 @@ func newUserCreateCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&password, "password", "p", "", "Password for the user")
+	cmd.Flags().StringVarP(&password, "password", "p", "", "Password for the user (prompted for when omitted)")
+	cmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "Read the password from standard input")
+	cmd.Flags().StringVar(&role, "role", "", "Role name or ID for the user")
+	cmd.Flags().StringSliceVar(&groups, "group", []string{}, "User group name(s) or ID(s)")
 	cmd.Flags().StringVarP(&roleID, "roleid", "r", "1", "Role ID for the user (default: 1 - User)")
 	cmd.Flags().StringVarP(&groupID, "groupid", "g", "", "User group ID for the user")
-	cmd.MarkFlagRequired("password")
-	cmd.MarkFlagRequired("groupid")
+	cmd.Flags().StringVar(&name, "name", "", "First name")
+	cmd.Flags().StringVar(&surname, "surname", "", "Last name")
+	cmd.Flags().MarkDeprecated("roleid", "use --role")
+	cmd.Flags().MarkDeprecated("groupid", "use --group")
 
 	return cmd
 }
@@ -176,7 +246,9 @@ func newUserCreateCmd() *cobra.Command {
 func newUserUpdateCmd() *cobra.Command {
 	var name string
 	var surname string
-	return &cobra.Command{
+	var role string
+
+	cmd := &cobra.Command{
 		Use:     "update [username]",
 		Aliases: []string{"update_user"},
 		Short:   "Update a Zabbix user",
@@ -185,18 +257,9 @@ func newUserUpdateCmd() *cobra.Command {
 			client, err := getZabbixClient(cmd)
 			handleError(err)
 
-			// Find user
-			res, err := client.Call("user.get", map[string]interface{}{
-				"filter": map[string]interface{}{"username": args[0]},
-			})
+			user, err := getUser(client, args[0], nil)
 			handleError(err)
-			var users []map[string]interface{}
-			json.Unmarshal(res, &users)
-			if len(users) == 0 {
-				handleError(fmt.Errorf("user not found: %s", args[0]))
-				return
-			}
-			userID := users[0]["userid"].(string)
+			userID := user["userid"].(string)
 
 			params := map[string]interface{}{"userid": userID}
 			if name != "" {
@@ -205,6 +268,11 @@ func newUserUpdateCmd() *cobra.Command {
 			if surname != "" {
 				params["surname"] = surname
 			}
+			if role != "" {
+				roleID, err := getRoleID(client, role)
+				handleError(err)
+				params["roleid"] = roleID
+			}
 
 			resp, err := client.Call("user.update", params)
 			handleError(err)
@@ -217,6 +285,12 @@ func newUserUpdateCmd() *cobra.Command {
 			outputResult(cmd, updateResp, headers, rows)
 		},
 	}
+
+	cmd.Flags().StringVar(&name, "name", "", "First name")
+	cmd.Flags().StringVar(&surname, "surname", "", "Last name")
+	cmd.Flags().StringVar(&role, "role", "", "Role name or ID")
+
+	return cmd
 }
 
 func newUserDeleteCmd() *cobra.Command {
@@ -264,49 +338,72 @@ func newUserDeleteCmd() *cobra.Command {
 }
 
 func newUserEnableCmd() *cobra.Command {
-	return &cobra.Command{
+	var groups []string
+
+	cmd := &cobra.Command{
 		Use:     "enable [username]",
 		Aliases: []string{"enable_user"},
 		Short:   "Enable a Zabbix user",
-		Args:    cobra.ExactArgs(1),
+		Long: `Enable a user by removing it from the disabled group ([users] disabled_group in the
+config, "Disabled" by default). Other members of the user's groups are not affected.
+
+Users that only belong to the disabled group need another group, given with --group.`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			client, err := getZabbixClient(cmd)
 			handleError(err)
 
-			// Find user and their groups
-			res, err := client.Call("user.get", map[string]interface{}{
-				"filter":        map[string]interface{}{"username": args[0]},
-				"selectUsrgrps": "extend",
+			user, err := getUser(client, args[0], map[string]interface{}{
+				"selectUsrgrps": []string{"usrgrpid", "name", "users_status"},
 			})
 			handleError(err)
-			var users []map[string]interface{}
-			json.Unmarshal(res, &users)
-			if len(users) == 0 {
-				handleError(fmt.Errorf("user not found: %s", args[0]))
-				return
-			}
-			user := users[0]
-			var lastResp map[string]interface{}
-			if groups, ok := user["usrgrps"].([]interface{}); ok {
-				for _, g := range groups {
-					group := g.(map[string]interface{})
-					groupID := group["usrgrpid"].(string)
-
-					// Enable user group (status 0 = enabled)
-					resp, err := client.Call("usergroup.update", map[string]interface{}{
-						"usrgrpid":     groupID,
-						"users_status": "0",
-					})
-					handleError(err)
-					json.Unmarshal(resp, &lastResp)
+			disabledGroup := disabledGroupName(cmd)
+
+			extra, err := getUserGroupIDs(client, groups)
+			handleError(err)
+
+			var groupIDs []string
+			var stillDisabled []string
+			removed := false
+			for _, g := range exportList(user["usrgrps"]) {
+				if fmt.Sprintf("%v", g["name"]) == disabledGroup {
+					removed = true
+					continue
 				}
+				groupIDs = append(groupIDs, fmt.Sprintf("%v", g["usrgrpid"]))
+				if fmt.Sprintf("%v", g["users_status"]) == "1" {
+					stillDisabled = append(stillDisabled, fmt.Sprintf("%v", g["name"]))
+				}
+			}
+			groupIDs = appendUnique(groupIDs, extra...)
+			if !removed && len(extra) == 0 {
+				handleError(fmt.Errorf("user %s is not in the %s group", args[0], disabledGroup))
+			}
+			if len(groupIDs) == 0 {
+				handleError(fmt.Errorf("user %s only belongs to %s; give the groups to restore with --group", args[0], disabledGroup))
 			}
 
+			resp, err := client.Call("user.update", map[string]interface{}{
+				"userid":  user["userid"],
+				"usrgrps": userGroupRefs(groupIDs),
+			})
+			handleError(err)
+			var updateResp map[string]interface{}
+			json.Unmarshal(resp, &updateResp)
+
+			note := "Removed from " + disabledGroup
+			if len(stillDisabled) > 0 {
+				note = "Still disabled by group(s): " + strings.Join(stillDisabled, ", ")
+			}
 			headers := []string{"Username", "Action", "Status", "Note"}
-			rows := [][]string{{args[0], "Enable User", "Success", "Enabled via user groups"}}
-			outputResult(cmd, lastResp, headers, rows)
+			rows := [][]string{{args[0], "Enable User", "Success", note}}
+			outputResult(cmd, updateResp, headers, rows)
 		},
 	}
+
+	cmd.Flags().StringSliceVar(&groups, "group", []string{}, "User group name(s) or ID(s) to add the user to")
+
+	return cmd
 }
 
 func newUserDisableCmd() *cobra.Command {
@@ -314,43 +411,332 @@ func newUserDisableCmd() *cobra.Command {
 		Use:     "disable [username]",
 		Aliases: []string{"disable_user"},
 		Short:   "Disable a Zabbix user",
-		Args:    cobra.ExactArgs(1),
+		Long: `Disable a user by adding it to the disabled group ([users] disabled_group in the config,
+"Disabled" by default). The group is created with users_status disabled when missing. Other
+members of the user's groups are not affected.`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			client, err := getZabbixClient(cmd)
 			handleError(err)
 
-			// Find user and their groups
-			res, err := client.Call("user.get", map[string]interface{}{
-				"filter":        map[string]interface{}{"username": args[0]},
-				"selectUsrgrps": "extend",
+			user, err := getUser(client, args[0], map[string]interface{}{
+				"selectUsrgrps": []string{"usrgrpid", "name"},
 			})
 			handleError(err)
-			var users []map[string]interface{}
-			json.Unmarshal(res, &users)
-			if len(users) == 0 {
-				handleError(fmt.Errorf("user not found: %s", args[0]))
-				return
+
+			disabledID, created, err := ensureDisabledGroup(client, disabledGroupName(cmd))
+			handleError(err)
+
+			var groupIDs []string
+			for _, g := range exportList(user["usrgrps"]) {
+				groupIDs = append(groupIDs, fmt.Sprintf("%v", g["usrgrpid"]))
 			}
-			user := users[0]
-			var lastResp map[string]interface{}
-			if groups, ok := user["usrgrps"].([]interface{}); ok {
-				for _, g := range groups {
-					group := g.(map[string]interface{})
-					groupID := group["usrgrpid"].(string)
-
-					// Disable user group (status 1 = disabled)
-					resp, err := client.Call("usergroup.update", map[string]interface{}{
-						"usrgrpid":     groupID,
-						"users_status": "1",
-					})
-					handleError(err)
-					json.Unmarshal(resp, &lastResp)
+			for _, id := range groupIDs {
+				if id == disabledID {
+					handleError(fmt.Errorf("user %s is already disabled", args[0]))
 				}
 			}
 
+			resp, err := client.Call("user.update", map[string]interface{}{
+				"userid":  user["userid"],
+				"usrgrps": userGroupRefs(append(groupIDs, disabledID)),
+			})
+			handleError(err)
+			var updateResp map[string]interface{}
+			json.Unmarshal(resp, &updateResp)
+
+			note := "Added to " + disabledGroupName(cmd)
+			if created {
+				note += " (group created)"
+			}
 			headers := []string{"Username", "Action", "Status", "Note"}
-			rows := [][]string{{args[0], "Disable User", "Success", "Disabled via user groups"}}
-			outputResult(cmd, lastResp, headers, rows)
+			rows := [][]string{{args[0], "Disable User", "Success", note}}
+			outputResult(cmd, updateResp, headers, rows)
+		},
+	}
+}
+
+func newUserSetPasswordCmd() *cobra.Command {
+	var passwordStdin bool
+	var current bool
+
+	cmd := &cobra.Command{
+		Use:   "set-password [username]",
+		Short: "Change the password of a Zabbix user",
+		Long: `Change a user's password. The new password is prompted for twice on the terminal, or read
+from standard input with --password-stdin, and checked against the server password policy.
+
+Changing the password of the user zabbix-dna logs in as requires the current password, which
+is prompted for automatically; use --current to force the prompt.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			user, err := getUser(client, args[0], nil)
+			handleError(err)
+
+			cfgPath, _ := cmd.Flags().GetString("config")
+			if cfg, err := config.LoadConfig(cfgPath); err == nil && cfg.API.AuthToken == "" && cfg.API.Username == args[0] {
+				current = true
+			}
+			params := map[string]interface{}{"userid": user["userid"]}
+			if current {
+				if passwordStdin {
+					handleError(fmt.Errorf("--password-stdin cannot be combined with the current password prompt"))
+				}
+				old, err := promptPassword("Current password: ")
+				handleError(err)
+				params["current_passwd"] = old
+			}
+
+			password, err := readNewPassword(passwordStdin)
+			handleError(err)
+			handleError(checkPasswordPolicy(client, password, args[0], exportString(user, "name"), exportString(user, "surname")))
+			params["passwd"] = password
+
+			resp, err := client.Call("user.update", params)
+			handleError(err)
+			var updateResp map[string]interface{}
+			json.Unmarshal(resp, &updateResp)
+
+			headers := []string{"Username", "Action", "Status"}
+			rows := [][]string{{args[0], "Set Password", "Success"}}
+			outputResult(cmd, updateResp, headers, rows)
 		},
 	}
+
+	cmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "Read the new password from standard input")
+	cmd.Flags().BoolVar(&current, "current", false, "Prompt for the current password (needed when changing your own)")
+
+	return cmd
+}
+
+// getUser returns the user with the given username, with extra user.get params merged in.
+func getUser(client *api.ZabbixClient, username string, extra map[string]interface{}) (map[string]interface{}, error) {
+	params := map[string]interface{}{
+		"output": []string{"userid", "username", "name", "surname", "roleid"},
+		"filter": map[string]interface{}{"username": username},
+	}
+	for k, v := range extra {
+		params[k] = v
+	}
+	users, err := callGetList(client, "user.get", params)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("user not found: %s", username)
+	}
+	return users[0], nil
+}
+
+// getRoleID resolves a role name or ID.
+func getRoleID(client *api.ZabbixClient, ref string) (string, error) {
+	params := map[string]interface{}{
+		"output": []string{"roleid", "name"},
+		"filter": map[string]interface{}{"name": ref},
+	}
+	if _, err := strconv.Atoi(ref); err == nil {
+		params["filter"] = map[string]interface{}{"roleid": ref}
+	}
+	roles, err := callGetList(client, "role.get", params)
+	if err != nil {
+		return "", err
+	}
+	if len(roles) == 0 {
+		return "", fmt.Errorf("role not found: %s", ref)
+	}
+	return fmt.Sprintf("%v", roles[0]["roleid"]), nil
+}
+
+// getUserGroupIDs resolves user group names or IDs, failing on unknown groups.
+func getUserGroupIDs(client *api.ZabbixClient, refs []string) ([]string, error) {
+	var ids []string
+	for _, ref := range refs {
+		filter := map[string]interface{}{"name": ref}
+		if _, err := strconv.Atoi(ref); err == nil {
+			filter = map[string]interface{}{"usrgrpid": ref}
+		}
+		groups, err := callGetList(client, "usergroup.get", map[string]interface{}{
+			"output": []string{"usrgrpid"},
+			"filter": filter,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(groups) == 0 {
+			return nil, fmt.Errorf("user group not found: %s", ref)
+		}
+		ids = append(ids, fmt.Sprintf("%v", groups[0]["usrgrpid"]))
+	}
+	return ids, nil
+}
+
+func userGroupRefs(ids []string) []map[string]string {
+	refs := make([]map[string]string, 0, len(ids))
+	for _, id := range ids {
+		refs = append(refs, map[string]string{"usrgrpid": id})
+	}
+	return refs
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, existing := range list {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func disabledGroupName(cmd *cobra.Command) string {
+	cfgPath, _ := cmd.Flags().GetString("config")
+	if cfg, err := config.LoadConfig(cfgPath); err == nil {
+		return cfg.Users.DisabledGroup
+	}
+	return "Disabled"
+}
+
+// ensureDisabledGroup returns the ID of the disabled group, creating it when missing. A group
+// with that name whose users are enabled is refused, as adding users to it would not disable them.
+func ensureDisabledGroup(client *api.ZabbixClient, name string) (string, bool, error) {
+	groups, err := callGetList(client, "usergroup.get", map[string]interface{}{
+		"output": []string{"usrgrpid", "users_status"},
+		"filter": map[string]interface{}{"name": name},
+	})
+	if err != nil {
+		return "", false, err
+	}
+	if len(groups) > 0 {
+		if fmt.Sprintf("%v", groups[0]["users_status"]) != "1" {
+			return "", false, fmt.Errorf("user group %s does not disable its users; set its status to disabled or change [users] disabled_group", name)
+		}
+		return fmt.Sprintf("%v", groups[0]["usrgrpid"]), false, nil
+	}
+
+	result, err := client.Call("usergroup.create", map[string]interface{}{
+		"name":         name,
+		"users_status": "1",
+	})
+	if err != nil {
+		return "", false, err
+	}
+	var resp map[string]interface{}
+	json.Unmarshal(result, &resp)
+	ids, _ := resp["usrgrpids"].([]interface{})
+	if len(ids) == 0 {
+		return "", false, fmt.Errorf("usergroup.create returned no ID")
+	}
+	return fmt.Sprintf("%v", ids[0]), true, nil
+}
+
+func getUserRoleName(u map[string]interface{}) string {
+	if role, ok := u["role"].(map[string]interface{}); ok {
+		return fmt.Sprintf("%v", role["name"])
+	}
+	return fmt.Sprintf("%v", u["roleid"])
+}
+
+// getUserStatus reports a user as disabled when any of its groups disables its users.
+func getUserStatus(u map[string]interface{}) string {
+	for _, g := range exportList(u["usrgrps"]) {
+		if fmt.Sprintf("%v", g["users_status"]) == "1" {
+			return "Disabled"
+		}
+	}
+	return "Enabled"
+}
+
+// readNewPassword prompts twice for a new password, or reads one line from standard input.
+func readNewPassword(fromStdin bool) (string, error) {
+	if fromStdin || !term.IsTerminal(os.Stdin.Fd()) {
+		password, err := readMacroValue("-")
+		if err == nil && password == "" {
+			err = fmt.Errorf("empty password on standard input")
+		}
+		return password, err
+	}
+	password, err := promptPassword("New password: ")
+	if err != nil {
+		return "", err
+	}
+	confirm, err := promptPassword("Repeat password: ")
+	if err != nil {
+		return "", err
+	}
+	if password != confirm {
+		return "", fmt.Errorf("passwords do not match")
+	}
+	if password == "" {
+		return "", fmt.Errorf("empty password")
+	}
+	return password, nil
+}
+
+// promptPassword reads a password from the terminal without echoing it.
+func promptPassword(prompt string) (string, error) {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", fmt.Errorf("cannot prompt for a password: standard input is not a terminal")
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return string(password), nil
+}
+
+// checkPasswordPolicy applies the server password policy (authentication.get) locally so a
+// rejected password gets a precise message. Servers without a policy API are not checked.
+func checkPasswordPolicy(client *api.ZabbixClient, password, username, name, surname string) error {
+	result, err := client.Call("authentication.get", map[string]interface{}{
+		"output": []string{"passwd_min_length", "passwd_check_rules"},
+	})
+	if err != nil {
+		return nil
+	}
+	var policy map[string]interface{}
+	if json.Unmarshal(result, &policy) != nil || policy == nil {
+		return nil
+	}
+
+	minLength, _ := strconv.Atoi(exportString(policy, "passwd_min_length"))
+	rules, _ := strconv.Atoi(exportString(policy, "passwd_check_rules"))
+	var problems []string
+	if len([]rune(password)) < minLength {
+		problems = append(problems, fmt.Sprintf("at least %d characters", minLength))
+	}
+	if rules&1 != 0 && (strings.ToLower(password) == password || strings.ToUpper(password) == password) {
+		problems = append(problems, "upper and lower case letters")
+	}
+	if rules&2 != 0 && !strings.ContainsAny(password, "0123456789") {
+		problems = append(problems, "a digit")
+	}
+	if rules&4 != 0 && strings.IndexFunc(password, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) < 0 {
+		problems = append(problems, "a special character")
+	}
+	if rules&8 != 0 {
+		lower := strings.ToLower(password)
+		for _, word := range []string{username, name, surname} {
+			if word != "" && strings.Contains(lower, strings.ToLower(word)) {
+				problems = append(problems, "no user name or real name")
+				break
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("password does not meet the server policy, it needs %s", strings.Join(problems, ", "))
+	}
+	return nil
 }
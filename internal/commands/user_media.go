@@ -0,0 +1,278 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"zabbix-dna/internal/api"
+
+	"github.com/spf13/cobra"
+)
+
+// userMediaFields are the writable media properties sent back on user.update, which replaces
+// the whole media list.
+var userMediaFields = []string{"mediatypeid", "sendto", "active", "severity", "period"}
+
+func newUserMediaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "media",
+		Short: "Manage the notification media of a user",
+	}
+
+	cmd.AddCommand(newUserMediaAddCmd())
+	cmd.AddCommand(newUserMediaRemoveCmd())
+
+	return cmd
+}
+
+func newUserMediaAddCmd() *cobra.Command {
+	var mediaType string
+	var sendTo string
+	var severities []string
+	var minSeverity string
+	var period string
+	var disabled bool
+
+	cmd := &cobra.Command{
+		Use:   "add [username]",
+		Short: "Add a notification media to a user",
+		Long: `Add a media to a user, keeping the media already configured.
+
+Severities are given as a list (--severity warning,high) or as a minimum (--min-severity
+average); all severities are used by default. The period uses the Zabbix time period syntax,
+e.g. "1-5,09:00-18:00" for working hours.`,
+		Example: `  zabbix-dna user media add jdoe --type Email --sendto jdoe@example.com --min-severity average
+  zabbix-dna user media add jdoe --type SMS --sendto +5511999990000 --severity disaster --period "1-7,00:00-24:00"`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(severities) > 0 && minSeverity != "" {
+				handleError(fmt.Errorf("use either --severity or --min-severity"))
+			}
+			mask, err := severityMask(severities, minSeverity)
+			handleError(err)
+
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			user, err := getUser(client, args[0], map[string]interface{}{"selectMedias": "extend"})
+			handleError(err)
+			mt, err := getMediaType(client, mediaType)
+			handleError(err)
+
+			media := map[string]interface{}{
+				"mediatypeid": mt["mediatypeid"],
+				"sendto":      sendTo,
+				"active":      "0",
+				"severity":    strconv.Itoa(mask),
+				"period":      period,
+			}
+			// Email media take a list of addresses.
+			if fmt.Sprintf("%v", mt["type"]) == "0" {
+				media["sendto"] = []string{sendTo}
+			}
+			if disabled {
+				media["active"] = "1"
+			}
+
+			medias := writableUserMedias(user)
+			for _, m := range medias {
+				if fmt.Sprintf("%v", m["mediatypeid"]) == fmt.Sprintf("%v", mt["mediatypeid"]) && mediaSendTo(m) == sendTo {
+					handleError(fmt.Errorf("user %s already has %s media for %s", args[0], mt["name"], sendTo))
+				}
+			}
+			medias = append(medias, media)
+
+			resp, err := client.Call("user.update", map[string]interface{}{
+				"userid": user["userid"],
+				"medias": medias,
+			})
+			handleError(err)
+			var updateResp map[string]interface{}
+			json.Unmarshal(resp, &updateResp)
+
+			headers := []string{"Username", "Media", "Action", "Status"}
+			rows := [][]string{{args[0], describeUserMedia(media, map[string]string{fmt.Sprintf("%v", mt["mediatypeid"]): fmt.Sprintf("%v", mt["name"])}), "Add Media", "Success"}}
+			outputResult(cmd, updateResp, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringVar(&mediaType, "type", "", "Media type name or ID (e.g. Email)")
+	cmd.Flags().StringVar(&sendTo, "sendto", "", "Address to send to")
+	cmd.Flags().StringSliceVar(&severities, "severity", []string{}, "Severities to notify (names or 0-5)")
+	cmd.Flags().StringVar(&minSeverity, "min-severity", "", "Notify this severity and above")
+	cmd.Flags().StringVar(&period, "period", "1-7,00:00-24:00", "When the media is active")
+	cmd.Flags().BoolVar(&disabled, "disabled", false, "Add the media disabled")
+	cmd.MarkFlagRequired("type")
+	cmd.MarkFlagRequired("sendto")
+
+	return cmd
+}
+
+func newUserMediaRemoveCmd() *cobra.Command {
+	var mediaType string
+	var sendTo string
+
+	cmd := &cobra.Command{
+		Use:   "remove [username]",
+		Short: "Remove notification media from a user",
+		Long: `Remove the user's media of a media type, optionally only the one sending to --sendto.
+Other media are kept.`,
+		Example: `  zabbix-dna user media remove jdoe --type SMS
+  zabbix-dna user media remove jdoe --type Email --sendto old@example.com`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			user, err := getUser(client, args[0], map[string]interface{}{"selectMedias": "extend"})
+			handleError(err)
+			mt, err := getMediaType(client, mediaType)
+			handleError(err)
+
+			var kept []map[string]interface{}
+			var removed []string
+			for _, m := range writableUserMedias(user) {
+				if fmt.Sprintf("%v", m["mediatypeid"]) == fmt.Sprintf("%v", mt["mediatypeid"]) && (sendTo == "" || mediaSendTo(m) == sendTo) {
+					removed = append(removed, mediaSendTo(m))
+					continue
+				}
+				kept = append(kept, m)
+			}
+			if len(removed) == 0 {
+				handleError(fmt.Errorf("user %s has no matching %s media", args[0], mt["name"]))
+			}
+			if kept == nil {
+				kept = []map[string]interface{}{}
+			}
+
+			resp, err := client.Call("user.update", map[string]interface{}{
+				"userid": user["userid"],
+				"medias": kept,
+			})
+			handleError(err)
+			var updateResp map[string]interface{}
+			json.Unmarshal(resp, &updateResp)
+
+			headers := []string{"Username", "Media", "Action", "Status"}
+			var rows [][]string
+			for _, r := range removed {
+				rows = append(rows, []string{args[0], fmt.Sprintf("%v: %s", mt["name"], r), "Remove Media", "Success"})
+			}
+			outputResult(cmd, updateResp, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringVar(&mediaType, "type", "", "Media type name or ID")
+	cmd.Flags().StringVar(&sendTo, "sendto", "", "Only remove the media sending to this address")
+	cmd.MarkFlagRequired("type")
+
+	return cmd
+}
+
+// getMediaType resolves a media type name or ID.
+func getMediaType(client *api.ZabbixClient, ref string) (map[string]interface{}, error) {
+	filter := map[string]interface{}{"name": ref}
+	if _, err := strconv.Atoi(ref); err == nil {
+		filter = map[string]interface{}{"mediatypeid": ref}
+	}
+	types, err := callGetList(client, "mediatype.get", map[string]interface{}{
+		"output": []string{"mediatypeid", "name", "type"},
+		"filter": filter,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(types) == 0 {
+		return nil, fmt.Errorf("media type not found: %s", ref)
+	}
+	return types[0], nil
+}
+
+// getMediaTypeNames maps media type IDs to names.
+func getMediaTypeNames(client *api.ZabbixClient) map[string]string {
+	names := map[string]string{}
+	types, _ := callGetList(client, "mediatype.get", map[string]interface{}{
+		"output": []string{"mediatypeid", "name"},
+	})
+	for _, t := range types {
+		names[fmt.Sprintf("%v", t["mediatypeid"])] = fmt.Sprintf("%v", t["name"])
+	}
+	return names
+}
+
+// writableUserMedias returns the user's media reduced to the properties user.update accepts.
+func writableUserMedias(user map[string]interface{}) []map[string]interface{} {
+	var medias []map[string]interface{}
+	for _, m := range exportList(user["medias"]) {
+		media := map[string]interface{}{}
+		for _, f := range userMediaFields {
+			if v, ok := m[f]; ok {
+				media[f] = v
+			}
+		}
+		medias = append(medias, media)
+	}
+	return medias
+}
+
+func mediaSendTo(m map[string]interface{}) string {
+	if list, ok := m["sendto"].([]interface{}); ok {
+		var parts []string
+		for _, v := range list {
+			parts = append(parts, fmt.Sprintf("%v", v))
+		}
+		return strings.Join(parts, ", ")
+	}
+	if list, ok := m["sendto"].([]string); ok {
+		return strings.Join(list, ", ")
+	}
+	return fmt.Sprintf("%v", m["sendto"])
+}
+
+// describeUserMedia renders a media as "Email: a@b.c (warning, high; 1-7,00:00-24:00)".
+func describeUserMedia(m map[string]interface{}, mediaTypes map[string]string) string {
+	name := mediaTypes[fmt.Sprintf("%v", m["mediatypeid"])]
+	if name == "" {
+		name = fmt.Sprintf("media type %v", m["mediatypeid"])
+	}
+	mask, _ := strconv.Atoi(exportString(m, "severity"))
+	var sev []string
+	for i := 0; i <= 5; i++ {
+		if mask&(1<<i) != 0 {
+			sev = append(sev, strings.ToLower(getPriorityName(strconv.Itoa(i))))
+		}
+	}
+	if mask == 63 {
+		sev = []string{"all severities"}
+	}
+	desc := fmt.Sprintf("%s: %s (%s; %s)", name, mediaSendTo(m), strings.Join(sev, ", "), exportString(m, "period"))
+	if exportString(m, "active") == "1" {
+		desc += " [disabled]"
+	}
+	return desc
+}
+
+// severityMask converts severity names, or a minimum severity, to the media severity bitmask.
+func severityMask(severities []string, minSeverity string) (int, error) {
+	if minSeverity != "" {
+		min, err := parseSeverity(minSeverity)
+		if err != nil {
+			return 0, err
+		}
+		return 63 &^ (1<<min - 1), nil
+	}
+	if len(severities) == 0 {
+		return 63, nil
+	}
+	mask := 0
+	for _, s := range severities {
+		n, err := parseSeverity(s)
+		if err != nil {
+			return 0, err
+		}
+		mask |= 1 << n
+	}
+	return mask, nil
+}
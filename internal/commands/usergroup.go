@@ -3,6 +3,9 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+
+	"zabbix-dna/internal/api"
 
 	"github.com/spf13/cobra"
 )
@@ -14,7 +17,13 @@ func newUserGroupCmd() *cobra.Command {
 	}
 
 	cmd.AddCommand(newUserGroupListCmd())
+	cmd.AddCommand(newUserGroupShowCmd())
+	cmd.AddCommand(newUserGroupCreateCmd())
+	cmd.AddCommand(newUserGroupUpdateCmd())
 	cmd.AddCommand(newUserGroupDeleteCmd())
+	cmd.AddCommand(newUserGroupGrantCmd())
+	cmd.AddCommand(newUserGroupRevokeCmd())
+	cmd.AddCommand(newUserGroupMatrixCmd())
 
 	return cmd
 }
@@ -65,6 +74,239 @@ func newUserGroupListCmd() *cobra.Command {
 	return cmd
 }
 
+func newUserGroupShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "show [group name]",
+		Aliases: []string{"show_usergroup"},
+		Short:   "Show a user group with its settings, users and permissions",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+			keys := getUserGroupRights(client)
+
+			group, err := getUserGroup(client, args[0], keys)
+			handleError(err)
+
+			headers := []string{"Property", "Value"}
+			var rows [][]string
+			rows = append(rows, []string{"UsrGrpID", fmt.Sprintf("%v", group["usrgrpid"])})
+			rows = append(rows, []string{"Name", fmt.Sprintf("%v", group["name"])})
+			rows = append(rows, []string{"Frontend access", getGUIAccessName(exportString(group, "gui_access"))})
+			rows = append(rows, []string{"Debug mode", enabledName(exportString(group, "debug_mode") == "1")})
+			rows = append(rows, []string{"Users status", enabledName(exportString(group, "users_status") != "1")})
+
+			var users []string
+			for _, u := range exportList(group["users"]) {
+				users = append(users, fmt.Sprintf("%v", u["username"]))
+			}
+			rows = append(rows, []string{"Users", strings.Join(users, ", ")})
+
+			rows = append(rows, userGroupRightRows(client, "Host group", "hostgroup.get", exportList(group[keys.hostKey]))...)
+			if keys.templateKey != "" {
+				rows = append(rows, userGroupRightRows(client, "Template group", "templategroup.get", exportList(group[keys.templateKey]))...)
+			}
+
+			names := groupNamesByID(client, "hostgroup.get", nil)
+			for _, f := range exportList(group["tag_filters"]) {
+				filter := exportString(f, "tag")
+				if v := exportString(f, "value"); v != "" {
+					filter += "=" + v
+				}
+				rows = append(rows, []string{"Tag filter", fmt.Sprintf("%s: %s", names[fmt.Sprintf("%v", f["groupid"])], filter)})
+			}
+
+			outputResult(cmd, group, headers, rows)
+		},
+	}
+}
+
+func newUserGroupCreateCmd() *cobra.Command {
+	var opts userGroupOptions
+
+	cmd := &cobra.Command{
+		Use:     "create [group name]",
+		Aliases: []string{"create_usergroup"},
+		Short:   "Create a Zabbix user group",
+		Long:    "Create a user group. Permissions are added afterwards with usergroup grant.",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			params := map[string]interface{}{"name": args[0]}
+			handleError(opts.apply(cmd, params))
+
+			result, err := client.Call("usergroup.create", params)
+			handleError(err)
+
+			var resp map[string]interface{}
+			json.Unmarshal(result, &resp)
+			id := ""
+			if ids, ok := resp["usrgrpids"].([]interface{}); ok && len(ids) > 0 {
+				id = fmt.Sprintf("%v", ids[0])
+			}
+
+			headers := []string{"User Group", "Action", "Status", "ID"}
+			rows := [][]string{{args[0], "Create", "Success", id}}
+			outputResult(cmd, resp, headers, rows)
+		},
+	}
+
+	opts.addFlags(cmd)
+
+	return cmd
+}
+
+func newUserGroupUpdateCmd() *cobra.Command {
+	var opts userGroupOptions
+	var name string
+
+	cmd := &cobra.Command{
+		Use:   "update [group name]",
+		Short: "Update the frontend access, debug mode or status of a user group",
+		Example: `  zabbix-dna usergroup update "API users" --frontend-access disabled
+  zabbix-dna usergroup update Developers --debug`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+
+			groups, err := callGetList(client, "usergroup.get", map[string]interface{}{
+				"output": []string{"usrgrpid"},
+				"filter": map[string]interface{}{"name": args[0]},
+			})
+			handleError(err)
+			if len(groups) == 0 {
+				handleError(fmt.Errorf("user group not found: %s", args[0]))
+			}
+
+			params := map[string]interface{}{"usrgrpid": groups[0]["usrgrpid"]}
+			if name != "" {
+				params["name"] = name
+			}
+			handleError(opts.apply(cmd, params))
+			if len(params) == 1 {
+				handleError(fmt.Errorf("nothing to update"))
+			}
+
+			result, err := client.Call("usergroup.update", params)
+			handleError(err)
+
+			var resp map[string]interface{}
+			json.Unmarshal(result, &resp)
+
+			headers := []string{"User Group", "Action", "Status"}
+			rows := [][]string{{args[0], "Update", "Success"}}
+			outputResult(cmd, resp, headers, rows)
+		},
+	}
+
+	opts.addFlags(cmd)
+	cmd.Flags().StringVar(&name, "name", "", "New name")
+
+	return cmd
+}
+
+// guiAccessCodes maps CLI names of frontend access modes to Zabbix codes.
+var guiAccessCodes = map[string]string{
+	"default":  "0",
+	"internal": "1",
+	"ldap":     "2",
+	"disabled": "3",
+}
+
+// userGroupOptions holds the user group settings shared by create and update.
+type userGroupOptions struct {
+	frontendAccess string
+	debug          bool
+	disabled       bool
+}
+
+func (o *userGroupOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.frontendAccess, "frontend-access", "default", "Frontend access: default, internal, ldap or disabled")
+	cmd.Flags().BoolVar(&o.debug, "debug", false, "Enable debug mode for the group's users")
+	cmd.Flags().BoolVar(&o.disabled, "disabled", false, "Disable the group's users")
+}
+
+// apply adds the changed settings to usergroup params.
+func (o *userGroupOptions) apply(cmd *cobra.Command, params map[string]interface{}) error {
+	if cmd.Flags().Changed("frontend-access") {
+		code, ok := guiAccessCodes[o.frontendAccess]
+		if !ok {
+			return fmt.Errorf("invalid frontend access: %s (use default, internal, ldap or disabled)", o.frontendAccess)
+		}
+		params["gui_access"] = code
+	}
+	if cmd.Flags().Changed("debug") {
+		params["debug_mode"] = boolCode(o.debug)
+	}
+	if cmd.Flags().Changed("disabled") {
+		params["users_status"] = boolCode(o.disabled)
+	}
+	return nil
+}
+
+func boolCode(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func enabledName(enabled bool) string {
+	if enabled {
+		return "Enabled"
+	}
+	return "Disabled"
+}
+
+func getGUIAccessName(code string) string {
+	for name, c := range guiAccessCodes {
+		if c == code {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// userGroupRightRows lists the permissions of a user group, one row per group.
+func userGroupRightRows(client *api.ZabbixClient, label, method string, rights []map[string]interface{}) [][]string {
+	if len(rights) == 0 {
+		return nil
+	}
+	var ids []string
+	for _, r := range rights {
+		ids = append(ids, fmt.Sprintf("%v", r["id"]))
+	}
+	names := groupNamesByID(client, method, ids)
+	var rows [][]string
+	for _, r := range rights {
+		perm := "deny"
+		for name, code := range permissionCodes {
+			if code == fmt.Sprintf("%v", r["permission"]) {
+				perm = name
+			}
+		}
+		rows = append(rows, []string{label, fmt.Sprintf("%s: %s", names[fmt.Sprintf("%v", r["id"])], perm)})
+	}
+	return rows
+}
+
+// groupNamesByID maps host or template group IDs to names; nil IDs fetch every group.
+func groupNamesByID(client *api.ZabbixClient, method string, ids []string) map[string]string {
+	params := map[string]interface{}{"output": []string{"groupid", "name"}}
+	if ids != nil {
+		params["groupids"] = ids
+	}
+	names := map[string]string{}
+	groups, _ := callGetList(client, method, params)
+	for _, g := range groups {
+		names[fmt.Sprintf("%v", g["groupid"])] = fmt.Sprintf("%v", g["name"])
+	}
+	return names
+}
+
 func newUserGroupDeleteCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "delete [group name]",
@@ -107,5 +349,3 @@ func newUserGroupDeleteCmd() *cobra.Command {
 		},
 	}
 }
-
-
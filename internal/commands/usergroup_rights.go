@@ -0,0 +1,510 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"zabbix-dna/internal/api"
+
+	"github.com/spf13/cobra"
+)
+
+// permissionCodes maps CLI permission names to Zabbix codes.
+var permissionCodes = map[string]string{
+	"deny":  "0",
+	"read":  "2",
+	"write": "3",
+}
+
+// userGroupRights names the select parameters and result keys of user group rights. Zabbix 6.2
+// split "rights" into host group and template group rights.
+type userGroupRights struct {
+	hostParam, hostKey         string
+	templateParam, templateKey string
+}
+
+func getUserGroupRights(client *api.ZabbixClient) userGroupRights {
+	if apiVersionAtLeast(getAPIVersion(client), 6, 2) {
+		return userGroupRights{"selectHostGroupRights", "hostgroup_rights", "selectTemplateGroupRights", "templategroup_rights"}
+	}
+	return userGroupRights{hostParam: "selectRights", hostKey: "rights"}
+}
+
+func newUserGroupGrantCmd() *cobra.Command {
+	var hostgroups []string
+	var templategroups []string
+	var permission string
+	var tags []string
+	var subgroups bool
+
+	cmd := &cobra.Command{
+		Use:   "grant [user group]",
+		Short: "Grant a user group access to host or template groups",
+		Long: `Set the permission of a user group on host groups or template groups (Zabbix 6.2+).
+Existing rights on other groups are kept.
+
+--tag adds tag-based permissions: with them the user group only sees problems of the host
+group that carry the tag (and value, when given as tag=value).`,
+		Example: `  zabbix-dna usergroup grant Ops --hostgroup "Linux servers" --permission write
+  zabbix-dna usergroup grant DBA --hostgroup Databases --subgroups --permission read --tag service=mysql
+  zabbix-dna usergroup grant Contractors --templategroup Templates/Applications --permission deny`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			code, ok := permissionCodes[permission]
+			if !ok {
+				handleError(fmt.Errorf("invalid permission: %s (use read, write or deny)", permission))
+			}
+			if len(hostgroups) == 0 && len(templategroups) == 0 {
+				handleError(fmt.Errorf("specify at least one --hostgroup or --templategroup"))
+			}
+			if len(tags) > 0 && len(templategroups) > 0 {
+				handleError(fmt.Errorf("tag-based permissions apply to host groups only"))
+			}
+			tagFilters, err := parseTagFlags(tags)
+			handleError(err)
+
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+			keys := getUserGroupRights(client)
+			if len(templategroups) > 0 && keys.templateKey == "" {
+				handleError(fmt.Errorf("template group permissions need Zabbix 6.2 or later"))
+			}
+
+			group, err := getUserGroup(client, args[0], keys)
+			handleError(err)
+
+			update := map[string]interface{}{"usrgrpid": group["usrgrpid"]}
+			headers := []string{"User Group", "Group", "Type", "Permission", "Tag filter"}
+			var rows [][]string
+
+			if len(hostgroups) > 0 {
+				ids, err := resolveGroupNames(client, "hostgroup.get", hostgroups, subgroups)
+				handleError(err)
+				update[keys.hostKey] = setRights(exportList(group[keys.hostKey]), ids, code)
+				if len(tagFilters) > 0 {
+					filters := writableTagFilters(group)
+					for _, id := range idsByName(ids) {
+						for _, t := range tagFilters {
+							if !hasTagFilter(filters, id, t["tag"], t["value"]) {
+								filters = append(filters, map[string]interface{}{"groupid": id, "tag": t["tag"], "value": t["value"]})
+							}
+						}
+					}
+					update["tag_filters"] = filters
+				}
+				for _, id := range idsByName(ids) {
+					rows = append(rows, []string{args[0], ids[id], "Host group", permission, strings.Join(tags, ", ")})
+				}
+			}
+			if len(templategroups) > 0 {
+				ids, err := resolveGroupNames(client, "templategroup.get", templategroups, subgroups)
+				handleError(err)
+				update[keys.templateKey] = setRights(exportList(group[keys.templateKey]), ids, code)
+				for _, id := range idsByName(ids) {
+					rows = append(rows, []string{args[0], ids[id], "Template group", permission, ""})
+				}
+			}
+
+			_, err = client.Call("usergroup.update", update)
+			handleError(err)
+
+			outputResult(cmd, update, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&hostgroups, "hostgroup", []string{}, "Host group name(s)")
+	cmd.Flags().StringSliceVar(&templategroups, "templategroup", []string{}, "Template group name(s)")
+	cmd.Flags().StringVar(&permission, "permission", "read", "Permission: read, write or deny")
+	cmd.Flags().StringArrayVar(&tags, "tag", []string{}, "Limit problem visibility to a tag (tag or tag=value)")
+	cmd.Flags().BoolVar(&subgroups, "subgroups", false, "Include subgroups (groups named <group>/...)")
+
+	return cmd
+}
+
+func newUserGroupRevokeCmd() *cobra.Command {
+	var hostgroups []string
+	var templategroups []string
+	var tags []string
+	var subgroups bool
+
+	cmd := &cobra.Command{
+		Use:   "revoke [user group]",
+		Short: "Revoke a user group's access to host or template groups",
+		Long: `Remove the permission of a user group on host groups or template groups, together with
+the tag filters of those host groups. With --tag only the matching tag filters are removed
+and the permission is kept.`,
+		Example: `  zabbix-dna usergroup revoke Contractors --hostgroup "Linux servers"
+  zabbix-dna usergroup revoke DBA --hostgroup Databases --tag service=mysql`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(hostgroups) == 0 && len(templategroups) == 0 {
+				handleError(fmt.Errorf("specify at least one --hostgroup or --templategroup"))
+			}
+			tagFilters, err := parseTagFlags(tags)
+			handleError(err)
+
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+			keys := getUserGroupRights(client)
+			if len(templategroups) > 0 && keys.templateKey == "" {
+				handleError(fmt.Errorf("template group permissions need Zabbix 6.2 or later"))
+			}
+
+			group, err := getUserGroup(client, args[0], keys)
+			handleError(err)
+
+			update := map[string]interface{}{"usrgrpid": group["usrgrpid"]}
+			headers := []string{"User Group", "Group", "Type", "Revoked"}
+			var rows [][]string
+
+			if len(hostgroups) > 0 {
+				ids, err := resolveGroupNames(client, "hostgroup.get", hostgroups, subgroups)
+				handleError(err)
+
+				var filters []map[string]interface{}
+				for _, f := range writableTagFilters(group) {
+					id := fmt.Sprintf("%v", f["groupid"])
+					if _, ok := ids[id]; ok && (len(tagFilters) == 0 || matchesTagFlags(f, tagFilters)) {
+						continue
+					}
+					filters = append(filters, f)
+				}
+				if filters == nil {
+					filters = []map[string]interface{}{}
+				}
+				update["tag_filters"] = filters
+
+				what := "permission, tag filters"
+				if len(tagFilters) > 0 {
+					what = "tag filters " + strings.Join(tags, ", ")
+				} else {
+					update[keys.hostKey] = setRights(exportList(group[keys.hostKey]), ids, "")
+				}
+				for _, id := range idsByName(ids) {
+					rows = append(rows, []string{args[0], ids[id], "Host group", what})
+				}
+			}
+			if len(templategroups) > 0 {
+				ids, err := resolveGroupNames(client, "templategroup.get", templategroups, subgroups)
+				handleError(err)
+				update[keys.templateKey] = setRights(exportList(group[keys.templateKey]), ids, "")
+				for _, id := range idsByName(ids) {
+					rows = append(rows, []string{args[0], ids[id], "Template group", "permission"})
+				}
+			}
+
+			_, err = client.Call("usergroup.update", update)
+			handleError(err)
+
+			outputResult(cmd, update, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&hostgroups, "hostgroup", []string{}, "Host group name(s)")
+	cmd.Flags().StringSliceVar(&templategroups, "templategroup", []string{}, "Template group name(s)")
+	cmd.Flags().StringArrayVar(&tags, "tag", []string{}, "Only remove these tag filters (tag or tag=value)")
+	cmd.Flags().BoolVar(&subgroups, "subgroups", false, "Include subgroups (groups named <group>/...)")
+
+	return cmd
+}
+
+func newUserGroupMatrixCmd() *cobra.Command {
+	var hostgroups []string
+	var users []string
+	var templateGroups bool
+
+	cmd := &cobra.Command{
+		Use:   "matrix",
+		Short: "Show the effective access of users to host groups",
+		Long: `Show a user × host group matrix of effective permissions, combining every user group a
+user belongs to the way Zabbix does: deny wins, otherwise the highest permission applies.
+
+RW = read-write, R = read-only, D = denied, - = no access. Super admins have full access (SA).
+Users in a disabled user group are marked (disabled). A "T" marks host groups where the
+access is limited by tag filters, that is where every user group granting access applies one.`,
+		Example: `  zabbix-dna usergroup matrix --hostgroup "Linux servers" --hostgroup Databases
+  zabbix-dna usergroup matrix --templategroups`,
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := getZabbixClient(cmd)
+			handleError(err)
+			keys := getUserGroupRights(client)
+
+			method, rightsKey := "hostgroup.get", keys.hostKey
+			if templateGroups {
+				if keys.templateKey == "" {
+					handleError(fmt.Errorf("template group permissions need Zabbix 6.2 or later"))
+				}
+				method, rightsKey = "templategroup.get", keys.templateKey
+			}
+
+			groupParams := map[string]interface{}{
+				"output":    []string{"groupid", "name"},
+				"sortfield": "name",
+			}
+			if len(hostgroups) > 0 {
+				groupParams["filter"] = map[string]interface{}{"name": hostgroups}
+			}
+			groups, err := callGetList(client, method, groupParams)
+			handleError(err)
+			for _, name := range hostgroups {
+				found := false
+				for _, g := range groups {
+					if fmt.Sprintf("%v", g["name"]) == name {
+						found = true
+						break
+					}
+				}
+				if !found {
+					handleError(fmt.Errorf("group not found: %s", name))
+				}
+			}
+			if len(groups) == 0 {
+				handleError(fmt.Errorf("no groups found"))
+			}
+
+			userParams := map[string]interface{}{
+				"output":     []string{"userid", "username"},
+				"selectRole": []string{"type"},
+				"sortfield":  "username",
+			}
+			if len(users) > 0 {
+				userParams["filter"] = map[string]interface{}{"username": users}
+			}
+			userList, err := callGetList(client, "user.get", userParams)
+			handleError(err)
+			var userIDs []string
+			for _, u := range userList {
+				userIDs = append(userIDs, fmt.Sprintf("%v", u["userid"]))
+			}
+
+			ugParams := map[string]interface{}{
+				"output":           []string{"usrgrpid", "name", "users_status"},
+				"selectUsers":      []string{"userid"},
+				keys.hostParam:     "extend",
+				"selectTagFilters": "extend",
+				"userids":          userIDs,
+			}
+			if keys.templateParam != "" {
+				ugParams[keys.templateParam] = "extend"
+			}
+			userGroups, err := callGetList(client, "usergroup.get", ugParams)
+			handleError(err)
+
+			headers := []string{"User"}
+			for _, g := range groups {
+				headers = append(headers, fmt.Sprintf("%v", g["name"]))
+			}
+			var rows [][]string
+			matrix := map[string]map[string]string{}
+			for _, u := range userList {
+				userID := fmt.Sprintf("%v", u["userid"])
+				username := fmt.Sprintf("%v", u["username"])
+				superAdmin := false
+				if role, ok := u["role"].(map[string]interface{}); ok && fmt.Sprintf("%v", role["type"]) == "3" {
+					superAdmin = true
+				}
+
+				perms := map[string]string{}
+				// Per group: user groups granting access, and how many of them filter by tags.
+				granting := map[string]int{}
+				tagged := map[string]int{}
+				disabled := false
+				for _, ug := range userGroups {
+					member := false
+					for _, m := range exportList(ug["users"]) {
+						if fmt.Sprintf("%v", m["userid"]) == userID {
+							member = true
+							break
+						}
+					}
+					if !member {
+						continue
+					}
+					if fmt.Sprintf("%v", ug["users_status"]) == "1" {
+						disabled = true
+					}
+					filtered := map[string]bool{}
+					if !templateGroups {
+						for _, f := range exportList(ug["tag_filters"]) {
+							filtered[fmt.Sprintf("%v", f["groupid"])] = true
+						}
+					}
+					for _, r := range exportList(ug[rightsKey]) {
+						id := fmt.Sprintf("%v", r["id"])
+						permission := fmt.Sprintf("%v", r["permission"])
+						perms[id] = combinePermissions(perms[id], permission)
+						if permission != "0" {
+							granting[id]++
+							if filtered[id] {
+								tagged[id]++
+							}
+						}
+					}
+				}
+
+				label := username
+				if disabled {
+					label += " (disabled)"
+				}
+				row := []string{label}
+				matrix[username] = map[string]string{}
+				for _, g := range groups {
+					id := fmt.Sprintf("%v", g["groupid"])
+					cell := permissionShortName(perms[id])
+					if superAdmin {
+						cell = "SA"
+					} else if granting[id] > 0 && tagged[id] == granting[id] && perms[id] != "0" {
+						cell += " T"
+					}
+					row = append(row, cell)
+					matrix[username][fmt.Sprintf("%v", g["name"])] = cell
+				}
+				rows = append(rows, row)
+			}
+
+			outputResult(cmd, matrix, headers, rows)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&hostgroups, "hostgroup", []string{}, "Only show these groups")
+	cmd.Flags().StringSliceVar(&users, "user", []string{}, "Only show these users")
+	cmd.Flags().BoolVar(&templateGroups, "templategroups", false, "Show template groups instead of host groups (Zabbix 6.2+)")
+
+	return cmd
+}
+
+// getUserGroup returns a user group by name with its rights and tag filters.
+func getUserGroup(client *api.ZabbixClient, name string, keys userGroupRights) (map[string]interface{}, error) {
+	params := map[string]interface{}{
+		"output":           "extend",
+		"filter":           map[string]interface{}{"name": name},
+		keys.hostParam:     "extend",
+		"selectTagFilters": "extend",
+		"selectUsers":      []string{"userid", "username"},
+	}
+	if keys.templateParam != "" {
+		params[keys.templateParam] = "extend"
+	}
+	groups, err := callGetList(client, "usergroup.get", params)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("user group not found: %s", name)
+	}
+	return groups[0], nil
+}
+
+// resolveGroupNames returns host or template group IDs mapped to names, failing on unknown
+// groups. With subgroups, groups nested below each name are included.
+func resolveGroupNames(client *api.ZabbixClient, method string, names []string, subgroups bool) (map[string]string, error) {
+	ids := map[string]string{}
+	for _, name := range names {
+		groups, err := callGetList(client, method, map[string]interface{}{
+			"output": []string{"groupid", "name"},
+			"filter": map[string]interface{}{"name": name},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(groups) == 0 {
+			return nil, fmt.Errorf("group not found: %s", name)
+		}
+		if subgroups {
+			nested, err := callGetList(client, method, map[string]interface{}{
+				"output":      []string{"groupid", "name"},
+				"search":      map[string]interface{}{"name": name + "/"},
+				"startSearch": true,
+			})
+			if err != nil {
+				return nil, err
+			}
+			groups = append(groups, nested...)
+		}
+		for _, g := range groups {
+			ids[fmt.Sprintf("%v", g["groupid"])] = fmt.Sprintf("%v", g["name"])
+		}
+	}
+	return ids, nil
+}
+
+// setRights returns the rights with the given groups set to permission, or removed when the
+// permission is empty. usergroup.update replaces the whole list.
+func setRights(existing []map[string]interface{}, ids map[string]string, permission string) []map[string]interface{} {
+	rights := []map[string]interface{}{}
+	for _, r := range existing {
+		if _, ok := ids[fmt.Sprintf("%v", r["id"])]; ok {
+			continue
+		}
+		rights = append(rights, map[string]interface{}{"id": r["id"], "permission": r["permission"]})
+	}
+	if permission != "" {
+		for _, id := range idsByName(ids) {
+			rights = append(rights, map[string]interface{}{"id": id, "permission": permission})
+		}
+	}
+	return rights
+}
+
+func writableTagFilters(group map[string]interface{}) []map[string]interface{} {
+	var filters []map[string]interface{}
+	for _, f := range exportList(group["tag_filters"]) {
+		filters = append(filters, map[string]interface{}{"groupid": f["groupid"], "tag": f["tag"], "value": f["value"]})
+	}
+	return filters
+}
+
+func hasTagFilter(filters []map[string]interface{}, groupID, tag, value string) bool {
+	for _, f := range filters {
+		if fmt.Sprintf("%v", f["groupid"]) == groupID && exportString(f, "tag") == tag && exportString(f, "value") == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesTagFlags reports whether a tag filter matches one of the --tag flags; a flag without
+// value matches every value of the tag.
+func matchesTagFlags(f map[string]interface{}, tags []map[string]string) bool {
+	for _, t := range tags {
+		if exportString(f, "tag") == t["tag"] && (t["value"] == "" || exportString(f, "value") == t["value"]) {
+			return true
+		}
+	}
+	return false
+}
+
+// combinePermissions merges rights from several user groups: deny wins, otherwise the highest.
+func combinePermissions(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case a == "0" || b == "0":
+		return "0"
+	case b > a:
+		return b
+	}
+	return a
+}
+
+func permissionShortName(p string) string {
+	switch p {
+	case "0":
+		return "D"
+	case "2":
+		return "R"
+	case "3":
+		return "RW"
+	}
+	return "-"
+}
+
+func idsByName(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return m[keys[i]] < m[keys[j]] })
+	return keys
+}
@@ -0,0 +1,27 @@
+package commands
+
+import "testing"
+
+func TestCombinePermissions(t *testing.T) {
+	tests := []struct {
+		perms []string
+		want  string
+	}{
+		{[]string{"2"}, "2"},
+		{[]string{"2", "3"}, "3"},
+		{[]string{"3", "2"}, "3"},
+		{[]string{"3", "0"}, "0"},
+		{[]string{"0", "3"}, "0"},
+		{[]string{"2", "0", "3"}, "0"},
+		{[]string{"0"}, "0"},
+	}
+	for _, tt := range tests {
+		got := ""
+		for _, p := range tt.perms {
+			got = combinePermissions(got, p)
+		}
+		if got != tt.want {
+			t.Errorf("combinePermissions(%v) = %q, want %q", tt.perms, got, tt.want)
+		}
+	}
+}
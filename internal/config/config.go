@@ -8,11 +8,14 @@ import (
 )
 
 type Config struct {
-	API     APIConfig     `toml:"api"`
-	App     AppConfig     `toml:"app"`
-	Logging LoggingConfig `toml:"logging"`
-	OTLP    OTLPConfig    `toml:"otlp"`
-	Salt    SaltConfig    `toml:"salt"`
+	API     APIConfig            `toml:"api"`
+	Servers map[string]APIConfig `toml:"servers"` // additional named servers, e.g. [servers.prod]
+	App     AppConfig            `toml:"app"`
+	Logging LoggingConfig        `toml:"logging"`
+	OTLP    OTLPConfig           `toml:"otlp"`
+	Salt    SaltConfig           `toml:"salt"`
+	Lint    LintConfig           `toml:"lint"`
+	Users   UsersConfig          `toml:"users"`
 }
 
 type APIConfig struct {
@@ -68,6 +71,21 @@ type SaltConfig struct {
 	EAuth    string `toml:"eauth"`
 }
 
+// LintConfig tunes the template linter: rules can be disabled or given another severity
+// ("error", "warning", "info" or "off"), and retention limits set the policy.
+type LintConfig struct {
+	DisabledRules []string          `toml:"disabled_rules"`
+	Severity      map[string]string `toml:"severity"`
+	MaxHistory    string            `toml:"max_history"`
+	MaxTrends     string            `toml:"max_trends"`
+}
+
+// UsersConfig names the user group used by user enable/disable. The group must have
+// users_status disabled; it is created on first use when missing.
+type UsersConfig struct {
+	DisabledGroup string `toml:"disabled_group"`
+}
+
 type OTLPConfig struct {
 	Endpoint    string `toml:"endpoint"`
 	Protocol    string `toml:"protocol"`
@@ -92,6 +110,12 @@ func LoadConfig(path string) (*Config, error) {
 	if cfg.API.Timeout == 0 {
 		cfg.API.Timeout = 30
 	}
+	for name, server := range cfg.Servers {
+		if server.Timeout == 0 {
+			server.Timeout = 30
+			cfg.Servers[name] = server
+		}
+	}
 	if cfg.App.Output.Format == "" {
 		cfg.App.Output.Format = "table"
 	}
@@ -118,6 +142,15 @@ func LoadConfig(path string) (*Config, error) {
 	if cfg.OTLP.ServiceName == "" {
 		cfg.OTLP.ServiceName = "zabbix-dna"
 	}
+	if cfg.Lint.MaxHistory == "" {
+		cfg.Lint.MaxHistory = "90d"
+	}
+	if cfg.Lint.MaxTrends == "" {
+		cfg.Lint.MaxTrends = "365d"
+	}
+	if cfg.Users.DisabledGroup == "" {
+		cfg.Users.DisabledGroup = "Disabled"
+	}
 	if cfg.Logging.LogLevel == "" {
 		cfg.Logging.LogLevel = "INFO"
 	}
@@ -0,0 +1,55 @@
+package expression
+
+// historyFunctions take an item query as their first argument.
+var historyFunctions = map[string]bool{
+	"avg": true, "baselinedev": true, "baselinewma": true, "change": true, "changecount": true,
+	"count": true, "countunique": true, "find": true, "first": true, "forecast": true,
+	"fuzzytime": true, "item_count": true, "jsonpath": true, "kurtosis": true, "last": true,
+	"logeventid": true, "logseverity": true, "logsource": true, "logtimestamp": true, "mad": true,
+	"max": true, "min": true, "monodec": true, "monoinc": true, "nodata": true,
+	"percentile": true, "rate": true, "skewness": true, "stddevpop": true, "stddevsamp": true,
+	"sum": true, "sumofsquares": true, "timeleft": true, "trendavg": true, "trendcount": true,
+	"trendmax": true, "trendmin": true, "trendstl": true, "trendsum": true, "varpop": true,
+	"varsamp": true, "xmlxpath": true,
+
+	// Aggregate functions over several items (calculated items, 6.0+)
+	"avg_foreach": true, "bucket_rate_foreach": true, "count_foreach": true, "exists_foreach": true,
+	"last_foreach": true, "max_foreach": true, "min_foreach": true, "sum_foreach": true,
+}
+
+// valueFunctions operate on values and expressions.
+var valueFunctions = map[string]bool{
+	// Math
+	"abs": true, "acos": true, "asin": true, "atan": true, "atan2": true, "cbrt": true,
+	"ceil": true, "cos": true, "cosh": true, "cot": true, "degrees": true, "e": true,
+	"exp": true, "expm1": true, "floor": true, "log": true, "log10": true, "mod": true,
+	"pi": true, "power": true, "radians": true, "rand": true, "round": true, "signum": true,
+	"sin": true, "sinh": true, "sqrt": true, "tan": true, "truncate": true,
+
+	// Operators
+	"between": true, "in": true,
+
+	// String
+	"ascii": true, "bitlength": true, "bytelength": true, "char": true, "concat": true,
+	"insert": true, "left": true, "length": true, "ltrim": true, "mid": true, "repeat": true,
+	"replace": true, "right": true, "rtrim": true, "trim": true,
+
+	// Date and time
+	"date": true, "dayofmonth": true, "dayofweek": true, "now": true, "time": true,
+
+	// Bitwise
+	"bitand": true, "bitlshift": true, "bitnot": true, "bitor": true, "bitrshift": true, "bitxor": true,
+
+	// Histograms
+	"bucket_percentile": true, "histogram_quantile": true,
+}
+
+// IsKnownFunction reports whether name is a function supported by Zabbix 6.0 or later.
+func IsKnownFunction(name string) bool {
+	return historyFunctions[name] || valueFunctions[name]
+}
+
+// IsHistoryFunction reports whether name reads item history and so takes an item query.
+func IsHistoryFunction(name string) bool {
+	return historyFunctions[name]
+}
@@ -0,0 +1,89 @@
+package expression
+
+import "strings"
+
+// NormalizeKey rewrites an item key to a canonical form so keys that the Zabbix server treats
+// as equal compare equal as text: spaces around parameters are dropped and parameters are only
+// quoted when they have to be, so `key[ a, "b" ]` and `key[a,b]` both become `key[a,b]`.
+// Keys that cannot be parsed are returned unchanged.
+func NormalizeKey(key string) string {
+	open := strings.IndexByte(key, '[')
+	if open < 0 || !strings.HasSuffix(key, "]") {
+		return key
+	}
+	params, rest, ok := splitKeyParams(key[open+1:])
+	if !ok || rest != "" {
+		return key
+	}
+	return key[:open] + "[" + strings.Join(params, ",") + "]"
+}
+
+// splitKeyParams parses the parameters after an opening '[' up to the matching ']' and returns
+// them normalized, together with whatever follows the closing bracket.
+func splitKeyParams(s string) (params []string, rest string, ok bool) {
+	i := 0
+	for {
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		if i >= len(s) {
+			return nil, "", false
+		}
+		var param string
+		switch s[i] {
+		case '"':
+			var b strings.Builder
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && s[i+1] == '"' {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, "", false
+			}
+			i++
+			param = quoteKeyParam(b.String())
+			for i < len(s) && s[i] == ' ' {
+				i++
+			}
+		case '[':
+			nested, after, ok := splitKeyParams(s[i+1:])
+			if !ok {
+				return nil, "", false
+			}
+			param = "[" + strings.Join(nested, ",") + "]"
+			i = len(s) - len(after)
+			for i < len(s) && s[i] == ' ' {
+				i++
+			}
+		default:
+			start := i
+			for i < len(s) && s[i] != ',' && s[i] != ']' {
+				i++
+			}
+			param = quoteKeyParam(strings.TrimRight(s[start:i], " "))
+		}
+		if i >= len(s) {
+			return nil, "", false
+		}
+		params = append(params, param)
+		switch s[i] {
+		case ',':
+			i++
+		case ']':
+			return params, s[i+1:], true
+		default:
+			return nil, "", false
+		}
+	}
+}
+
+// quoteKeyParam quotes a parameter value only when it cannot be written unquoted.
+func quoteKeyParam(v string) string {
+	if !strings.ContainsAny(v, ",]\"") && !strings.HasPrefix(v, "[") && !strings.HasPrefix(v, " ") {
+		return v
+	}
+	return `"` + strings.ReplaceAll(v, `"`, `\"`) + `"`
+}
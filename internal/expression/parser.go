@@ -0,0 +1,648 @@
+// Package expression parses Zabbix 6.0+ trigger and calculated item expressions offline,
+// so syntax errors can be reported with a position before anything is sent to the API.
+package expression
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Node is an element of a parsed expression.
+type Node interface {
+	Position() int
+}
+
+// BinaryExpr is an operation between two operands, e.g. "a > 5" or "a and b".
+type BinaryExpr struct {
+	Pos         int
+	Op          string
+	Left, Right Node
+}
+
+// UnaryExpr is a negation: "-x" or "not x".
+type UnaryExpr struct {
+	Pos int
+	Op  string
+	X   Node
+}
+
+// NumberLit is a numeric constant, kept as written including its suffix (e.g. "5m", "10K").
+type NumberLit struct {
+	Pos   int
+	Value string
+}
+
+// StringLit is a double-quoted string constant, unescaped.
+type StringLit struct {
+	Pos   int
+	Value string
+}
+
+// MacroRef is a user macro ({$NAME}), LLD macro ({#NAME}) or built-in macro ({HOST.HOST}).
+type MacroRef struct {
+	Pos  int
+	Name string
+}
+
+// FunctionCall is a function with its arguments.
+type FunctionCall struct {
+	Pos  int
+	Name string
+	Args []Node
+}
+
+// ItemQuery is an item reference such as /host/key[params]?[filter].
+type ItemQuery struct {
+	Pos    int
+	Host   string
+	Key    string
+	Filter string
+}
+
+// PeriodArg is a function period or time shift argument, e.g. "#5" or "1h:now/d".
+type PeriodArg struct {
+	Pos   int
+	Value string
+}
+
+// EmptyArg is an omitted optional function argument.
+type EmptyArg struct {
+	Pos int
+}
+
+func (n *BinaryExpr) Position() int   { return n.Pos }
+func (n *UnaryExpr) Position() int    { return n.Pos }
+func (n *NumberLit) Position() int    { return n.Pos }
+func (n *StringLit) Position() int    { return n.Pos }
+func (n *MacroRef) Position() int     { return n.Pos }
+func (n *FunctionCall) Position() int { return n.Pos }
+func (n *ItemQuery) Position() int    { return n.Pos }
+func (n *PeriodArg) Position() int    { return n.Pos }
+func (n *EmptyArg) Position() int     { return n.Pos }
+
+// Error is a syntax error at a byte offset of the expression.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos+1)
+}
+
+// FormatError renders err under the expression with a caret pointing at the error position.
+func FormatError(src string, err error) string {
+	perr, ok := err.(*Error)
+	if !ok {
+		return err.Error()
+	}
+	return fmt.Sprintf("%s\n  %s\n  %s^", perr.Error(), src, strings.Repeat(" ", perr.Pos))
+}
+
+// Parse parses an expression and returns its syntax tree.
+func Parse(src string) (Node, error) {
+	p := &parser{src: src}
+	p.skipSpace()
+	if p.pos == len(p.src) {
+		return nil, p.errorf("empty expression")
+	}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:p.pos+1])
+	}
+	return n, nil
+}
+
+// Walk calls fn for n and every node below it, depth first. Children are skipped when fn returns false.
+func Walk(n Node, fn func(Node) bool) {
+	if n == nil || !fn(n) {
+		return
+	}
+	switch v := n.(type) {
+	case *BinaryExpr:
+		Walk(v.Left, fn)
+		Walk(v.Right, fn)
+	case *UnaryExpr:
+		Walk(v.X, fn)
+	case *FunctionCall:
+		for _, a := range v.Args {
+			Walk(a, fn)
+		}
+	}
+}
+
+// Functions returns every function call in the expression.
+func Functions(n Node) []*FunctionCall {
+	var out []*FunctionCall
+	Walk(n, func(n Node) bool {
+		if f, ok := n.(*FunctionCall); ok {
+			out = append(out, f)
+		}
+		return true
+	})
+	return out
+}
+
+// ItemQueries returns every item reference in the expression.
+func ItemQueries(n Node) []*ItemQuery {
+	var out []*ItemQuery
+	Walk(n, func(n Node) bool {
+		if q, ok := n.(*ItemQuery); ok {
+			out = append(out, q)
+		}
+		return true
+	})
+	return out
+}
+
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &Error{Pos: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && strings.ContainsRune(" \t\r\n", rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+// keyword reports whether a whole-word keyword starts at the current position.
+func (p *parser) keyword(word string) bool {
+	if !strings.HasPrefix(p.src[p.pos:], word) {
+		return false
+	}
+	end := p.pos + len(word)
+	return end == len(p.src) || !isIdentChar(p.src[end])
+}
+
+// Operator precedence, lowest first: or, and, = <>, < <= > >=, + -, * /, unary - not.
+
+func (p *parser) parseOr() (Node, error) {
+	return p.parseBinary(p.parseAnd, func() string {
+		if p.keyword("or") {
+			return "or"
+		}
+		return ""
+	})
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	return p.parseBinary(p.parseEquality, func() string {
+		if p.keyword("and") {
+			return "and"
+		}
+		return ""
+	})
+}
+
+func (p *parser) parseEquality() (Node, error) {
+	return p.parseBinary(p.parseComparison, func() string {
+		switch {
+		case strings.HasPrefix(p.src[p.pos:], "<>"):
+			return "<>"
+		case p.peek() == '=':
+			return "="
+		}
+		return ""
+	})
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	return p.parseBinary(p.parseAdditive, func() string {
+		rest := p.src[p.pos:]
+		switch {
+		case strings.HasPrefix(rest, "<="), strings.HasPrefix(rest, ">="):
+			return rest[:2]
+		case strings.HasPrefix(rest, "<>"):
+			return ""
+		case strings.HasPrefix(rest, "<"), strings.HasPrefix(rest, ">"):
+			return rest[:1]
+		}
+		return ""
+	})
+}
+
+func (p *parser) parseAdditive() (Node, error) {
+	return p.parseBinary(p.parseMultiplicative, func() string {
+		if c := p.peek(); c == '+' || c == '-' {
+			return string(c)
+		}
+		return ""
+	})
+}
+
+func (p *parser) parseMultiplicative() (Node, error) {
+	return p.parseBinary(p.parseUnary, func() string {
+		if c := p.peek(); c == '*' || c == '/' {
+			return string(c)
+		}
+		return ""
+	})
+}
+
+func (p *parser) parseBinary(next func() (Node, error), operator func() string) (Node, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		op := operator()
+		if op == "" {
+			return left, nil
+		}
+		pos := p.pos
+		p.pos += len(op)
+		p.skipSpace()
+		if p.pos == len(p.src) {
+			return nil, p.errorf("missing operand after %q", op)
+		}
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Pos: pos, Op: op, Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	p.skipSpace()
+	pos := p.pos
+	if p.peek() == '-' {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Pos: pos, Op: "-", X: x}, nil
+	}
+	if p.keyword("not") {
+		p.pos += 3
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Pos: pos, Op: "not", X: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	p.skipSpace()
+	pos := p.pos
+	c := p.peek()
+	switch {
+	case p.pos == len(p.src):
+		return nil, p.errorf("unexpected end of expression")
+	case c == '(':
+		p.pos++
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.peek() != ')' {
+			return nil, p.errorf("missing closing parenthesis for the one at position %d", pos+1)
+		}
+		p.pos++
+		return n, nil
+	case c == '"':
+		return p.parseString()
+	case c == '{':
+		return p.parseMacro()
+	case isDigit(c) || (c == '.' && p.pos+1 < len(p.src) && isDigit(p.src[p.pos+1])):
+		return p.parseNumber()
+	case isIdentStart(c):
+		start := p.pos
+		for p.pos < len(p.src) && isIdentChar(p.src[p.pos]) {
+			p.pos++
+		}
+		name := p.src[start:p.pos]
+		if p.peek() != '(' {
+			p.pos = start
+			return nil, p.errorf("unexpected identifier %q (functions need parentheses, strings need quotes)", name)
+		}
+		return p.parseCall(start, name)
+	case c == '/':
+		return nil, p.errorf("item query outside of a function")
+	default:
+		return nil, p.errorf("unexpected %q", string(c))
+	}
+}
+
+func (p *parser) parseCall(pos int, name string) (Node, error) {
+	p.pos++ // '('
+	call := &FunctionCall{Pos: pos, Name: name}
+	p.skipSpace()
+	if p.peek() == ')' {
+		p.pos++
+		return call, nil
+	}
+	for {
+		arg, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return call, nil
+		case 0:
+			return nil, p.errorf("missing closing parenthesis of %s()", name)
+		default:
+			return nil, p.errorf("expected ',' or ')' in arguments of %s(), found %q", name, string(p.peek()))
+		}
+	}
+}
+
+func (p *parser) parseArgument() (Node, error) {
+	p.skipSpace()
+	pos := p.pos
+	switch c := p.peek(); {
+	case c == ',' || c == ')':
+		return &EmptyArg{Pos: pos}, nil
+	case c == '/':
+		return p.parseItemQuery()
+	}
+
+	raw := p.scanArgument()
+	if strings.HasPrefix(raw, "#") || strings.Contains(raw, ":now") {
+		if err := validatePeriod(raw); err != nil {
+			return nil, &Error{Pos: pos, Msg: err.Error()}
+		}
+		p.pos += len(raw)
+		return &PeriodArg{Pos: pos, Value: raw}, nil
+	}
+	return p.parseOr()
+}
+
+// scanArgument returns the raw text of the function argument at the current position.
+func (p *parser) scanArgument() string {
+	depth := 0
+	inString := false
+	for i := p.pos; i < len(p.src); i++ {
+		c := p.src[i]
+		switch {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			if depth == 0 {
+				return strings.TrimSpace(p.src[p.pos:i])
+			}
+			depth--
+		case c == ',' && depth == 0:
+			return strings.TrimSpace(p.src[p.pos:i])
+		}
+	}
+	return strings.TrimSpace(p.src[p.pos:])
+}
+
+func validatePeriod(raw string) error {
+	value := raw
+	if i := strings.Index(raw, ":"); i >= 0 {
+		value = raw[:i]
+		shift := raw[i+1:]
+		if !strings.HasPrefix(shift, "now") {
+			return fmt.Errorf("invalid time shift %q (expected now, now-1h, now/d, ...)", shift)
+		}
+	}
+	if strings.HasPrefix(value, "#") {
+		n := value[1:]
+		if n == "" {
+			return fmt.Errorf("missing value count after '#'")
+		}
+		if strings.HasPrefix(n, "{") {
+			return nil
+		}
+		for i := 0; i < len(n); i++ {
+			if !isDigit(n[i]) {
+				return fmt.Errorf("invalid value count %q", value)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseItemQuery() (Node, error) {
+	pos := p.pos
+	p.pos++ // leading '/'
+	hostStart := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] != '/' {
+		if p.src[p.pos] == '{' {
+			if err := p.skipBraces(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if strings.ContainsRune(",)(", rune(p.src[p.pos])) {
+			return nil, p.errorf("item query needs the form /host/key")
+		}
+		p.pos++
+	}
+	if p.pos == len(p.src) {
+		return nil, &Error{Pos: pos, Msg: "item query needs the form /host/key"}
+	}
+	host := p.src[hostStart:p.pos]
+	p.pos++ // '/' between host and key
+
+	keyStart := p.pos
+	for p.pos < len(p.src) && (isKeyChar(p.src[p.pos]) || p.src[p.pos] == '{') {
+		if p.src[p.pos] == '{' {
+			if err := p.skipBraces(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		p.pos++
+	}
+	if p.pos == keyStart {
+		return nil, p.errorf("missing item key")
+	}
+	if p.peek() == '[' {
+		if err := p.skipBrackets(); err != nil {
+			return nil, err
+		}
+	}
+	query := &ItemQuery{Pos: pos, Host: host, Key: p.src[keyStart:p.pos]}
+
+	if strings.HasPrefix(p.src[p.pos:], "?[") {
+		p.pos++
+		filterStart := p.pos
+		if err := p.skipBrackets(); err != nil {
+			return nil, err
+		}
+		query.Filter = p.src[filterStart+1 : p.pos-1]
+	}
+	return query, nil
+}
+
+// skipBrackets moves past a [...] block, honouring nesting and quoted strings.
+func (p *parser) skipBrackets() error {
+	start := p.pos
+	depth := 0
+	inString := false
+	for ; p.pos < len(p.src); p.pos++ {
+		c := p.src[p.pos]
+		switch {
+		case inString:
+			if c == '\\' {
+				p.pos++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				p.pos++
+				return nil
+			}
+		}
+	}
+	return &Error{Pos: start, Msg: "unclosed '['"}
+}
+
+// skipBraces moves past a {...} macro, honouring nesting and quoted strings.
+func (p *parser) skipBraces() error {
+	start := p.pos
+	depth := 0
+	inString := false
+	for ; p.pos < len(p.src); p.pos++ {
+		c := p.src[p.pos]
+		switch {
+		case inString:
+			if c == '\\' {
+				p.pos++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				p.pos++
+				return nil
+			}
+		}
+	}
+	return &Error{Pos: start, Msg: "unclosed macro"}
+}
+
+func (p *parser) parseMacro() (Node, error) {
+	pos := p.pos
+	if err := p.skipBraces(); err != nil {
+		return nil, err
+	}
+	name := p.src[pos:p.pos]
+	if len(name) <= 2 {
+		return nil, &Error{Pos: pos, Msg: "empty macro"}
+	}
+	return &MacroRef{Pos: pos, Name: name}, nil
+}
+
+func (p *parser) parseString() (Node, error) {
+	pos := p.pos
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch c {
+		case '\\':
+			if p.pos+1 < len(p.src) && (p.src[p.pos+1] == '"' || p.src[p.pos+1] == '\\') {
+				b.WriteByte(p.src[p.pos+1])
+				p.pos += 2
+				continue
+			}
+			b.WriteByte(c)
+		case '"':
+			p.pos++
+			return &StringLit{Pos: pos, Value: b.String()}, nil
+		default:
+			b.WriteByte(c)
+		}
+		p.pos++
+	}
+	return nil, &Error{Pos: pos, Msg: "unterminated string"}
+}
+
+func (p *parser) parseNumber() (Node, error) {
+	start := p.pos
+	for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+		p.pos++
+	}
+	if p.peek() == '.' {
+		p.pos++
+		for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+			p.pos++
+		}
+	}
+	if c := p.peek(); c == 'e' || c == 'E' {
+		save := p.pos
+		p.pos++
+		if c := p.peek(); c == '+' || c == '-' {
+			p.pos++
+		}
+		if !isDigit(p.peek()) {
+			p.pos = save
+		}
+		for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+			p.pos++
+		}
+	}
+	if c := p.peek(); c != 0 && strings.IndexByte("smhdwKMGT", c) >= 0 {
+		p.pos++
+	}
+	if c := p.peek(); isIdentChar(c) {
+		return nil, p.errorf("invalid suffix %q on number %s (expected s, m, h, d, w, K, M, G or T)", string(c), p.src[start:p.pos])
+	}
+	return &NumberLit{Pos: start, Value: p.src[start:p.pos]}, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isKeyChar(c byte) bool {
+	return isIdentChar(c) || c == '.' || c == '-' || c == '*'
+}
@@ -0,0 +1,161 @@
+package expression
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// render prints a syntax tree as a compact s-expression for comparisons.
+func render(n Node) string {
+	switch v := n.(type) {
+	case *BinaryExpr:
+		return fmt.Sprintf("(%s %s %s)", v.Op, render(v.Left), render(v.Right))
+	case *UnaryExpr:
+		return fmt.Sprintf("(%s %s)", v.Op, render(v.X))
+	case *NumberLit:
+		return v.Value
+	case *StringLit:
+		return fmt.Sprintf("%q", v.Value)
+	case *MacroRef:
+		return v.Name
+	case *FunctionCall:
+		args := make([]string, len(v.Args))
+		for i, a := range v.Args {
+			args[i] = render(a)
+		}
+		return v.Name + "(" + strings.Join(args, ", ") + ")"
+	case *ItemQuery:
+		s := "/" + v.Host + "/" + v.Key
+		if v.Filter != "" {
+			s += "?[" + v.Filter + "]"
+		}
+		return s
+	case *PeriodArg:
+		return "period:" + v.Value
+	case *EmptyArg:
+		return "_"
+	}
+	return fmt.Sprintf("%T", n)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"function", `last(/web01/system.cpu.load)>5`, `(> last(/web01/system.cpu.load) 5)`},
+		{"period and shift", `avg(/h/k,5m:now-1d)`, `avg(/h/k, period:5m:now-1d)`},
+		{"value count", `min(/h/k,#3)=0`, `(= min(/h/k, period:#3) 0)`},
+		{"empty argument", `count(/h/k,,"eq","0")`, `count(/h/k, _, "eq", "0")`},
+		{"nested functions", `abs(last(/h/k)-last(/h/k,#2))`, `abs((- last(/h/k) last(/h/k, period:#2)))`},
+		{"nested parens", `((1+2))*3`, `(* (+ 1 2) 3)`},
+		{"parens override precedence", `1*(2+3)`, `(* 1 (+ 2 3))`},
+		{"precedence", `1+2*3>4 and 5<6 or 7=8`, `(or (and (> (+ 1 (* 2 3)) 4) (< 5 6)) (= 7 8))`},
+		{"left associative", `10-2-3`, `(- (- 10 2) 3)`},
+		{"comparison operators", `1<=2 and 3>=4 and 5<>6`, `(and (and (<= 1 2) (>= 3 4)) (<> 5 6))`},
+		{"unary", `-1+not 0`, `(+ (- 1) (not 0))`},
+		{"units", `last(/h/k)>10K or last(/h/k)<5m`, `(or (> last(/h/k) 10K) (< last(/h/k) 5m))`},
+		{"decimal", `last(/h/k)>0.5`, `(> last(/h/k) 0.5)`},
+		{"quoted escapes", `find(/h/k,,"regexp","a \"b\" \\d")=1`, `(= find(/h/k, _, "regexp", "a \"b\" \\d") 1)`},
+		{"key params", `last(/h/vfs.fs.size[/,pused])`, `last(/h/vfs.fs.size[/,pused])`},
+		{"key with spaces", `last(/My host/net.if.in["eth 0",bytes])`, `last(/My host/net.if.in["eth 0",bytes])`},
+		{"key with brackets", `last(/h/k[[a,b],"x]y"])`, `last(/h/k[[a,b],"x]y"])`},
+		{"item filter", `count_foreach(/*/k?[group="Linux servers"],5m)`, `count_foreach(/*/k?[group="Linux servers"], 5m)`},
+		{"macros", `last(/{HOST.HOST}/k)>{$MAX:"x"}`, `(> last(/{HOST.HOST}/k) {$MAX:"x"})`},
+		{"spaces", `  last( /h/k , #1 )  >  1 `, `(> last(/h/k, period:#1) 1)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.src, err)
+			}
+			if got := render(n); got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		pos int
+		msg string
+	}{
+		{``, 0, "empty expression"},
+		{`last(/h/k)>`, 11, "missing operand after \">\""},
+		{`(last(/h/k)>1`, 13, "missing closing parenthesis for the one at position 1"},
+		{`last(/h/k))`, 10, "unexpected"},
+		{`last(/h/k)>5x`, 12, "invalid suffix"},
+		{`foo>1`, 0, "functions need parentheses"},
+		{`/h/k>1`, 0, "item query outside of a function"},
+		{`last(/h)`, 7, "item query needs the form /host/key"},
+		{`last(/h/k[a,b)`, 9, "unclosed '['"},
+		{`last(/h/k)>{$M`, 11, "unclosed macro"},
+		{`find(/h/k,,"eq","a)`, 16, "unterminated string"},
+		{`avg(/h/k,1h:yesterday)`, 11, "expected ',' or ')'"},
+		{`avg(/h/k,1h:x:now)`, 9, "invalid time shift"},
+		{`last(/h/k,#)`, 10, "missing value count"},
+		{`last(/h/k,#x)`, 10, "invalid value count"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := Parse(tt.src)
+			if err == nil {
+				t.Fatalf("Parse(%q) succeeded, want error %q", tt.src, tt.msg)
+			}
+			e, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("Parse(%q) error %T, want *Error", tt.src, err)
+			}
+			if !strings.Contains(e.Msg, tt.msg) || e.Pos != tt.pos {
+				t.Errorf("Parse(%q) = %q at %d, want %q at %d", tt.src, e.Msg, e.Pos, tt.msg, tt.pos)
+			}
+		})
+	}
+}
+
+func TestItemQueries(t *testing.T) {
+	n, err := Parse(`last(/a/k1)>0 or count(/b/k2[x, "y z"]?[tag="t"],5m)>1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, q := range ItemQueries(n) {
+		got = append(got, q.Host+"|"+q.Key+"|"+q.Filter)
+	}
+	want := []string{`a|k1|`, `b|k2[x, "y z"]|tag="t"`}
+	if strings.Join(got, ";") != strings.Join(want, ";") {
+		t.Errorf("ItemQueries = %q, want %q", got, want)
+	}
+}
+
+func TestNormalizeKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{`system.uptime`, `system.uptime`},
+		{`key[a,b]`, `key[a,b]`},
+		{`key[a, b]`, `key[a,b]`},
+		{`key[ a , b ]`, `key[a,b]`},
+		{`key["a","b"]`, `key[a,b]`},
+		{`key[,]`, `key[,]`},
+		{`key["a,b", c]`, `key["a,b",c]`},
+		{`key["x]y"]`, `key["x]y"]`},
+		{`key["say \"hi\""]`, `key["say \"hi\""]`},
+		{`key[" a"]`, `key[" a"]`},
+		{`key[[a, "b"], c]`, `key[[a,b],c]`},
+		{`key["[a]"]`, `key["[a]"]`},
+		{`key[a`, `key[a`},
+		{`key["a]`, `key["a]`},
+	}
+	for _, tt := range tests {
+		if got := NormalizeKey(tt.key); got != tt.want {
+			t.Errorf("NormalizeKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
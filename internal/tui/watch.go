@@ -0,0 +1,348 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	watchHeaderStyle   = lipgloss.NewStyle().Foreground(zabbixWhite).Background(zabbixRed).Bold(true)
+	watchNewStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("#4ec94e")).Bold(true)
+	watchChangedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#e0c341"))
+	watchResolvedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#777777")).Strikethrough(true)
+	watchCursorStyle   = lipgloss.NewStyle().Background(zabbixGray)
+	watchStatusStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+	watchErrorStyle    = lipgloss.NewStyle().Foreground(zabbixRed).Bold(true)
+)
+
+// WatchRow is one row of a watch screen. Key identifies the row across refreshes.
+type WatchRow struct {
+	Key      string
+	Cells    []string
+	Severity int // -1 when the row has no severity
+	Host     string
+}
+
+// WatchConfig describes what a watch screen shows and how it refreshes.
+type WatchConfig struct {
+	Title       string
+	Headers     []string
+	Interval    time.Duration
+	Fetch       func() ([]WatchRow, error)
+	Acknowledge func(row WatchRow) error // nil disables the acknowledge binding
+}
+
+type watchState int
+
+const (
+	watchStateSame watchState = iota
+	watchStateNew
+	watchStateChanged
+	watchStateResolved
+)
+
+type watchEntry struct {
+	row   WatchRow
+	state watchState
+}
+
+type watchTickMsg time.Time
+
+type watchResultMsg struct {
+	rows []WatchRow
+	err  error
+}
+
+type watchAckMsg struct {
+	key string
+	err error
+}
+
+type watchModel struct {
+	cfg         WatchConfig
+	entries     []watchEntry
+	seen        bool
+	cursor      int
+	minSeverity int
+	hostFilter  string
+	lastUpdate  time.Time
+	status      string
+	err         error
+	height      int
+	width       int
+}
+
+// Watch runs a full-screen view that refreshes cfg.Fetch every cfg.Interval and highlights
+// rows that appeared, changed or disappeared since the previous poll.
+func Watch(cfg WatchConfig) error {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	m := watchModel{cfg: cfg, height: 24, width: 120}
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+func (m watchModel) Init() tea.Cmd {
+	return m.fetch()
+}
+
+func (m watchModel) fetch() tea.Cmd {
+	return func() tea.Msg {
+		rows, err := m.cfg.Fetch()
+		return watchResultMsg{rows: rows, err: err}
+	}
+}
+
+func (m watchModel) tick() tea.Cmd {
+	return tea.Tick(m.cfg.Interval, func(t time.Time) tea.Msg { return watchTickMsg(t) })
+}
+
+func (m watchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.width = msg.Width
+		return m, nil
+
+	case watchTickMsg:
+		return m, m.fetch()
+
+	case watchResultMsg:
+		m.err = msg.err
+		if msg.err == nil {
+			m.merge(msg.rows)
+			m.lastUpdate = time.Now()
+		}
+		return m, m.tick()
+
+	case watchAckMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Acknowledge failed: %v", msg.err)
+		} else {
+			m.status = fmt.Sprintf("Acknowledged %s", msg.key)
+		}
+		return m, m.fetch()
+
+	case tea.KeyMsg:
+		visible := m.visible()
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(visible)-1 {
+				m.cursor++
+			}
+		case "r":
+			m.status = "Refreshing..."
+			return m, m.fetch()
+		case "s":
+			m.minSeverity = (m.minSeverity + 1) % 6
+			m.cursor = 0
+		case "h", "enter":
+			if m.hostFilter != "" {
+				m.hostFilter = ""
+			} else if m.cursor < len(visible) {
+				m.hostFilter = visible[m.cursor].row.Host
+			}
+			m.cursor = 0
+		case "a":
+			if m.cfg.Acknowledge == nil {
+				m.status = "Acknowledge is not available on this screen"
+			} else if m.cursor < len(visible) {
+				row := visible[m.cursor].row
+				ack := m.cfg.Acknowledge
+				m.status = fmt.Sprintf("Acknowledging %s...", row.Key)
+				return m, func() tea.Msg {
+					return watchAckMsg{key: row.Key, err: ack(row)}
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// merge applies a new poll result. Existing rows keep their position, new rows are
+// inserted at the top and rows that disappeared stay visible as resolved for one poll.
+func (m *watchModel) merge(rows []WatchRow) {
+	current := make(map[string]WatchRow)
+	for _, r := range rows {
+		current[r.Key] = r
+	}
+
+	var fresh []watchEntry
+	if m.seen {
+		existing := make(map[string]bool)
+		for _, e := range m.entries {
+			existing[e.row.Key] = true
+		}
+		for _, r := range rows {
+			if !existing[r.Key] {
+				fresh = append(fresh, watchEntry{row: r, state: watchStateNew})
+			}
+		}
+	} else {
+		for _, r := range rows {
+			fresh = append(fresh, watchEntry{row: r, state: watchStateSame})
+		}
+	}
+
+	var kept []watchEntry
+	for _, e := range m.entries {
+		if e.state == watchStateResolved {
+			continue
+		}
+		r, ok := current[e.row.Key]
+		if !ok {
+			kept = append(kept, watchEntry{row: e.row, state: watchStateResolved})
+			continue
+		}
+		state := watchStateSame
+		if strings.Join(r.Cells, "\x00") != strings.Join(e.row.Cells, "\x00") {
+			state = watchStateChanged
+		}
+		kept = append(kept, watchEntry{row: r, state: state})
+	}
+
+	m.entries = append(fresh, kept...)
+	m.seen = true
+	if visible := m.visible(); m.cursor >= len(visible) {
+		m.cursor = len(visible) - 1
+		if m.cursor < 0 {
+			m.cursor = 0
+		}
+	}
+}
+
+func (m watchModel) visible() []watchEntry {
+	var out []watchEntry
+	for _, e := range m.entries {
+		if e.row.Severity >= 0 && e.row.Severity < m.minSeverity {
+			continue
+		}
+		if m.hostFilter != "" && e.row.Host != m.hostFilter {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func (m watchModel) View() string {
+	var b strings.Builder
+
+	title := fmt.Sprintf(" %s ", m.cfg.Title)
+	info := fmt.Sprintf("every %s", m.cfg.Interval)
+	if !m.lastUpdate.IsZero() {
+		info += fmt.Sprintf(" | updated %s", m.lastUpdate.Format("15:04:05"))
+	}
+	if m.minSeverity > 0 {
+		info += fmt.Sprintf(" | severity >= %d", m.minSeverity)
+	}
+	if m.hostFilter != "" {
+		info += fmt.Sprintf(" | host: %s", m.hostFilter)
+	}
+	b.WriteString(watchHeaderStyle.Render(title) + " " + watchStatusStyle.Render(info) + "\n\n")
+
+	visible := m.visible()
+	widths := make([]int, len(m.cfg.Headers))
+	for i, h := range m.cfg.Headers {
+		widths[i] = lipgloss.Width(h)
+	}
+	for _, e := range visible {
+		for i, c := range e.row.Cells {
+			if i < len(widths) && lipgloss.Width(c) > widths[i] {
+				widths[i] = lipgloss.Width(c)
+			}
+		}
+	}
+	// Shrink the widest column until the table fits the terminal
+	for watchTableWidth(widths) > m.width && m.width > 0 {
+		widest := 0
+		for i := range widths {
+			if widths[i] > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= 8 {
+			break
+		}
+		widths[widest]--
+	}
+
+	b.WriteString(watchHeaderStyle.Render(watchLine(m.cfg.Headers, widths)) + "\n")
+
+	maxRows := m.height - 8
+	if maxRows < 1 {
+		maxRows = 1
+	}
+	offset := 0
+	if m.cursor >= maxRows {
+		offset = m.cursor - maxRows + 1
+	}
+	for i := offset; i < len(visible) && i < offset+maxRows; i++ {
+		e := visible[i]
+		line := watchLine(e.row.Cells, widths)
+		switch e.state {
+		case watchStateNew:
+			line = watchNewStyle.Render(line)
+		case watchStateChanged:
+			line = watchChangedStyle.Render(line)
+		case watchStateResolved:
+			line = watchResolvedStyle.Render(line)
+		}
+		if i == m.cursor {
+			line = watchCursorStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(visible) == 0 {
+		b.WriteString(watchStatusStyle.Render("  No results.") + "\n")
+	}
+
+	b.WriteString("\n")
+	if m.err != nil {
+		b.WriteString(watchErrorStyle.Render(fmt.Sprintf("Error: %v", m.err)) + "\n")
+	} else if m.status != "" {
+		b.WriteString(watchStatusStyle.Render(m.status) + "\n")
+	}
+	help := "↑/↓ move • s severity • h host • r refresh • q quit"
+	if m.cfg.Acknowledge != nil {
+		help = "↑/↓ move • a ack • s severity • h host • r refresh • q quit"
+	}
+	b.WriteString(helpStyle.Render(help))
+	return b.String()
+}
+
+func watchLine(cells []string, widths []int) string {
+	parts := make([]string, len(widths))
+	for i, w := range widths {
+		c := ""
+		if i < len(cells) {
+			c = cells[i]
+		}
+		// Truncate by display width, double-width runes included.
+		if lipgloss.Width(c) > w {
+			c = ansi.Truncate(c, w, "…")
+		}
+		parts[i] = c + strings.Repeat(" ", max(0, w-lipgloss.Width(c)))
+	}
+	return " " + strings.Join(parts, "  ") + " "
+}
+
+func watchTableWidth(widths []int) int {
+	total := 2
+	for _, w := range widths {
+		total += w + 2
+	}
+	return total
+}